@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mostlydev/clawdapus/internal/audit"
+	"github.com/mostlydev/clawdapus/internal/clawdash"
+)
+
+var (
+	auditLast         string
+	auditModel        string
+	auditType         string
+	auditIntervention string
+	auditFrom         string
+	auditJSON         bool
+	auditCSV          bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [service]",
+	Short: "Show cllama request, response and intervention history",
+	Long: `Read the structured JSON audit logs the pod's cllama proxies write to stdout
+(or a persisted sink with --from) and list the records for one agent or the
+whole pod. A service name matches the replicas the pod manifest lists for it;
+a replica name such as bot-0 matches only that replica. Without a manifest the
+name must equal the record's claw_id.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podDir, err := resolvePodDir()
+		if err != nil {
+			return err
+		}
+		opts := auditOptions{
+			last:         auditLast,
+			model:        auditModel,
+			recordType:   auditType,
+			intervention: auditIntervention,
+			from:         auditFrom,
+		}
+		if len(args) == 1 {
+			opts.agent = args[0]
+		}
+		switch {
+		case auditJSON && auditCSV:
+			return fmt.Errorf("--json and --csv are mutually exclusive")
+		case auditJSON:
+			opts.format = "json"
+		case auditCSV:
+			opts.format = "csv"
+		}
+		return runAudit(os.Stdout, podDir, opts)
+	},
+}
+
+type auditOptions struct {
+	agent        string
+	last         string
+	model        string
+	recordType   string
+	intervention string
+	from         string
+	format       string // table (default), json or csv
+}
+
+// auditProxyLogs returns the stdout of a proxy service since the given
+// window. Tests replace it.
+var auditProxyLogs = func(composePath, service string, since time.Duration) ([]byte, error) {
+	args := []string{"compose", "-f", composePath, "logs", "--no-color", "--no-log-prefix"}
+	if since > 0 {
+		args = append(args, "--since", since.String())
+	}
+	args = append(args, service)
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker compose logs %s: %w: %s", service, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
+
+// auditNow is the reference time for --last; tests replace it.
+var auditNow = time.Now
+
+func runAudit(w io.Writer, podDir string, opts auditOptions) error {
+	window, err := parseSince(opts.last)
+	if err != nil {
+		return fmt.Errorf("--last: %w", err)
+	}
+	switch opts.recordType {
+	case "", audit.TypeRequest, audit.TypeResponse, audit.TypeIntervention, audit.TypeDriftScore, audit.TypeError:
+	default:
+		return fmt.Errorf("--type: expected request, response, intervention, drift_score or error, got %q", opts.recordType)
+	}
+
+	var manifest *clawdash.PodManifest
+	manifestPath := filepath.Join(podDir, ".claw-runtime", "pod-manifest.json")
+	if _, err := os.Stat(manifestPath); err == nil {
+		if manifest, err = loadPodManifest(manifestPath); err != nil {
+			return err
+		}
+	}
+	var agents []string
+	if opts.agent != "" {
+		agents = []string{opts.agent}
+		if manifest != nil {
+			if agents = manifestAgentIDs(manifest, opts.agent); len(agents) == 0 {
+				return fmt.Errorf("service %q is not a claw service in pod %q", opts.agent, manifest.PodName)
+			}
+		}
+	}
+
+	var records []audit.Record
+	if opts.from != "" {
+		if records, err = audit.Load(opts.from); err != nil {
+			return err
+		}
+	} else {
+		if manifest == nil {
+			return fmt.Errorf("no pod manifest at %s (run 'claw up' first, or read a saved log with --from)", manifestPath)
+		}
+		if len(manifest.Proxies) == 0 {
+			return fmt.Errorf("pod %q has no cllama proxies to audit", manifest.PodName)
+		}
+		composePath := filepath.Join(podDir, "compose.generated.yml")
+		for _, proxy := range manifest.Proxies {
+			out, err := auditProxyLogs(composePath, proxy.ServiceName, window)
+			if err != nil {
+				return err
+			}
+			proxyRecords, err := audit.Read(bytes.NewReader(out), proxy.ServiceName)
+			if err != nil {
+				return err
+			}
+			records = append(records, proxyRecords...)
+		}
+	}
+
+	filter := audit.Filter{
+		Agents:       agents,
+		Model:        opts.model,
+		Type:         opts.recordType,
+		Intervention: opts.intervention,
+	}
+	if window > 0 {
+		filter.Since = auditNow().Add(-window)
+	}
+	records = filter.Apply(records)
+
+	switch opts.format {
+	case "json":
+		return writeAuditJSON(w, records)
+	case "csv":
+		return writeAuditCSV(w, records)
+	default:
+		return writeAuditTable(w, records)
+	}
+}
+
+// manifestAgentIDs returns the claw_ids agent stands for in the pod: every
+// replica of a claw service, or a single replica. It returns nil when agent
+// names neither.
+func manifestAgentIDs(manifest *clawdash.PodManifest, agent string) []string {
+	for name, svc := range manifest.Services {
+		if svc.ClawType == "" {
+			continue
+		}
+		replicas := expandedServiceNames(name, svc.Count)
+		if name == agent {
+			return replicas
+		}
+		for _, replica := range replicas {
+			if replica == agent {
+				return []string{agent}
+			}
+		}
+	}
+	return nil
+}
+
+var auditColumns = []string{"time", "proxy", "claw_id", "type", "model", "status", "latency_ms", "intervention", "reason"}
+
+func auditRow(r audit.Record) []string {
+	status := ""
+	if r.StatusCode != 0 {
+		status = strconv.Itoa(r.StatusCode)
+	}
+	latency := ""
+	if r.LatencyMS != 0 {
+		latency = strconv.FormatInt(r.LatencyMS, 10)
+	}
+	reason := r.Reason
+	if r.DriftScore != nil {
+		reason = "drift " + strconv.FormatFloat(*r.DriftScore, 'f', -1, 64)
+	}
+	if r.Error != "" {
+		reason = r.Error
+	}
+	return []string{r.Time.Format(time.RFC3339), r.Proxy, r.ClawID, r.Type, r.Model, status, latency, r.Intervention, reason}
+}
+
+func writeAuditTable(w io.Writer, records []audit.Record) error {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No audit records matched.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tPROXY\tAGENT\tTYPE\tMODEL\tSTATUS\tLATENCY\tINTERVENTION\tREASON\t")
+	for _, r := range records {
+		row := auditRow(r)
+		if row[6] != "" {
+			row[6] += "ms"
+		}
+		for _, v := range row {
+			fmt.Fprintf(tw, "%s\t", dashIfEmpty(v))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func writeAuditJSON(w io.Writer, records []audit.Record) error {
+	if records == nil {
+		records = []audit.Record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeAuditCSV(w io.Writer, records []audit.Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(auditColumns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write(auditRow(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditLast, "last", "", "Only show records within this window (e.g. 24h, 7d)")
+	auditCmd.Flags().StringVar(&auditModel, "model", "", "Only show records for this model (with or without provider prefix)")
+	auditCmd.Flags().StringVar(&auditType, "type", "", "Only show records of this type (request, response, intervention, drift_score, error)")
+	auditCmd.Flags().StringVar(&auditIntervention, "intervention", "", "Only show records carrying an intervention of this type (\"any\" for all)")
+	auditCmd.Flags().StringVar(&auditFrom, "from", "", "Read a persisted audit sink (JSONL file or directory) instead of proxy container logs")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "Print records as JSON")
+	auditCmd.Flags().BoolVar(&auditCSV, "csv", false, "Print records as CSV")
+	rootCmd.AddCommand(auditCmd)
+}
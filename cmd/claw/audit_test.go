@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/audit"
+)
+
+const auditManifest = `{
+  "podName": "desk",
+  "services": {
+    "crusher": {"clawType": "openclaw", "imageRef": "crusher:latest", "count": 3},
+    "redis": {"imageRef": "redis:7", "count": 1}
+  },
+  "proxies": [
+    {"proxyType": "passthrough", "serviceName": "cllama", "image": "cllama:latest"},
+    {"proxyType": "policy", "serviceName": "cllama-policy", "image": "policy:latest"}
+  ]
+}`
+
+func stubAuditLogs(t *testing.T, logs map[string]string) *[]string {
+	t.Helper()
+	prevLogs, prevNow := auditProxyLogs, auditNow
+	t.Cleanup(func() {
+		auditProxyLogs, auditNow = prevLogs, prevNow
+	})
+	var calls []string
+	auditProxyLogs = func(composePath, service string, since time.Duration) ([]byte, error) {
+		calls = append(calls, service+" "+since.String())
+		return []byte(logs[service]), nil
+	}
+	auditNow = func() time.Time { return time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) }
+	return &calls
+}
+
+func TestRunAuditMergesProxyLogsAndFilters(t *testing.T) {
+	podDir := t.TempDir()
+	writePlanFile(t, filepath.Join(podDir, ".claw-runtime", "pod-manifest.json"), auditManifest)
+	calls := stubAuditLogs(t, map[string]string{
+		"cllama": `{"ts":"2026-03-02T10:00:00Z","claw_id":"crusher-2","type":"response","model":"openai/gpt-4o","status_code":200,"latency_ms":900}
+{"ts":"2026-03-01T10:00:00Z","claw_id":"crusher-2","type":"request","model":"openai/gpt-4o"}
+{"ts":"2026-03-02T09:00:00Z","claw_id":"crusher-1","type":"request","model":"openai/gpt-4o"}
+`,
+		"cllama-policy": `{"ts":"2026-03-02T08:00:00Z","claw_id":"crusher-2","type":"intervention","intervention":"output_modified","intervention_reason":"financial advice detected"}
+{"ts":"2026-03-02T08:30:00Z","claw_id":"crusher-12","type":"intervention","intervention":"tool_dropped","intervention_reason":"not a replica"}
+`,
+	})
+
+	var buf bytes.Buffer
+	if err := runAudit(&buf, podDir, auditOptions{agent: "crusher-2", last: "24h", format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(*calls, ",") != "cllama 24h0m0s,cllama-policy 24h0m0s" {
+		t.Fatalf("unexpected log calls: %v", *calls)
+	}
+	var records []audit.Record
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(records) != 2 || records[0].Proxy != "cllama-policy" || records[1].Type != "response" {
+		t.Fatalf("expected the replica's records within the window, oldest first: %+v", records)
+	}
+
+	buf.Reset()
+	if err := runAudit(&buf, podDir, auditOptions{agent: "crusher", intervention: "output-modified"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "output_modified") || !strings.Contains(out, "financial advice detected") || strings.Contains(out, "request") {
+		t.Fatalf("unexpected table:\n%s", out)
+	}
+	if strings.Contains(out, "not a replica") {
+		t.Fatalf("expected crusher-12 to be outside crusher's three replicas:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runAudit(&buf, podDir, auditOptions{model: "gpt-4o", format: "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != strings.Join(auditColumns, ",") || !strings.HasSuffix(lines[3], ",200,900,,") {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestRunAuditRejectsUnknownAgentAndReadsSink(t *testing.T) {
+	podDir := t.TempDir()
+	writePlanFile(t, filepath.Join(podDir, ".claw-runtime", "pod-manifest.json"), auditManifest)
+	stubAuditLogs(t, nil)
+
+	for _, agent := range []string{"redis", "crusher-3", "nope"} {
+		if err := runAudit(&bytes.Buffer{}, podDir, auditOptions{agent: agent}); err == nil {
+			t.Fatalf("expected %q to be rejected", agent)
+		}
+	}
+
+	sink := filepath.Join(t.TempDir(), "audit.jsonl")
+	writePlanFile(t, sink, `{"ts":"2026-03-02T11:00:00Z","claw_id":"crusher-0","type":"drift_score","drift_score":0.31}`+"\n")
+	var buf bytes.Buffer
+	if err := runAudit(&buf, t.TempDir(), auditOptions{from: sink}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "drift 0.31") {
+		t.Fatalf("expected sink record in table:\n%s", buf.String())
+	}
+	if err := runAudit(&bytes.Buffer{}, t.TempDir(), auditOptions{}); err == nil {
+		t.Fatal("expected missing manifest without --from to fail")
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mostlydev/clawdapus/internal/clawfile"
+	"github.com/mostlydev/clawdapus/internal/pod"
+	"github.com/mostlydev/clawdapus/internal/recipe"
+)
+
+var (
+	bakeFromRecipe string
+	bakeTag        string
+	bakeDryRun     bool
+)
+
+var bakeCmd = &cobra.Command{
+	Use:   "bake <service>",
+	Short: "Promote a recipe into a service's Clawfile and rebuild its image",
+	Long: "Inserts the RUN steps of a recipe produced by 'claw recipe' into the service's\n" +
+		"Clawfile, rebuilds the image, and optionally points claw-pod.yml at a new tag.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podFile := composePodFile
+		if podFile == "" {
+			podFile = "claw-pod.yml"
+		}
+		absPodFile, err := filepath.Abs(podFile)
+		if err != nil {
+			return fmt.Errorf("resolve pod file %q: %w", podFile, err)
+		}
+		return runBake(absPodFile, args[0], bakeOptions{
+			FromRecipe: bakeFromRecipe,
+			Tag:        bakeTag,
+			DryRun:     bakeDryRun,
+		})
+	},
+}
+
+type bakeOptions struct {
+	FromRecipe string // "latest" or a recipe file path
+	Tag        string // new image ref; rewrites services.<svc>.image when set
+	DryRun     bool
+}
+
+func runBake(podFile, service string, opts bakeOptions) error {
+	if strings.TrimSpace(opts.FromRecipe) == "" {
+		return fmt.Errorf("--from-recipe is required (use 'latest' or a recipe file path)")
+	}
+
+	data, err := os.ReadFile(podFile)
+	if err != nil {
+		return fmt.Errorf("read pod file: %w", err)
+	}
+	p, err := pod.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	podDir := filepath.Dir(podFile)
+
+	if _, ok := p.Services[service]; !ok {
+		if base := replicaSuffixPattern.ReplaceAllString(service, ""); base != service {
+			if _, ok := p.Services[base]; ok {
+				service = base
+			}
+		}
+	}
+	svc, ok := p.Services[service]
+	if !ok {
+		return fmt.Errorf("service %q not found in %s", service, filepath.Base(podFile))
+	}
+	if svc.Claw == nil {
+		return fmt.Errorf("service %q is not claw-managed (no x-claw block)", service)
+	}
+
+	cfg, err := parseServiceBuildConfig(svc.Compose["build"])
+	if err != nil {
+		return fmt.Errorf("service %q: parse build: %w", service, err)
+	}
+	if cfg == nil {
+		return fmt.Errorf("service %q: no build config declared; claw bake needs the service's Clawfile", service)
+	}
+	contextDir := cfg.Context
+	if !filepath.IsAbs(contextDir) {
+		contextDir = filepath.Join(podDir, contextDir)
+	}
+	clawfilePath, err := resolveBuildDockerfilePath(contextDir, cfg.Dockerfile)
+	if err != nil {
+		return fmt.Errorf("service %q: %w", service, err)
+	}
+	if !isClawBuildFile(clawfilePath) {
+		return fmt.Errorf("service %q: %s is not a Clawfile", service, clawfilePath)
+	}
+
+	recipePath := opts.FromRecipe
+	if recipePath == "latest" {
+		recipePath, err = recipe.Latest(recipeDir(podDir, service))
+		if err != nil {
+			return fmt.Errorf("service %q: %w", service, err)
+		}
+	}
+	r, err := recipe.Load(recipePath)
+	if err != nil {
+		return err
+	}
+	if r.Empty() {
+		return fmt.Errorf("recipe %s promotes nothing", recipePath)
+	}
+
+	source, err := os.ReadFile(clawfilePath)
+	if err != nil {
+		return fmt.Errorf("read clawfile: %w", err)
+	}
+	baked, inserted, err := clawfile.InsertSteps(source, r.RunLines(), clawfile.PromotedBlockMarker+" from "+filepath.Base(recipePath))
+	if err != nil {
+		return fmt.Errorf("service %q: %w", service, err)
+	}
+
+	imageRef := strings.TrimSpace(opts.Tag)
+	if imageRef == "" {
+		imageRef = strings.TrimSpace(svc.Image)
+	}
+	if imageRef == "" {
+		imageRef = managedServiceImageRef(p.Name, service)
+	}
+	updatePod := strings.TrimSpace(opts.Tag) != "" && strings.TrimSpace(opts.Tag) != strings.TrimSpace(svc.Image)
+
+	relClawfile := displayPath(podDir, clawfilePath)
+	changed := !bytes.Equal(baked, source)
+	switch {
+	case !changed:
+		fmt.Printf("[claw] %s: %s already contains every promoted step\n", service, relClawfile)
+	case inserted == 0:
+		fmt.Printf("[claw] %s: replacing the promoted block in %s\n", service, relClawfile)
+	default:
+		fmt.Printf("[claw] %s: promoting %d step(s) into %s\n", service, inserted, relClawfile)
+	}
+	if opts.DryRun {
+		for _, line := range r.RunLines() {
+			fmt.Printf("  + %s\n", line)
+		}
+		fmt.Printf("[claw] %s: would build %s\n", service, imageRef)
+		if updatePod {
+			fmt.Printf("[claw] %s: would set image: %s in %s\n", service, imageRef, filepath.Base(podFile))
+		}
+		return nil
+	}
+
+	if changed {
+		if err := os.WriteFile(clawfilePath, baked, 0o644); err != nil {
+			return fmt.Errorf("write clawfile: %w", err)
+		}
+	}
+
+	generatedPath, err := generateClawDockerfile(clawfilePath)
+	if err != nil {
+		return fmt.Errorf("generate Dockerfile from %q: %w", clawfilePath, err)
+	}
+	if err := buildGeneratedImage(generatedPath, imageRef); err != nil {
+		return fmt.Errorf("build image %q from %q: %w", imageRef, generatedPath, err)
+	}
+	fmt.Printf("[claw] %s: built %s\n", service, imageRef)
+
+	if updatePod {
+		if err := setPodServiceImage(podFile, data, service, imageRef); err != nil {
+			return err
+		}
+		fmt.Printf("[claw] %s: set image: %s in %s\n", service, imageRef, filepath.Base(podFile))
+	}
+	fmt.Println("[claw] run 'claw up' to roll the pod onto the baked image")
+	return nil
+}
+
+// setPodServiceImage rewrites services.<service>.image through the YAML AST so
+// comments and key order in claw-pod.yml are preserved.
+func setPodServiceImage(podFile string, data []byte, service, imageRef string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse pod YAML AST: %w", err)
+	}
+	root, err := podRootMap(&doc)
+	if err != nil {
+		return err
+	}
+	serviceNode := findMapValue(findMapValue(root, "services"), service)
+	if serviceNode == nil || serviceNode.Kind != yaml.MappingNode {
+		return fmt.Errorf("service %q not found in pod YAML", service)
+	}
+	if imageNode := findMapValue(serviceNode, "image"); imageNode != nil && imageNode.Kind == yaml.ScalarNode {
+		imageNode.Value = imageRef
+	} else {
+		setMapValue(serviceNode, "image", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: imageRef})
+	}
+
+	out, err := marshalYAMLDocument(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(podFile, out, 0o644); err != nil {
+		return fmt.Errorf("write pod file: %w", err)
+	}
+	return nil
+}
+
+func displayPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+func init() {
+	bakeCmd.Flags().StringVar(&bakeFromRecipe, "from-recipe", "", "Recipe to promote ('latest' or a path to a recipe file)")
+	bakeCmd.Flags().StringVarP(&bakeTag, "tag", "t", "", "Build under this image ref and point claw-pod.yml at it")
+	bakeCmd.Flags().BoolVar(&bakeDryRun, "dry-run", false, "Print planned changes without writing files or building")
+	rootCmd.AddCommand(bakeCmd)
+}
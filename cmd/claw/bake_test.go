@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/recipe"
+)
+
+func writeBakeFixture(t *testing.T) (podFile string, clawfilePath string) {
+	t.Helper()
+	podDir := t.TempDir()
+	agentDir := filepath.Join(podDir, "agents", "crusher")
+	if err := os.MkdirAll(agentDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	clawfilePath = filepath.Join(agentDir, "Clawfile")
+	if err := os.WriteFile(clawfilePath, []byte("FROM python:3.12-slim\nCLAW_TYPE generic\nTRACK pip\nCMD [\"python\", \"-m\", \"agent\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	podFile = filepath.Join(podDir, "claw-pod.yml")
+	pod := `x-claw:
+  pod: desk
+
+services:
+  crusher:
+    # pinned by the operator
+    image: desk-crusher:v1
+    build:
+      context: ./agents/crusher
+    x-claw:
+      agent: ./AGENTS.md
+`
+	if err := os.WriteFile(podFile, []byte(pod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &recipe.Recipe{
+		Service:     "crusher",
+		GeneratedAt: time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC),
+		Packages:    map[string][]recipe.Package{"pip": {{Name: "tiktoken", Version: "0.7.0"}}},
+	}
+	if _, err := recipe.Save(recipeDir(podDir, "crusher"), r); err != nil {
+		t.Fatal(err)
+	}
+	return podFile, clawfilePath
+}
+
+func stubBakeBuild(t *testing.T, wantClawfile string) *string {
+	t.Helper()
+	prevGenerate := generateClawDockerfile
+	prevBuildGenerated := buildGeneratedImage
+	t.Cleanup(func() {
+		generateClawDockerfile = prevGenerate
+		buildGeneratedImage = prevBuildGenerated
+	})
+
+	generatedPath := filepath.Join(filepath.Dir(wantClawfile), "Dockerfile.generated")
+	generateClawDockerfile = func(path string) (string, error) {
+		if path != wantClawfile {
+			t.Fatalf("expected Clawfile path %q, got %q", wantClawfile, path)
+		}
+		return generatedPath, nil
+	}
+	var builtTag string
+	buildGeneratedImage = func(path, tag string) error {
+		if path != generatedPath {
+			t.Fatalf("expected generated path %q, got %q", generatedPath, path)
+		}
+		builtTag = tag
+		return nil
+	}
+	return &builtTag
+}
+
+func TestRunBakePromotesLatestRecipeAndBumpsPodImage(t *testing.T) {
+	podFile, clawfilePath := writeBakeFixture(t)
+	builtTag := stubBakeBuild(t, clawfilePath)
+
+	if err := runBake(podFile, "crusher-0", bakeOptions{FromRecipe: "latest", Tag: "desk-crusher:v2"}); err != nil {
+		t.Fatalf("runBake: %v", err)
+	}
+
+	if *builtTag != "desk-crusher:v2" {
+		t.Fatalf("expected build tag desk-crusher:v2, got %q", *builtTag)
+	}
+	clawfileOut, err := os.ReadFile(clawfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(clawfileOut), "CMD [\"python\", \"-m\", \"agent\"]\n# promoted by claw bake from 20260302T101500Z.json\nRUN pip install --no-cache-dir tiktoken==0.7.0\n") {
+		t.Fatalf("expected promoted step after final instruction:\n%s", clawfileOut)
+	}
+
+	podOut, err := os.ReadFile(podFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(podOut), "image: desk-crusher:v2") {
+		t.Fatalf("expected bumped image tag:\n%s", podOut)
+	}
+	if !strings.Contains(string(podOut), "# pinned by the operator") {
+		t.Fatalf("expected pod comments to be preserved:\n%s", podOut)
+	}
+}
+
+func TestRunBakeDryRunWritesNothing(t *testing.T) {
+	podFile, clawfilePath := writeBakeFixture(t)
+	builtTag := stubBakeBuild(t, clawfilePath)
+	before, err := os.ReadFile(clawfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runBake(podFile, "crusher", bakeOptions{FromRecipe: "latest", Tag: "desk-crusher:v2", DryRun: true}); err != nil {
+		t.Fatalf("runBake: %v", err)
+	}
+
+	after, err := os.ReadFile(clawfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Fatalf("expected Clawfile unchanged in dry run:\n%s", after)
+	}
+	if *builtTag != "" {
+		t.Fatalf("expected no build in dry run, got %q", *builtTag)
+	}
+}
+
+func TestRunBakeRequiresRecipe(t *testing.T) {
+	podFile, _ := writeBakeFixture(t)
+	err := runBake(podFile, "crusher", bakeOptions{})
+	if err == nil || !strings.Contains(err.Error(), "--from-recipe is required") {
+		t.Fatalf("expected missing recipe error, got %v", err)
+	}
+}
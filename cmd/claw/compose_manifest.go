@@ -74,17 +74,37 @@ func buildPodManifest(p *pod.Pod, resolved map[string]*driver.ResolvedClaw, prox
 	if len(proxies) > 0 {
 		out.Proxies = make([]clawdash.ProxyManifest, 0, len(proxies))
 		for _, proxy := range proxies {
-			out.Proxies = append(out.Proxies, clawdash.ProxyManifest{
+			entry := clawdash.ProxyManifest{
 				ProxyType:   proxy.ProxyType,
 				ServiceName: cllama.ProxyServiceName(proxy.ProxyType),
 				Image:       proxy.Image,
-			})
+			}
+			if proxy.Upstream != "" {
+				entry.Upstream = cllama.ProxyServiceName(proxy.Upstream)
+			}
+			out.Proxies = append(out.Proxies, entry)
 		}
 		sort.Slice(out.Proxies, func(i, j int) bool {
 			return out.Proxies[i].ServiceName < out.Proxies[j].ServiceName
 		})
 	}
 
+	if len(p.VolumeAccess) > 0 {
+		volumeNames := make([]string, 0, len(p.VolumeAccess))
+		for name := range p.VolumeAccess {
+			volumeNames = append(volumeNames, name)
+		}
+		sort.Strings(volumeNames)
+		out.Volumes = make([]clawdash.VolumeManifest, 0, len(volumeNames))
+		for _, name := range volumeNames {
+			volume := clawdash.VolumeManifest{Name: name, Access: make([]clawdash.VolumeAccessManifest, 0, len(p.VolumeAccess[name]))}
+			for _, rule := range p.VolumeAccess[name] {
+				volume.Access = append(volume.Access, clawdash.VolumeAccessManifest{Service: rule.Service, Mode: rule.Mode})
+			}
+			out.Volumes = append(out.Volumes, volume)
+		}
+	}
+
 	return out
 }
 
@@ -97,6 +97,25 @@ func TestBuildPodManifestIncludesResolvedState(t *testing.T) {
 	}
 }
 
+func TestBuildPodManifestRecordsProxyUpstream(t *testing.T) {
+	p := &pod.Pod{Name: "fleet", Services: map[string]*pod.Service{}}
+	proxies := []pod.CllamaProxyConfig{
+		{ProxyType: "passthrough", Image: "ghcr.io/mostlydev/cllama:latest"},
+		{ProxyType: "policy", Image: "ghcr.io/mostlydev/cllama-policy:latest", Upstream: "passthrough"},
+	}
+
+	got := buildPodManifest(p, map[string]*driver.ResolvedClaw{}, proxies)
+	if len(got.Proxies) != 2 {
+		t.Fatalf("expected 2 proxies, got %d", len(got.Proxies))
+	}
+	if got.Proxies[0].ServiceName != "cllama" || got.Proxies[0].Upstream != "" {
+		t.Fatalf("expected terminal cllama hop, got %+v", got.Proxies[0])
+	}
+	if got.Proxies[1].ServiceName != "cllama-policy" || got.Proxies[1].Upstream != "cllama" {
+		t.Fatalf("expected cllama-policy -> cllama, got %+v", got.Proxies[1])
+	}
+}
+
 func TestWritePodManifestWritesJSONFile(t *testing.T) {
 	dir := t.TempDir()
 	p := &pod.Pod{
@@ -126,3 +145,26 @@ func TestWritePodManifestWritesJSONFile(t *testing.T) {
 		t.Fatalf("expected podName=test-pod, got %v", decoded["podName"])
 	}
 }
+
+func TestBuildPodManifestIncludesVolumeAccess(t *testing.T) {
+	p := &pod.Pod{
+		Name:     "fleet",
+		Services: map[string]*pod.Service{},
+		VolumeAccess: map[string][]pod.VolumeAccessRule{
+			"shared-cache": {
+				{Service: "analyst", Mode: "read-write"},
+				{Service: "crusher-*", Mode: "read-only"},
+			},
+			"archive": {{Service: "*", Mode: "read-only"}},
+		},
+	}
+
+	got := buildPodManifest(p, nil, nil)
+	if len(got.Volumes) != 2 || got.Volumes[0].Name != "archive" || got.Volumes[1].Name != "shared-cache" {
+		t.Fatalf("expected sorted volume ACLs, got %+v", got.Volumes)
+	}
+	access := got.Volumes[1].Access
+	if len(access) != 2 || access[1].Service != "crusher-*" || access[1].Mode != "read-only" {
+		t.Fatalf("unexpected shared-cache access: %+v", access)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,21 +17,31 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/mostlydev/clawdapus/internal/build"
+	"github.com/mostlydev/clawdapus/internal/clawfile"
 	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/driver/shared"
+	"github.com/mostlydev/clawdapus/internal/egress"
 	"github.com/mostlydev/clawdapus/internal/inspect"
+	"github.com/mostlydev/clawdapus/internal/mockllm"
 	"github.com/mostlydev/clawdapus/internal/persona"
 	"github.com/mostlydev/clawdapus/internal/pod"
 	"github.com/mostlydev/clawdapus/internal/runtime"
+	"github.com/mostlydev/clawdapus/internal/secretscan"
 )
 
 var composeUpDetach bool
+var composeUpScanImageCredentials bool
+var composeUpMockLLM bool
+var composeUpDryRun bool
 
 var envVarPattern = regexp.MustCompile(`\$\{([A-Z_][A-Z0-9_]*)\}`)
 
 var (
 	extractServiceSkillFromImage = runtime.ExtractServiceSkill
+	discoverMCPTools             = runtime.DiscoverMCPTools
+	fetchOpenAPISpec             = runtime.FetchOpenAPISpec
+	scanImageCredentialFiles     = runtime.ScanImageCredentialFiles
 	writeRuntimeFile             = os.WriteFile
 	inspectClawImage             = inspect.Inspect
 	imageExistsLocally           = build.ImageExistsLocally
@@ -58,11 +69,34 @@ var composeUpCmd = &cobra.Command{
 		if podFile == "" {
 			podFile = "claw-pod.yml"
 		}
+		if composeUpDryRun {
+			return runComposePlan(podFile)
+		}
 		return runComposeUp(podFile)
 	},
 }
 
+// composeUpOptions adjusts a `claw up` run for commands that reuse the pipeline.
+type composeUpOptions struct {
+	// rotateTokens reissues cllama bearer tokens for rotateServices (every
+	// cllama agent when empty). Their new tokens change the config hash of
+	// those agents and the proxies in their chains, so only they are recreated.
+	rotateTokens   bool
+	rotateServices []string
+	// planDir materializes into this scratch directory instead of the pod
+	// directory and stops before any docker compose call. Skills that claw up
+	// extracts or discovers by starting a container are reused from the live
+	// runtime dir instead.
+	planDir string
+	// skipImageBuilds fails on a missing managed image instead of building it.
+	skipImageBuilds bool
+}
+
 func runComposeUp(podFile string) error {
+	return runComposeUpWithOptions(podFile, composeUpOptions{})
+}
+
+func runComposeUpWithOptions(podFile string, opts composeUpOptions) error {
 	f, err := os.Open(podFile)
 	if err != nil {
 		return fmt.Errorf("open pod file: %w", err)
@@ -81,9 +115,42 @@ func runComposeUp(podFile string) error {
 	if err := resolveRuntimePlaceholders(podDir, p); err != nil {
 		return fmt.Errorf("resolve x-claw runtime placeholders: %w", err)
 	}
-	runtimeDir := filepath.Join(podDir, ".claw-runtime")
-	if err := resetRuntimeDir(runtimeDir); err != nil {
-		return fmt.Errorf("reset runtime dir: %w", err)
+	if err := pod.ValidateVolumeAccess(p); err != nil {
+		return fmt.Errorf("volume access preflight: %w", err)
+	}
+	mockLLMEnabled, err := configureMockLLM(podDir, p, composeUpMockLLM)
+	if err != nil {
+		return err
+	}
+	if mockLLMEnabled {
+		fmt.Printf("[claw] mock LLM enabled: model calls go to %s\n", mockllm.ServiceName)
+	}
+	liveRuntimeDir := filepath.Join(podDir, ".claw-runtime")
+	prevEgressPolicy := previousEgressPolicy(liveRuntimeDir)
+	prevComposePath := filepath.Join(podDir, "compose.generated.yml")
+	prevConfigHashes := previousConfigHashes(prevComposePath)
+	outputDir := podDir
+	// Everything is materialized into a staging dir first. Running containers
+	// bind-mount the live runtime dir, so it is only touched once the pod has
+	// validated and the compose file has been emitted.
+	runtimeDir := filepath.Join(podDir, runtimeStagingDirName)
+	if opts.planDir != "" {
+		outputDir = opts.planDir
+		runtimeDir = filepath.Join(opts.planDir, ".claw-runtime")
+	}
+	discovery := &skillDiscovery{skipImageBuilds: opts.skipImageBuilds}
+	if opts.planDir != "" {
+		discovery.scratchRuntimeDir = runtimeDir
+		discovery.liveRuntimeDir = liveRuntimeDir
+	}
+	if err := os.RemoveAll(runtimeDir); err != nil {
+		return fmt.Errorf("reset runtime staging dir: %w", err)
+	}
+	if err := os.MkdirAll(runtimeDir, 0o700); err != nil {
+		return fmt.Errorf("create runtime staging dir: %w", err)
+	}
+	if opts.planDir == "" {
+		defer os.RemoveAll(runtimeDir)
 	}
 
 	results := make(map[string]*driver.MaterializeResult)
@@ -93,6 +160,11 @@ func runComposeUp(podFile string) error {
 	serviceImageRefs := make(map[string]string)
 	serviceInfos := make(map[string]*inspect.ClawInfo)
 
+	secretAllowlist, err := secretscan.LoadAllowlist(filepath.Join(podDir, secretscan.AllowlistFile))
+	if err != nil {
+		return err
+	}
+
 	// Pre-collect all pod handles so each service can reference its peers.
 	// This is a cheap pass over the already-parsed pod YAML — no image inspection needed.
 	podHandles := make(map[string]map[string]*driver.HandleInfo) // service → platform → HandleInfo
@@ -107,7 +179,7 @@ func runComposeUp(podFile string) error {
 			continue
 		}
 
-		imageRef, err := resolveManagedServiceImage(podDir, p, name, svc)
+		imageRef, err := resolveManagedServiceImage(podDir, p, name, svc, opts.skipImageBuilds)
 		if err != nil {
 			return err
 		}
@@ -120,41 +192,20 @@ func runComposeUp(podFile string) error {
 		if info.ClawType == "" {
 			return fmt.Errorf("service %q: image %q has no claw.type label", name, imageRef)
 		}
-
-		// Resolve agent contract
-		agentHostPath := ""
-		agentFile := info.Agent
-		if svc.Claw.Agent != "" {
-			contract, err := runtime.ResolveContract(podDir, svc.Claw.Agent)
-			if err != nil {
-				return fmt.Errorf("service %q: %w", name, err)
-			}
-			agentHostPath = contract.HostPath
-			// Use the basename from the pod-level agent path
-			agentFile = filepath.Base(svc.Claw.Agent)
-		} else if agentFile != "" {
-			contract, err := runtime.ResolveContract(podDir, agentFile)
-			if err != nil {
-				return fmt.Errorf("service %q: %w", name, err)
-			}
-			agentHostPath = contract.HostPath
+		privileges, err := pod.ParsePrivileges(info.Privileges)
+		if err != nil {
+			return fmt.Errorf("service %q: image %q: %w", name, imageRef, err)
 		}
-
-		// Surfaces are already parsed by pod.Parse() — use them directly.
-		var surfaces []driver.ResolvedSurface
-		if svc.Claw != nil {
-			surfaces = svc.Claw.Surfaces
+		for _, warning := range privileges.Warnings {
+			fmt.Printf("[claw] warning: service %q: %s\n", name, warning)
 		}
+		svc.Claw.Privileges = info.Privileges
 
-		// Enrich service surfaces with port info from pod service definitions.
-		// Merge expose: and ports: — both describe reachable container ports.
-		for i := range surfaces {
-			if surfaces[i].Scheme == "service" {
-				if targetSvc, ok := p.Services[surfaces[i].Target]; ok {
-					surfaces[i].Ports = mergedPorts(targetSvc.Expose, targetSvc.Ports)
-				}
-			}
+		agentFile, agentHostPath, err := resolveAgentContract(podDir, svc, info)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", name, err)
 		}
+		surfaces := serviceSurfacesWithPorts(p, svc)
 
 		svcRuntimeDir := filepath.Join(runtimeDir, name)
 		if err := os.MkdirAll(svcRuntimeDir, 0700); err != nil {
@@ -174,54 +225,11 @@ func runComposeUp(podFile string) error {
 			return fmt.Errorf("service %q: materialize persona: %w", name, err)
 		}
 
-		// Merge skills: image-level (from labels) + pod-level (from x-claw)
-		imageSkills, err := runtime.ResolveSkills(podDir, info.Skills)
+		surfaces, candidates, err := serviceSkillCandidates(podDir, svcRuntimeDir, p, name, svc, info, surfaces, includeSkills, serviceImageRefs, serviceInfos, discovery)
 		if err != nil {
-			return fmt.Errorf("service %q: %w", name, err)
-		}
-		if info.SkillEmit != "" {
-			emitSkill, err := resolveSkillEmit(name, svcRuntimeDir, svc.Image, info.SkillEmit)
-			if err != nil {
-				return fmt.Errorf("service %q: resolve emitted skill: %w", name, err)
-			}
-			if emitSkill != nil {
-				imageSkills = append(imageSkills, *emitSkill)
-			}
-		}
-		surfaces, generatedSkills, err := resolveServiceSurfaceSkills(podDir, svcRuntimeDir, p, surfaces, serviceImageRefs, serviceInfos)
-		if err != nil {
-			return fmt.Errorf("service %q: resolve service surface skills: %w", name, err)
-		}
-		// Add channel surface skills (surface-discord.md etc.)
-		channelSkills, err := resolveChannelGeneratedSkills(svcRuntimeDir, surfaces)
-		if err != nil {
-			return fmt.Errorf("service %q: resolve generated channel skills: %w", name, err)
-		}
-		if len(channelSkills) > 0 {
-			generatedSkills = mergeResolvedSkills(generatedSkills, channelSkills)
-		}
-		if len(includeSkills) > 0 {
-			generatedSkills = mergeResolvedSkills(generatedSkills, includeSkills)
-		}
-		handleSkills, err := resolveHandleSkills(svcRuntimeDir, svc.Claw.Handles)
-		if err != nil {
-			return fmt.Errorf("service %q: resolve handle skills: %w", name, err)
-		}
-		if len(handleSkills) > 0 {
-			generatedSkills = mergeResolvedSkills(generatedSkills, handleSkills)
-		}
-		podSkills := make([]driver.ResolvedSkill, 0)
-		if svc.Claw != nil {
-			podSkills, err = runtime.ResolveSkills(podDir, svc.Claw.Skills)
-			if err != nil {
-				return fmt.Errorf("service %q: %w", name, err)
-			}
-		}
-		skills := mergeResolvedSkills(imageSkills, podSkills)
-		if len(generatedSkills) > 0 {
-			// Pod and image skills override generated defaults.
-			skills = mergeResolvedSkills(generatedSkills, skills)
+			return err
 		}
+		skills, _ := resolveSkillCandidates(candidates)
 		agentHostPath, err = materializeServiceSurfaceGuides(svcRuntimeDir, agentHostPath, surfaces, skills)
 		if err != nil {
 			return fmt.Errorf("service %q: materialize service surface guides: %w", name, err)
@@ -247,6 +255,7 @@ func runComposeUp(podFile string) error {
 			PeerHandles:   peerHandles,
 			Includes:      resolvedIncludes,
 			Configures:    info.Configures,
+			Tracks:        info.Tracks,
 			Privileges:    info.Privileges,
 			Count:         svc.Claw.Count,
 			Environment:   svc.Environment,
@@ -254,6 +263,11 @@ func runComposeUp(podFile string) error {
 			Skills:        skills,
 			Cllama:        resolveCllama(info.Cllama, svc.Claw.Cllama),
 		}
+		if err := validateCllamaPolicyScope(name, svc.Claw, rc); err != nil {
+			return err
+		}
+		// Agents behind cllama reach the mock through their proxy chain.
+		rc.MockLLM = mockLLMEnabled && len(rc.Cllama) == 0
 		if resolvedPersona != nil {
 			rc.PersonaHostPath = resolvedPersona.HostPath
 		}
@@ -291,6 +305,9 @@ func runComposeUp(podFile string) error {
 		if err := d.Validate(rc); err != nil {
 			return fmt.Errorf("service %q: validation failed: %w", name, err)
 		}
+		if err := scanServiceArtifacts(podDir, name, rc, secretAllowlist); err != nil {
+			return err
+		}
 
 		drivers[name] = d
 		resolvedClaws[name] = rc
@@ -301,10 +318,32 @@ func runComposeUp(podFile string) error {
 	cllamaEnabled, cllamaAgents := detectCllama(resolvedClaws)
 	proxies := make([]pod.CllamaProxyConfig, 0)
 	cllamaDashboardPort := envOrDefault("CLLAMA_UI_PORT", "8181")
+	if opts.rotateTokens && !cllamaEnabled {
+		return fmt.Errorf("claw tokens rotate: no services in %s use cllama", podFile)
+	}
+	// The token store is saved only once the pod is up with its tokens, so a
+	// failed run never leaves it out of step with the running proxies.
+	var tokenStore *cllama.TokenStore
 	if cllamaEnabled {
-		proxyTypes := collectProxyTypes(resolvedClaws)
-		if len(proxyTypes) > 1 {
-			return fmt.Errorf("multi-proxy chaining not yet supported: found proxy types %v; Phase 4 supports one proxy type per pod", proxyTypes)
+		hops, err := planProxyChains(resolvedClaws)
+		if err != nil {
+			return err
+		}
+
+		tokenStore, err = cllama.LoadTokenStore(cllama.TokenStorePath(podDir), p.Name)
+		if err != nil {
+			return err
+		}
+		if prev := tokenStore.DiscardedFrom(); prev != "" {
+			fmt.Printf("[claw] warning: %s was issued for pod %q, not %q; issuing new cllama tokens for every agent\n", cllama.TokenStoreFile, prev, p.Name)
+		}
+		if opts.rotateTokens {
+			rotate, err := tokenRotationServices(opts.rotateServices, cllamaAgents)
+			if err != nil {
+				return err
+			}
+			tokenStore.Rotate(rotate)
+			fmt.Printf("[claw] rotating cllama tokens for: %s\n", strings.Join(rotate, ", "))
 		}
 
 		tokens := make(map[string]string)
@@ -313,11 +352,11 @@ func runComposeUp(podFile string) error {
 			if rc.Count > 1 {
 				for i := 0; i < rc.Count; i++ {
 					ordinalName := fmt.Sprintf("%s-%d", name, i)
-					tokens[ordinalName] = cllama.GenerateToken(ordinalName)
+					tokens[ordinalName] = tokenStore.Token(ordinalName, name)
 				}
 				rc.CllamaToken = tokens[fmt.Sprintf("%s-0", name)]
 			} else {
-				tokens[name] = cllama.GenerateToken(name)
+				tokens[name] = tokenStore.Token(name, name)
 				rc.CllamaToken = tokens[name]
 			}
 
@@ -335,8 +374,10 @@ func runComposeUp(podFile string) error {
 				}
 			}
 		}
+		tokenStore.Retain(sortedKeys(tokens))
 
 		imageEnvCache := make(map[string]map[string]string)
+		imageFilesCache := make(map[string][]string)
 		for _, name := range cllamaAgents {
 			svc := p.Services[name]
 			if svc == nil {
@@ -347,6 +388,9 @@ func runComposeUp(podFile string) error {
 					return fmt.Errorf("service %q: provider key %q found in pod env; cllama requires credential starvation (move provider keys to x-claw.cllama-env)", name, k)
 				}
 			}
+			if err := checkComposeCredentialSources(podDir, p, name, svc); err != nil {
+				return err
+			}
 
 			imageEnv, ok := imageEnvCache[svc.Image]
 			if !ok {
@@ -361,28 +405,27 @@ func runComposeUp(podFile string) error {
 					return fmt.Errorf("service %q: provider key %q found in image-baked env; cllama requires credential starvation", name, k)
 				}
 			}
-		}
 
-		for _, name := range cllamaAgents {
-			stripLLMKeys(resolvedClaws[name].Environment)
+			if composeUpScanImageCredentials {
+				files, ok := imageFilesCache[svc.Image]
+				if !ok {
+					files, err = scanImageCredentialFiles(svc.Image)
+					if err != nil {
+						return fmt.Errorf("service %q: scan image files for credential starvation: %w", name, err)
+					}
+					imageFilesCache[svc.Image] = files
+				}
+				if len(files) > 0 {
+					return fmt.Errorf("service %q: credential files found in image %q (%s); cllama requires credential starvation", name, svc.Image, strings.Join(files, ", "))
+				}
+			}
 		}
 
-		proxyEnv := map[string]string{
-			"CLAW_POD": p.Name,
-		}
 		for _, name := range cllamaAgents {
-			svc := p.Services[name]
-			if svc == nil || svc.Claw == nil {
-				continue
-			}
-			for k, v := range svc.Claw.CllamaEnv {
-				if _, exists := proxyEnv[k]; !exists {
-					proxyEnv[k] = v
-				}
-			}
+			stripLLMKeys(resolvedClaws[name].Environment)
 		}
 
-		contextInputs := make([]cllama.AgentContextInput, 0)
+		contextInputs := make(map[string][]cllama.AgentContextInput, len(cllamaAgents))
 		for _, name := range cllamaAgents {
 			rc := resolvedClaws[name]
 			if rc.AgentHostPath == "" {
@@ -392,6 +435,19 @@ func runComposeUp(podFile string) error {
 			if err != nil {
 				return fmt.Errorf("service %q: read AGENTS.md for cllama context: %w", name, err)
 			}
+			var policy *cllama.Policy
+			var credentials map[string]string
+			if svc := p.Services[name]; svc != nil && svc.Claw != nil {
+				policy = svc.Claw.CllamaPolicy
+				credentials = svc.Claw.CllamaCreds
+			}
+			if err := validateCllamaPolicyModels(name, rc, policy); err != nil {
+				return err
+			}
+			contract, err := buildContextContract(rc.Includes)
+			if err != nil {
+				return fmt.Errorf("service %q: %w", name, err)
+			}
 
 			if rc.Count > 1 {
 				for i := 0; i < rc.Count; i++ {
@@ -399,7 +455,7 @@ func runComposeUp(podFile string) error {
 					ordinalRC := *rc
 					ordinalRC.ServiceName = ordinalName
 					md := shared.GenerateClawdapusMD(&ordinalRC, p.Name)
-					contextInputs = append(contextInputs, cllama.AgentContextInput{
+					contextInputs[name] = append(contextInputs[name], cllama.AgentContextInput{
 						AgentID:     ordinalName,
 						AgentsMD:    string(agentContent),
 						ClawdapusMD: md,
@@ -410,13 +466,16 @@ func runComposeUp(podFile string) error {
 							"type":    rc.ClawType,
 							"token":   tokens[ordinalName],
 						},
+						Policy:      policy,
+						Contract:    contract,
+						Credentials: credentials,
 					})
 				}
 				continue
 			}
 
 			md := shared.GenerateClawdapusMD(rc, p.Name)
-			contextInputs = append(contextInputs, cllama.AgentContextInput{
+			contextInputs[name] = append(contextInputs[name], cllama.AgentContextInput{
 				AgentID:     name,
 				AgentsMD:    string(agentContent),
 				ClawdapusMD: md,
@@ -426,30 +485,64 @@ func runComposeUp(podFile string) error {
 					"type":    rc.ClawType,
 					"token":   tokens[name],
 				},
+				Policy:      policy,
+				Contract:    contract,
+				Credentials: credentials,
 			})
 		}
-		if err := cllama.GenerateContextDir(runtimeDir, contextInputs); err != nil {
-			return fmt.Errorf("generate cllama context dir: %w", err)
-		}
 
-		authDir := filepath.Join(runtimeDir, "proxy-auth")
-		if err := os.MkdirAll(authDir, 0700); err != nil {
-			return fmt.Errorf("create cllama auth dir: %w", err)
-		}
+		proxyTypes := make([]string, 0, len(hops))
+		for i, hop := range hops {
+			serviceName := cllama.ProxyServiceName(hop.ProxyType)
+			assigned := proxyAgents(hop.ProxyType, resolvedClaws, cllamaAgents)
+
+			// Each proxy sees only the agents routed through it, and only
+			// terminal hops receive per-agent provider credentials.
+			inputs := make([]cllama.AgentContextInput, 0, len(assigned))
+			for _, name := range assigned {
+				for _, input := range contextInputs[name] {
+					if hop.Next != "" {
+						input.Credentials = nil
+					}
+					inputs = append(inputs, input)
+				}
+			}
+			proxyEnv, err := proxyEnvironment(p, hop, assigned)
+			if err != nil {
+				return err
+			}
+			contextDir := cllama.ProxyContextDir(runtimeDir, hop.ProxyType)
+			if err := cllama.GenerateContextDir(contextDir, inputs); err != nil {
+				return fmt.Errorf("generate cllama context dir for %s: %w", serviceName, err)
+			}
+
+			authDir := filepath.Join(runtimeDir, "proxy-auth", serviceName)
+			if err := os.MkdirAll(authDir, 0700); err != nil {
+				return fmt.Errorf("create cllama auth dir for %s: %w", serviceName, err)
+			}
 
-		for _, proxyType := range proxyTypes {
 			proxies = append(proxies, pod.CllamaProxyConfig{
-				ProxyType:      proxyType,
-				Image:          cllama.ProxyImageRef(proxyType),
-				ContextHostDir: filepath.Join(runtimeDir, "context"),
+				ProxyType:      hop.ProxyType,
+				Image:          cllama.ProxyImageRef(hop.ProxyType),
+				ContextHostDir: contextDir,
 				AuthHostDir:    authDir,
-				DashboardPort:  cllamaDashboardPort,
+				DashboardPort:  offsetPort(cllamaDashboardPort, i),
 				Environment:    proxyEnv,
+				Upstream:       hop.Next,
 				PodName:        p.Name,
 			})
+			proxyTypes = append(proxyTypes, hop.ProxyType)
+			if len(hops) > 1 {
+				fmt.Printf("[claw] %s: serving %s\n", serviceName, strings.Join(assigned, ", "))
+			}
 		}
 		fmt.Printf("[claw] cllama proxies enabled: %s (agents: %s)\n",
 			strings.Join(proxyTypes, ", "), strings.Join(cllamaAgents, ", "))
+		for _, name := range cllamaAgents {
+			if chain := resolvedClaws[name].Cllama; len(chain) > 1 {
+				fmt.Printf("[claw] %s: cllama chain %s\n", name, strings.Join(chain, " -> "))
+			}
+		}
 	}
 
 	manifestPath, err := writePodManifest(runtimeDir, p, resolvedClaws, proxies)
@@ -467,6 +560,19 @@ func runComposeUp(podFile string) error {
 		PodName:            p.Name,
 	}
 
+	egressPolicy, err := buildEgressPolicy(p.Name, resolvedClaws)
+	if err != nil {
+		return err
+	}
+	if egressPolicy != nil {
+		egressPolicy.KeepSecrets(prevEgressPolicy)
+		p.Egress, err = writeEgressRuntime(runtimeDir, egressPolicy)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[claw] egress proxy enabled: %s\n", egressServiceSummary(egressPolicy))
+	}
+
 	// Pass 2: materialize after cllama tokens/context are resolved.
 	for _, name := range sortedResolvedClawNames(resolvedClaws) {
 		rc := resolvedClaws[name]
@@ -487,23 +593,22 @@ func runComposeUp(podFile string) error {
 			}
 		}
 
+		if len(rc.Tracks) > 0 {
+			if err := mountTrackLog(result, svcRuntimeDir); err != nil {
+				return fmt.Errorf("service %q: %w", name, err)
+			}
+		}
+
 		// Mount individual skill files into the driver's skill directory
 		if result.SkillDir != "" && len(rc.Skills) > 0 {
 			for _, sk := range rc.Skills {
-				containerPath := filepath.Join(result.SkillDir, sk.Name)
-				if result.SkillLayout == "directory" {
-					// Claude Code format: skills/name/SKILL.md
-					stem := strings.TrimSuffix(sk.Name, filepath.Ext(sk.Name))
-					containerPath = filepath.Join(result.SkillDir, stem, "SKILL.md")
-				}
 				result.Mounts = append(result.Mounts, driver.Mount{
 					HostPath:      sk.HostPath,
-					ContainerPath: containerPath,
+					ContainerPath: skillContainerPath(result, sk.Name),
 					ReadOnly:      true,
 				})
 			}
 		}
-
 		results[name] = result
 		fmt.Printf("[claw] %s: materialized (%s driver)\n", name, rc.ClawType)
 	}
@@ -513,42 +618,100 @@ func runComposeUp(podFile string) error {
 		return err
 	}
 
-	generatedPath := filepath.Join(podDir, "compose.generated.yml")
-	if err := os.WriteFile(generatedPath, []byte(output), 0644); err != nil {
-		return fmt.Errorf("write compose.generated.yml: %w", err)
+	// Inputs are digested in the staging dir but recorded, like the compose
+	// file, with the live paths they are mounted from once swapped in.
+	inputs, err := collectRuntimeInputs([]byte(output), runtimeDir)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("[claw] wrote %s\n", generatedPath)
-
-	if err := ensureInfraImages(cllamaEnabled, proxies, p.Clawdash); err != nil {
+	if opts.planDir == "" {
+		relocateRuntimeInputs(inputs, runtimeDir, liveRuntimeDir)
+		output = strings.ReplaceAll(output, runtimeDir, liveRuntimeDir)
+	}
+	runtimeConsumers := runtimeConsumerServices(resolvedClaws, proxies, p.Clawdash, p.Egress, p.MockLLM)
+	configHashes, err := serviceConfigHashes([]byte(output), inputs, runtimeConsumers)
+	if err != nil {
+		return err
+	}
+	stamped, err := stampConfigHashes([]byte(output), configHashes)
+	if err != nil {
 		return err
 	}
 
-	if len(drivers) == 0 {
-		fmt.Println("[claw] warning: no x-claw services found; running plain docker compose lifecycle")
+	generatedPath := filepath.Join(outputDir, "compose.generated.yml")
+	if opts.planDir != "" {
+		if err := os.WriteFile(generatedPath, stamped, 0644); err != nil {
+			return fmt.Errorf("write compose.generated.yml: %w", err)
+		}
+		fmt.Printf("[claw] wrote %s\n", generatedPath)
+		return writeRuntimeInputs(runtimeDir, inputs)
 	}
 
 	if len(drivers) > 0 && !composeUpDetach {
 		return fmt.Errorf("claw-managed services require detached mode for fail-closed post-apply verification; rerun with 'claw up -d %s'", podFile)
 	}
+	if err := ensureInfraImages(cllamaEnabled, proxies, p.Clawdash, p.Egress, p.MockLLM); err != nil {
+		return err
+	}
+
+	// Nothing below fails on the pod itself: swap the staged runtime in and
+	// write the compose file and its config-hash baseline.
+	if err := swapRuntimeDir(runtimeDir, liveRuntimeDir, liveRuntimeMounts(liveRuntimeDir, prevComposePath)); err != nil {
+		return fmt.Errorf("install runtime dir: %w", err)
+	}
+	runtimeDir = liveRuntimeDir
+	if err := os.WriteFile(generatedPath, stamped, 0644); err != nil {
+		return fmt.Errorf("write compose.generated.yml: %w", err)
+	}
+	fmt.Printf("[claw] wrote %s\n", generatedPath)
+	if err := writeRuntimeInputs(runtimeDir, inputs); err != nil {
+		return err
+	}
+
+	if len(drivers) == 0 {
+		fmt.Println("[claw] warning: no x-claw services found; running plain docker compose lifecycle")
+	}
 
 	composeArgs := []string{"compose", "-f", generatedPath, "up"}
 	if composeUpDetach {
 		composeArgs = append(composeArgs, "-d")
 	}
 
+	// Services whose config hash moved have a new definition, so compose
+	// recreates them; unchanged services keep running.
+	changed, unchanged := changedConfigServices(prevConfigHashes, configHashes)
+	if len(changed) > 0 {
+		fmt.Printf("[claw] config changed: %s\n", strings.Join(changed, ", "))
+	}
+	if len(unchanged) > 0 {
+		fmt.Printf("[claw] config unchanged, not recreating: %s\n", strings.Join(unchanged, ", "))
+	}
+
 	if err := runComposeDockerCommand(composeArgs...); err != nil {
 		return fmt.Errorf("docker compose up failed: %w", err)
 	}
 
-	runtimeConsumers := runtimeConsumerServices(resolvedClaws, proxies, p.Clawdash)
-	if composeUpDetach && len(runtimeConsumers) > 0 {
-		recreateArgs := append([]string{"compose", "-f", generatedPath, "up", "-d", "--force-recreate"}, runtimeConsumers...)
-		if err := runComposeDockerCommand(recreateArgs...); err != nil {
-			return fmt.Errorf("docker compose force-recreate failed: %w", err)
+	if err := postApplyServices(generatedPath, drivers, resolvedClaws); err != nil {
+		return err
+	}
+	gen, err := recordGeneration(podDir, runtimeDir, generatedPath, p.Name, p.Generations)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[claw] recorded generation %d\n", gen)
+	if tokenStore != nil {
+		if err := tokenStore.Save(); err != nil {
+			return err
 		}
 	}
 
-	// PostApply: verify every generated service container.
+	fmt.Println("[claw] pod is up")
+	return nil
+}
+
+// postApplyServices verifies every generated service container through its
+// driver's PostApply.
+func postApplyServices(generatedPath string, drivers map[string]driver.Driver, resolvedClaws map[string]*driver.ResolvedClaw) error {
 	for name, d := range drivers {
 		rc := resolvedClaws[name]
 		for _, generatedService := range expandedServiceNames(name, rc.Count) {
@@ -564,19 +727,154 @@ func runComposeUp(podFile string) error {
 			}
 		}
 	}
-
-	fmt.Println("[claw] pod is up")
 	return nil
 }
 
-func resetRuntimeDir(path string) error {
-	if err := os.RemoveAll(path); err != nil {
+// resetRuntimeDir clears generated runtime state from a previous run. Per-service
+// TRACK mutation logs (<service>/track) and the egress denial log (egress/logs)
+// are preserved so they survive re-runs. Directories in keep, which running
+// containers bind-mount, are emptied in place rather than deleted, so a
+// container left running because its config is unchanged still sees the
+// regenerated files.
+func resetRuntimeDir(path string, keep map[string]struct{}) error {
+	entries, err := os.ReadDir(path)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		if !entry.IsDir() {
+			if err := os.RemoveAll(entryPath); err != nil {
+				return err
+			}
+			continue
+		}
+		children, err := os.ReadDir(entryPath)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if isPreservedRuntimeDir(entry.Name(), child.Name()) && child.IsDir() {
+				continue
+			}
+			if err := clearRuntimeEntry(filepath.Join(entryPath, child.Name()), keep); err != nil {
+				return err
+			}
+		}
+	}
 	return os.MkdirAll(path, 0o700)
 }
 
-func runtimeConsumerServices(resolvedClaws map[string]*driver.ResolvedClaw, proxies []pod.CllamaProxyConfig, dash *pod.ClawdashConfig) []string {
+// runtimeStagingDirName is the pod-dir sibling of .claw-runtime that claw up
+// materializes into before swapping the result in.
+const runtimeStagingDirName = ".claw-runtime.staging"
+
+// swapRuntimeDir replaces the contents of the live runtime dir with the staged
+// ones. The live dir is reset as resetRuntimeDir resets it, so kept mounts are
+// emptied in place and TRACK and egress logs survive, then each staged entry
+// is moved in; directories that still exist are merged rather than replaced.
+func swapRuntimeDir(staging, live string, keep map[string]struct{}) error {
+	if err := resetRuntimeDir(live, keep); err != nil {
+		return err
+	}
+	return mergeRuntimeDir(staging, live)
+}
+
+func mergeRuntimeDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+		existing, err := os.Lstat(to)
+		if err == nil && existing.IsDir() && entry.IsDir() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if err := os.Chmod(to, info.Mode()&(fs.ModePerm|fs.ModeSticky)); err != nil {
+				return err
+			}
+			if err := mergeRuntimeDir(from, to); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.RemoveAll(to); err != nil {
+			return err
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearRuntimeEntry deletes p unless it is, or contains, a kept directory; kept
+// directories and their ancestors are emptied instead.
+func clearRuntimeEntry(p string, keep map[string]struct{}) error {
+	if !holdsKeptDir(p, keep) {
+		return os.RemoveAll(p)
+	}
+	children, err := os.ReadDir(p)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := clearRuntimeEntry(filepath.Join(p, child.Name()), keep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func holdsKeptDir(p string, keep map[string]struct{}) bool {
+	for dir := range keep {
+		if dir == p || strings.HasPrefix(dir, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackRuntimeDirName is the per-service runtime subdirectory that holds TRACK
+// mutation logs.
+const trackRuntimeDirName = "track"
+
+// isPreservedRuntimeDir reports whether the runtime subdirectory dir/child
+// holds audit logs rather than generated state: a service's TRACK log or the
+// egress proxy's denial log.
+func isPreservedRuntimeDir(dir, child string) bool {
+	return child == trackRuntimeDirName || (dir == egressRuntimeDirName && child == egressLogDirName)
+}
+
+// mountTrackLog bind-mounts the service's persistent TRACK log directory over
+// the well-known in-container path written by the package manager wrappers.
+func mountTrackLog(result *driver.MaterializeResult, svcRuntimeDir string) error {
+	hostDir := filepath.Join(svcRuntimeDir, trackRuntimeDirName)
+	if err := os.MkdirAll(hostDir, 0o700); err != nil {
+		return fmt.Errorf("create track log dir: %w", err)
+	}
+	// Wrappers run as the container user, which may not match the host user,
+	// so it may create the log but not list the dir or remove others' files.
+	if err := os.Chmod(hostDir, 0o1733); err != nil {
+		return fmt.Errorf("chmod track log dir: %w", err)
+	}
+	result.Mounts = append(result.Mounts, driver.Mount{
+		HostPath:      hostDir,
+		ContainerPath: clawfile.TrackLogDir,
+		ReadOnly:      false,
+	})
+	if result.Environment == nil {
+		result.Environment = make(map[string]string)
+	}
+	result.Environment["CLAW_TRACK_LOG"] = clawfile.TrackLogPath
+	return nil
+}
+
+func runtimeConsumerServices(resolvedClaws map[string]*driver.ResolvedClaw, proxies []pod.CllamaProxyConfig, dash *pod.ClawdashConfig, egressCfg *pod.EgressConfig, mockCfg *pod.MockLLMConfig) []string {
 	seen := make(map[string]struct{})
 	names := make([]string, 0, len(resolvedClaws)+len(proxies)+1)
 
@@ -609,6 +907,18 @@ func runtimeConsumerServices(resolvedClaws map[string]*driver.ResolvedClaw, prox
 		}
 	}
 
+	if egressCfg != nil {
+		if _, ok := seen[egress.ServiceName]; !ok {
+			names = append(names, egress.ServiceName)
+		}
+	}
+
+	if mockCfg != nil {
+		if _, ok := seen[mockllm.ServiceName]; !ok {
+			names = append(names, mockllm.ServiceName)
+		}
+	}
+
 	sort.Strings(names)
 	return names
 }
@@ -628,7 +938,7 @@ func resolveRuntimePlaceholders(podDir string, p *pod.Pod) error {
 		})
 	}
 
-	for _, svc := range p.Services {
+	for name, svc := range p.Services {
 		if svc == nil || svc.Claw == nil {
 			continue
 		}
@@ -640,6 +950,16 @@ func resolveRuntimePlaceholders(podDir string, p *pod.Pod) error {
 		for key, value := range svc.Claw.CllamaEnv {
 			svc.Claw.CllamaEnv[key] = expand(value)
 		}
+		for key, value := range svc.Claw.CllamaCreds {
+			expanded := expand(value)
+			if envVarPattern.MatchString(expanded) {
+				return fmt.Errorf("service %q: cllama-credentials: %q references an unset variable", name, key)
+			}
+			svc.Claw.CllamaCreds[key] = expanded
+		}
+		if err := pod.ValidateCllamaCredentials(svc.Claw.CllamaCreds); err != nil {
+			return fmt.Errorf("service %q: %w", name, err)
+		}
 		for i, value := range svc.Claw.Skills {
 			svc.Claw.Skills[i] = expand(value)
 		}
@@ -897,27 +1217,6 @@ func readDotEnvFile(path string) (map[string]string, error) {
 	return out, nil
 }
 
-func mergeResolvedSkills(imageSkills, podSkills []driver.ResolvedSkill) []driver.ResolvedSkill {
-	merged := make([]driver.ResolvedSkill, 0, len(imageSkills)+len(podSkills))
-	byName := make(map[string]int, len(imageSkills))
-
-	for _, skill := range imageSkills {
-		byName[skill.Name] = len(merged)
-		merged = append(merged, skill)
-	}
-
-	for _, skill := range podSkills {
-		if idx, ok := byName[skill.Name]; ok {
-			merged[idx] = skill
-			continue
-		}
-		byName[skill.Name] = len(merged)
-		merged = append(merged, skill)
-	}
-
-	return merged
-}
-
 func materializeContractIncludes(baseDir, runtimeDir, agentHostPath string, includes []pod.IncludeEntry) ([]driver.ResolvedInclude, []driver.ResolvedSkill, error) {
 	if len(includes) == 0 {
 		return nil, nil, nil
@@ -1167,22 +1466,115 @@ func detectCllama(claws map[string]*driver.ResolvedClaw) (bool, []string) {
 	return len(agents) > 0, agents
 }
 
-func collectProxyTypes(claws map[string]*driver.ResolvedClaw) []string {
-	seen := make(map[string]struct{})
-	for _, rc := range claws {
-		for _, proxyType := range rc.Cllama {
-			if strings.TrimSpace(proxyType) == "" {
+// planProxyChains turns every agent's ordered CLLAMA list into the pod's
+// proxy hops: one service per type, each forwarding to the next type in the
+// chains it belongs to.
+func planProxyChains(claws map[string]*driver.ResolvedClaw) ([]cllama.Hop, error) {
+	chains := make(map[string][]string, len(claws))
+	for name, rc := range claws {
+		if len(rc.Cllama) > 0 {
+			chains[name] = rc.Cllama
+		}
+	}
+	return cllama.PlanChains(chains)
+}
+
+// buildContextContract lists an agent's contract includes for the cllama
+// context. It returns nil when the agent has no includes.
+func buildContextContract(includes []driver.ResolvedInclude) (*cllama.Contract, error) {
+	if len(includes) == 0 {
+		return nil, nil
+	}
+	contract := &cllama.Contract{Includes: make([]cllama.ContractInclude, 0, len(includes))}
+	for _, include := range includes {
+		content, err := os.ReadFile(include.HostPath)
+		if err != nil {
+			return nil, fmt.Errorf("include %q: read for cllama contract: %w", include.ID, err)
+		}
+		contract.Includes = append(contract.Includes, cllama.NewContractInclude(include.ID, include.Mode, include.Description, content))
+	}
+	return contract, nil
+}
+
+// validateCllamaPolicyScope rejects a service's own cllama-policy when the
+// service is not behind a cllama proxy, where nothing would enforce it. Pod
+// defaults are exempt; they only apply to services that use cllama.
+func validateCllamaPolicyScope(name string, claw *pod.ClawBlock, rc *driver.ResolvedClaw) error {
+	if claw.OwnPolicy && len(rc.Cllama) == 0 {
+		return fmt.Errorf("service %q: cllama-policy has no effect without cllama; declare x-claw.cllama or CLLAMA in the Clawfile", name)
+	}
+	return nil
+}
+
+// validateCllamaPolicyModels fails fast when a service's own model slots fall
+// outside its cllama-policy allowlist; the proxy would reject every call.
+func validateCllamaPolicyModels(name string, rc *driver.ResolvedClaw, policy *cllama.Policy) error {
+	for _, slot := range sortedKeys(rc.Models) {
+		if !policy.AllowsModel(rc.Models[slot]) {
+			return fmt.Errorf("service %q: model %q (slot %q) is not in cllama-policy allowed-models %v", name, rc.Models[slot], slot, policy.AllowedModels)
+		}
+	}
+	return nil
+}
+
+// proxyAgents returns the cllama agents whose chain includes proxyType.
+func proxyAgents(proxyType string, claws map[string]*driver.ResolvedClaw, cllamaAgents []string) []string {
+	assigned := make([]string, 0, len(cllamaAgents))
+	for _, name := range cllamaAgents {
+		for _, t := range claws[name].Cllama {
+			if cllama.ProxyType(t) == proxyType {
+				assigned = append(assigned, name)
+				break
+			}
+		}
+	}
+	return assigned
+}
+
+// proxyEnvironment builds one proxy's env from the x-claw.cllama-env of the
+// agents it serves. Only terminal hops call a provider, so hops that forward
+// to another proxy never receive provider keys. Two agents setting the same
+// key to different values is an error: the proxy has one env, and per-agent
+// keys belong in x-claw.cllama-credentials.
+func proxyEnvironment(p *pod.Pod, hop cllama.Hop, assigned []string) (map[string]string, error) {
+	env := map[string]string{
+		"CLAW_POD": p.Name,
+	}
+	setBy := make(map[string]string)
+	for _, name := range assigned {
+		svc := p.Services[name]
+		if svc == nil || svc.Claw == nil {
+			continue
+		}
+		for _, k := range sortedKeys(svc.Claw.CllamaEnv) {
+			v := svc.Claw.CllamaEnv[k]
+			if hop.Next != "" && isProviderKey(k) {
+				continue
+			}
+			if k == "CLAW_POD" {
+				return nil, fmt.Errorf("service %q: cllama-env must not set reserved %s", name, k)
+			}
+			if owner, exists := setBy[k]; exists {
+				if env[k] != v {
+					return nil, fmt.Errorf("cllama-env conflict on %s: services %q and %q set %s to different values (use x-claw.cllama-credentials for per-agent keys)", cllama.ProxyServiceName(hop.ProxyType), owner, name, k)
+				}
 				continue
 			}
-			seen[proxyType] = struct{}{}
+			env[k] = v
+			setBy[k] = name
 		}
 	}
-	types := make([]string, 0, len(seen))
-	for proxyType := range seen {
-		types = append(types, proxyType)
+	return env, nil
+}
+
+// offsetPort returns port+offset, leaving unparsable ports to the emitter's
+// default handling.
+func offsetPort(port string, offset int) string {
+	value, err := strconv.Atoi(strings.TrimSpace(port))
+	if err != nil || offset == 0 {
+		return port
 	}
-	sort.Strings(types)
-	return types
+	return strconv.Itoa(value + offset)
 }
 
 func sortedResolvedClawNames(claws map[string]*driver.ResolvedClaw) []string {
@@ -1238,7 +1630,7 @@ func inspectImageEnv(imageRef string) (map[string]string, error) {
 	return env, nil
 }
 
-func resolveSkillEmit(serviceName, runtimeDir, imageRef, emitPath string) (*driver.ResolvedSkill, error) {
+func resolveSkillEmit(serviceName, runtimeDir, imageRef, emitPath string, discovery *skillDiscovery) (*driver.ResolvedSkill, error) {
 	if emitPath == "" {
 		return nil, nil
 	}
@@ -1253,13 +1645,22 @@ func resolveSkillEmit(serviceName, runtimeDir, imageRef, emitPath string) (*driv
 		return nil, fmt.Errorf("service %q: create emitted-skill dir: %w", serviceName, err)
 	}
 
-	content, err := extractServiceSkillFromImage(imageRef, emitPath)
-	if err != nil {
-		// Extraction failure is non-fatal: warn and fall back to the generated stub skill.
-		// The pod can still start; the agent gets a generic skill rather than a custom one.
-		fmt.Printf("[claw] warning: service %q: could not extract emitted skill %q from %q: %v (using fallback)\n",
-			serviceName, emitPath, imageRef, err)
-		return nil, nil
+	var content []byte
+	if reused, planned := discovery.reuse(emitHostPath, serviceName, "emitted skill "+emitPath); planned {
+		if reused == "" {
+			return nil, nil
+		}
+		content = []byte(reused)
+	} else {
+		extracted, err := extractServiceSkillFromImage(imageRef, emitPath)
+		if err != nil {
+			// Extraction failure is non-fatal: warn and fall back to the generated stub skill.
+			// The pod can still start; the agent gets a generic skill rather than a custom one.
+			fmt.Printf("[claw] warning: service %q: could not extract emitted skill %q from %q: %v (using fallback)\n",
+				serviceName, emitPath, imageRef, err)
+			return nil, nil
+		}
+		content = extracted
 	}
 	if err := writeRuntimeFile(emitHostPath, content, 0644); err != nil {
 		return nil, fmt.Errorf("write emitted skill %q: %w", emitPath, err)
@@ -1271,10 +1672,13 @@ func resolveSkillEmit(serviceName, runtimeDir, imageRef, emitPath string) (*driv
 	}, nil
 }
 
-func resolveServiceSurfaceSkills(podDir, runtimeDir string, p *pod.Pod, surfaces []driver.ResolvedSurface, imageRefs map[string]string, infos map[string]*inspect.ClawInfo) ([]driver.ResolvedSurface, []driver.ResolvedSkill, error) {
+// resolveServiceSurfaceSkillOrigins generates a skill for each service surface
+// and reports where each came from, keyed by skill name.
+func resolveServiceSurfaceSkillOrigins(podDir, runtimeDir string, p *pod.Pod, surfaces []driver.ResolvedSurface, imageRefs map[string]string, infos map[string]*inspect.ClawInfo, discovery *skillDiscovery) ([]driver.ResolvedSurface, []driver.ResolvedSkill, map[string]skillOrigin, error) {
 	surfaceSkillsDir := filepath.Join(runtimeDir, "skills")
 	resolvedSurfaces := append([]driver.ResolvedSurface(nil), surfaces...)
 	generated := make([]driver.ResolvedSkill, 0)
+	origins := make(map[string]skillOrigin)
 	seen := make(map[string]struct{}, len(surfaces))
 
 	for i, surface := range resolvedSurfaces {
@@ -1284,28 +1688,59 @@ func resolveServiceSurfaceSkills(podDir, runtimeDir string, p *pod.Pod, surfaces
 
 		name := surfaceFallbackSkillName(surface.Target)
 		if name == "surface-.md" {
-			return nil, nil, fmt.Errorf("invalid service target for generated skill: %q", surface.Target)
+			return nil, nil, nil, fmt.Errorf("invalid service target for generated skill: %q", surface.Target)
 		}
+		surfaceContent := ""
+		source := skillSourceFallback
 
-		if targetSvc, ok := p.Services[surface.Target]; ok {
-			imageRef, info, err := resolveServiceInspection(podDir, p, surface.Target, targetSvc, imageRefs, infos)
+		targetSvc, ok := p.Services[surface.Target]
+		if ok {
+			imageRef, info, err := resolveServiceInspection(podDir, p, surface.Target, targetSvc, imageRefs, infos, discovery.skipBuilds())
 			if err != nil {
-				return nil, nil, fmt.Errorf("inspect target service %q: %w", surface.Target, err)
+				return nil, nil, nil, fmt.Errorf("inspect target service %q: %w", surface.Target, err)
 			}
 			if info != nil && strings.TrimSpace(info.SkillEmit) != "" {
-				emitSkill, err := resolveSkillEmit(surface.Target, runtimeDir, imageRef, info.SkillEmit)
+				emitSkill, err := resolveSkillEmit(surface.Target, runtimeDir, imageRef, info.SkillEmit, discovery)
 				if err != nil {
-					return nil, nil, fmt.Errorf("extract emitted skill for target service %q: %w", surface.Target, err)
+					return nil, nil, nil, fmt.Errorf("extract emitted skill for target service %q: %w", surface.Target, err)
 				}
 				if emitSkill != nil {
 					resolvedSurfaces[i].SkillName = emitSkill.Name
 					if _, exists := seen[emitSkill.Name]; !exists {
 						seen[emitSkill.Name] = struct{}{}
 						generated = append(generated, *emitSkill)
+						origins[emitSkill.Name] = skillOrigin{source: skillSourceEmitted, surface: "service://" + surface.Target}
 					}
 					continue
 				}
 			}
+			_, exists := seen[name]
+			discoverable := !exists && info != nil && (strings.TrimSpace(info.MCPEndpoint) != "" || strings.TrimSpace(info.OpenAPI) != "")
+			if cached, ok := discovery.discovered(surface.Target); discoverable && ok {
+				surfaceContent, source = cached.content, cached.source
+			} else if discoverable && discovery.planning() {
+				surfaceContent, _ = discovery.reuse(filepath.Join(surfaceSkillsDir, name), surface.Target, "MCP/OpenAPI skill")
+				source = skillSourceOpenAPI
+				if strings.TrimSpace(info.MCPEndpoint) != "" {
+					source = skillSourceMCP
+				}
+			} else if discoverable {
+				env := discoveryEnv(surface.Target, info, targetSvc.Environment)
+				if strings.TrimSpace(info.MCPEndpoint) != "" {
+					surfaceContent = resolveMCPServiceSkill(surface.Target, imageRef, info, env, surface.Ports)
+					source = skillSourceMCP
+				}
+				if surfaceContent == "" && strings.TrimSpace(info.OpenAPI) != "" {
+					surfaceContent = resolveOpenAPIServiceSkill(surface.Target, imageRef, info, env, surface.Ports)
+					source = skillSourceOpenAPI
+				}
+				discovery.remember(surface.Target, discoveredSkill{content: surfaceContent, source: source})
+			}
+			// A pod describe block ranks just above the generated fallback.
+			if !exists && surfaceContent == "" && targetSvc.Describe != nil {
+				surfaceContent = runtime.GenerateDescribedServiceSkill(surface.Target, surface.Ports, targetSvc.Describe)
+				source = skillSourceDescribe
+			}
 		}
 
 		resolvedSurfaces[i].SkillName = name
@@ -1316,31 +1751,166 @@ func resolveServiceSurfaceSkills(podDir, runtimeDir string, p *pod.Pod, surfaces
 
 		skillPath := filepath.Join(surfaceSkillsDir, name)
 		if err := os.MkdirAll(filepath.Dir(skillPath), 0700); err != nil {
-			return nil, nil, fmt.Errorf("create generated skill dir: %w", err)
+			return nil, nil, nil, fmt.Errorf("create generated skill dir: %w", err)
+		}
+		content := surfaceContent
+		if content == "" {
+			content = runtime.GenerateServiceSkillFallback(surface.Target, surface.Ports)
+			source = skillSourceFallback
 		}
-		content := runtime.GenerateServiceSkillFallback(surface.Target, surface.Ports)
 		if err := writeRuntimeFile(skillPath, []byte(content), 0644); err != nil {
-			return nil, nil, fmt.Errorf("write generated service skill %q: %w", name, err)
+			return nil, nil, nil, fmt.Errorf("write generated service skill %q: %w", name, err)
 		}
 		generated = append(generated, driver.ResolvedSkill{
 			Name:     name,
 			HostPath: skillPath,
 		})
+		origins[name] = skillOrigin{source: source, surface: "service://" + surface.Target}
+	}
+
+	return resolvedSurfaces, generated, origins, nil
+}
+
+// skillDiscovery is the per-run state of service skill resolution. A nil
+// skillDiscovery builds missing images and runs every discovery container.
+type skillDiscovery struct {
+	skipImageBuilds bool
+	// In plan mode, scratchRuntimeDir is the runtime dir being materialized
+	// and skills that need a container are read from liveRuntimeDir instead.
+	scratchRuntimeDir string
+	liveRuntimeDir    string
+	// surfaceSkills caches MCP/OpenAPI discovery by target service, so a
+	// target consumed by several claws starts one discovery container.
+	surfaceSkills map[string]discoveredSkill
+}
+
+// discoveredSkill is the outcome of MCP/OpenAPI discovery for one target;
+// empty content means discovery failed and the fallback is used.
+type discoveredSkill struct {
+	content string
+	source  string
+}
+
+func (d *skillDiscovery) skipBuilds() bool {
+	return d != nil && d.skipImageBuilds
+}
+
+func (d *skillDiscovery) planning() bool {
+	return d != nil && d.scratchRuntimeDir != ""
+}
+
+func (d *skillDiscovery) discovered(target string) (discoveredSkill, bool) {
+	if d == nil {
+		return discoveredSkill{}, false
 	}
+	skill, ok := d.surfaceSkills[target]
+	return skill, ok
+}
 
-	return resolvedSurfaces, generated, nil
+func (d *skillDiscovery) remember(target string, skill discoveredSkill) {
+	if d == nil {
+		return
+	}
+	if d.surfaceSkills == nil {
+		d.surfaceSkills = make(map[string]discoveredSkill)
+	}
+	d.surfaceSkills[target] = skill
+}
+
+// discoveryEnv picks the pod environment a discovery container starts with:
+// only the variables named by the target image's claw.discovery.env label.
+// The rest of the service's environment, credentials included, stays out of
+// the throwaway container.
+func discoveryEnv(target string, info *inspect.ClawInfo, env map[string]string) map[string]string {
+	out := make(map[string]string)
+	for _, name := range strings.Split(info.DiscoveryEnv, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		value, ok := env[name]
+		if !ok {
+			fmt.Printf("[claw] warning: service %q: claw.discovery.env names %s, which is not in its environment\n", target, name)
+			continue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// reuse reports whether container-based skill discovery is skipped because a
+// plan is running. When it is, it returns what the last claw up wrote at the
+// live counterpart of hostPath, or "" after noting that the skill was not
+// re-discovered.
+func (d *skillDiscovery) reuse(hostPath, target, what string) (string, bool) {
+	if !d.planning() {
+		return "", false
+	}
+	if rel, err := filepath.Rel(d.scratchRuntimeDir, hostPath); err == nil && !strings.HasPrefix(rel, "..") {
+		if data, err := os.ReadFile(filepath.Join(d.liveRuntimeDir, rel)); err == nil {
+			return string(data), true
+		}
+	}
+	fmt.Printf("[claw] %s: %s not re-discovered in plan mode (using fallback)\n", target, what)
+	return "", true
+}
+
+// resolveMCPServiceSkill lists the tools of an MCP-capable surface target
+// (claw.mcp.endpoint label) and renders them into a surface skill. Discovery
+// failure is non-fatal: it warns and returns "" so the generated fallback is used.
+func resolveMCPServiceSkill(target, imageRef string, info *inspect.ClawInfo, env map[string]string, ports []string) string {
+	endpoint, err := runtime.ParseMCPEndpoint(info.MCPEndpoint, info.MCPTransport)
+	if err != nil {
+		fmt.Printf("[claw] warning: service %q: %v (using fallback)\n", target, err)
+		return ""
+	}
+	tools, err := discoverMCPTools(imageRef, endpoint, env)
+	if err != nil {
+		fmt.Printf("[claw] warning: service %q: MCP tool discovery failed: %v (using fallback)\n", target, err)
+		return ""
+	}
+	fmt.Printf("[claw] %s: discovered %d MCP tool(s)\n", target, len(tools))
+	return runtime.GenerateMCPServiceSkill(target, endpoint, ports, tools)
+}
+
+// resolveOpenAPIServiceSkill reads the OpenAPI document of a surface target
+// (claw.openapi label) and renders its endpoint catalog into a surface skill.
+// Like MCP discovery, failure warns and returns "" so the fallback is used.
+func resolveOpenAPIServiceSkill(target, imageRef string, info *inspect.ClawInfo, env map[string]string, ports []string) string {
+	source, err := runtime.ParseOpenAPISource(info.OpenAPI)
+	if err != nil {
+		fmt.Printf("[claw] warning: service %q: %v (using fallback)\n", target, err)
+		return ""
+	}
+	authEnv, err := runtime.ParseOpenAPIAuthEnv(info.OpenAPIAuth)
+	if err != nil {
+		fmt.Printf("[claw] warning: service %q: claw.openapi.auth-env: %v (using fallback)\n", target, err)
+		return ""
+	}
+	spec, err := fetchOpenAPISpec(imageRef, source, env)
+	if err != nil {
+		fmt.Printf("[claw] warning: service %q: OpenAPI discovery failed: %v (using fallback)\n", target, err)
+		return ""
+	}
+	content, err := runtime.GenerateOpenAPIServiceSkill(target, source, ports, spec, authEnv)
+	if err != nil {
+		fmt.Printf("[claw] warning: service %q: %v (using fallback)\n", target, err)
+		return ""
+	}
+	fmt.Printf("[claw] %s: rendered OpenAPI endpoint catalog\n", target)
+	return content
 }
 
 func surfaceFallbackSkillName(target string) string {
 	return fmt.Sprintf("surface-%s.md", strings.TrimSpace(strings.ReplaceAll(target, "/", "-")))
 }
 
-func resolveServiceInspection(podDir string, p *pod.Pod, serviceName string, svc *pod.Service, imageRefs map[string]string, infos map[string]*inspect.ClawInfo) (string, *inspect.ClawInfo, error) {
+func resolveServiceInspection(podDir string, p *pod.Pod, serviceName string, svc *pod.Service, imageRefs map[string]string, infos map[string]*inspect.ClawInfo, skipBuilds bool) (string, *inspect.ClawInfo, error) {
 	if imageRef, ok := imageRefs[serviceName]; ok {
 		return imageRef, infos[serviceName], nil
 	}
 
-	imageRef, err := resolveManagedServiceImage(podDir, p, serviceName, svc)
+	imageRef, err := resolveManagedServiceImage(podDir, p, serviceName, svc, skipBuilds)
 	if err != nil {
 		return "", nil, err
 	}
@@ -1630,7 +2200,7 @@ type serviceBuildConfig struct {
 	Target     string
 }
 
-func resolveManagedServiceImage(podDir string, p *pod.Pod, serviceName string, svc *pod.Service) (string, error) {
+func resolveManagedServiceImage(podDir string, p *pod.Pod, serviceName string, svc *pod.Service, skipBuilds bool) (string, error) {
 	imageRef := strings.TrimSpace(svc.Image)
 	if imageRef != "" && imageExistsLocally(imageRef) {
 		return imageRef, nil
@@ -1661,6 +2231,9 @@ func resolveManagedServiceImage(podDir string, p *pod.Pod, serviceName string, s
 		return imageRef, nil
 	}
 
+	if skipBuilds {
+		return "", fmt.Errorf("service %q: image %q is not built; claw plan does not build images (run 'claw up' or 'docker compose build' first)", serviceName, imageRef)
+	}
 	fmt.Printf("[claw] %s: building image %s for inspection\n", serviceName, imageRef)
 	if err := buildManagedServiceImage(podDir, imageRef, cfg); err != nil {
 		return "", fmt.Errorf("service %q: %w", serviceName, err)
@@ -1934,11 +2507,19 @@ func dockerBuildTaggedImageDefault(imageRef, dockerfilePath, contextDir string,
 	return nil
 }
 
-// ensureInfraImages checks that cllama proxy and clawdash images exist locally,
-// building them from source when missing.
-func ensureInfraImages(cllamaEnabled bool, proxies []pod.CllamaProxyConfig, dash *pod.ClawdashConfig) error {
+// ensureInfraImages checks that cllama proxy, clawdash, egress proxy and mock
+// LLM images exist locally, building them from source when missing.
+func ensureInfraImages(cllamaEnabled bool, proxies []pod.CllamaProxyConfig, dash *pod.ClawdashConfig, egressCfg *pod.EgressConfig, mockCfg *pod.MockLLMConfig) error {
 	if cllamaEnabled {
 		for _, proxy := range proxies {
+			if cllama.ProxyServiceName(proxy.ProxyType) != "cllama" {
+				// Only the passthrough reference proxy is built from this repo;
+				// other proxy types ship their own images.
+				if err := ensurePulledImage(proxy.Image, cllama.ProxyServiceName(proxy.ProxyType)); err != nil {
+					return err
+				}
+				continue
+			}
 			if err := ensureImage(proxy.Image, "cllama", "cllama/Dockerfile", "cllama"); err != nil {
 				return err
 			}
@@ -1949,6 +2530,16 @@ func ensureInfraImages(cllamaEnabled bool, proxies []pod.CllamaProxyConfig, dash
 			return err
 		}
 	}
+	if egressCfg != nil {
+		if err := ensureImage(egressCfg.Image, "clawegress", "dockerfiles/clawegress/Dockerfile", "."); err != nil {
+			return err
+		}
+	}
+	if mockCfg != nil {
+		if err := ensureImage(mockCfg.Image, "clawmockllm", "dockerfiles/clawmockllm/Dockerfile", "."); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1986,6 +2577,19 @@ func ensureImage(imageRef, name, dockerfilePath, contextDir string) error {
 	return nil
 }
 
+// ensurePulledImage makes sure an image with no in-repo source exists locally,
+// pulling it when missing.
+func ensurePulledImage(imageRef, name string) error {
+	if imageExistsLocally(imageRef) {
+		return nil
+	}
+	fmt.Printf("[claw] pulling %s image\n", name)
+	if err := runInfraDockerCommand("pull", imageRef); err != nil {
+		return fmt.Errorf("%s image %q is not available locally and could not be pulled: %w", name, imageRef, err)
+	}
+	return nil
+}
+
 func runInfraDockerCommandDefault(args ...string) error {
 	cmd := exec.Command("docker", args...)
 	cmd.Stdout = os.Stdout
@@ -2023,5 +2627,8 @@ func findRepoRoot() (string, bool) {
 
 func init() {
 	composeUpCmd.Flags().BoolVarP(&composeUpDetach, "detach", "d", false, "Run in background")
+	composeUpCmd.Flags().BoolVar(&composeUpDryRun, "dry-run", false, "Show what would change (same as 'claw plan') without touching Docker")
+	composeUpCmd.Flags().BoolVar(&composeUpMockLLM, "mock-llm", false, "Route model calls to a deterministic OpenAI-compatible stub instead of real providers")
+	composeUpCmd.Flags().BoolVar(&composeUpScanImageCredentials, "scan-image-credentials", false, "Scan cllama agent image filesystems for credential files (.env, auth.json, ~/.config/openai)")
 	rootCmd.AddCommand(composeUpCmd)
 }
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"slices"
 	"strings"
 	"testing"
 
+	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/driver/openclaw"
 	"github.com/mostlydev/clawdapus/internal/inspect"
 	"github.com/mostlydev/clawdapus/internal/pod"
+	"github.com/mostlydev/clawdapus/internal/runtime"
 )
 
 func TestComposeUpRejectsFileFlagAndPositionalTogether(t *testing.T) {
@@ -29,28 +32,6 @@ func TestComposeUpRejectsFileFlagAndPositionalTogether(t *testing.T) {
 	}
 }
 
-func TestMergeResolvedSkills(t *testing.T) {
-	imageSkills := []driver.ResolvedSkill{
-		{Name: "agent.md", HostPath: "/host/image/agent.md"},
-		{Name: "shared.md", HostPath: "/host/image/shared.md"},
-	}
-	podSkills := []driver.ResolvedSkill{
-		{Name: "shared.md", HostPath: "/host/pod/shared.md"},
-		{Name: "pod.md", HostPath: "/host/pod/pod.md"},
-	}
-
-	merged := mergeResolvedSkills(imageSkills, podSkills)
-	if len(merged) != 3 {
-		t.Fatalf("expected 3 skills, got %d", len(merged))
-	}
-	if merged[1].HostPath != "/host/pod/shared.md" {
-		t.Fatalf("expected pod override for shared.md, got %q", merged[1].HostPath)
-	}
-	if merged[2].Name != "pod.md" {
-		t.Fatalf("expected pod-level-only skill to be appended, got %q", merged[2].Name)
-	}
-}
-
 func TestResolveSkillEmitWritesFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -67,7 +48,7 @@ func TestResolveSkillEmitWritesFile(t *testing.T) {
 		writeRuntimeFile = prevWriter
 	}()
 
-	skill, err := resolveSkillEmit("gateway", tmpDir, "claw/openclaw:latest", "/app/SKILL.md")
+	skill, err := resolveSkillEmit("gateway", tmpDir, "claw/openclaw:latest", "/app/SKILL.md", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -93,7 +74,7 @@ func TestResolveSkillEmitWritesFile(t *testing.T) {
 func TestResolveSkillEmitRejectsInvalidPath(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	_, err := resolveSkillEmit("gateway", tmpDir, "claw/openclaw:latest", "/")
+	_, err := resolveSkillEmit("gateway", tmpDir, "claw/openclaw:latest", "/", nil)
 	if err == nil {
 		t.Fatal("expected invalid emitted skill path error")
 	}
@@ -109,7 +90,7 @@ func TestResolveSkillEmitFallsBackOnExtractionError(t *testing.T) {
 	defer func() { extractServiceSkillFromImage = prevExtractor }()
 
 	// Should return nil, nil — pod startup continues with fallback skill
-	skill, err := resolveSkillEmit("gateway", tmpDir, "claw/openclaw:latest", "/app/SKILL.md")
+	skill, err := resolveSkillEmit("gateway", tmpDir, "claw/openclaw:latest", "/app/SKILL.md", nil)
 	if err != nil {
 		t.Fatalf("expected warn+fallback (nil error), got: %v", err)
 	}
@@ -193,6 +174,36 @@ func TestResolveRuntimePlaceholdersUsesDotEnvForHandleTopology(t *testing.T) {
 	}
 }
 
+func TestResolveRuntimePlaceholdersRevalidatesCllamaCredentials(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("DESK_KEY=sk-or-v1-abc\nEMPTY_KEY=\n"), 0o644); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	newPod := func(value string) *pod.Pod {
+		return &pod.Pod{Services: map[string]*pod.Service{
+			"desk": {Claw: &pod.ClawBlock{CllamaCreds: map[string]string{"OPENROUTER_API_KEY": value}}},
+		}}
+	}
+
+	p := newPod("${DESK_KEY}")
+	if err := resolveRuntimePlaceholders(tmpDir, p); err != nil {
+		t.Fatalf("resolveRuntimePlaceholders: %v", err)
+	}
+	if got := p.Services["desk"].Claw.CllamaCreds["OPENROUTER_API_KEY"]; got != "sk-or-v1-abc" {
+		t.Fatalf("expected expanded credential, got %q", got)
+	}
+
+	for value, want := range map[string]string{
+		"${EMPTY_KEY}":   "must not be empty",
+		"${MISSING_KEY}": "unset variable",
+	} {
+		err := resolveRuntimePlaceholders(tmpDir, newPod(value))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", value, want, err)
+		}
+	}
+}
+
 func TestResolveRuntimePlaceholdersExpandsDiscordAllowFromHandlesAndServices(t *testing.T) {
 	p := &pod.Pod{
 		Name: "test-pod",
@@ -407,7 +418,7 @@ func TestResetRuntimeDirClearsStaleContents(t *testing.T) {
 		t.Fatalf("write stale file: %v", err)
 	}
 
-	if err := resetRuntimeDir(runtimeDir); err != nil {
+	if err := resetRuntimeDir(runtimeDir, nil); err != nil {
 		t.Fatalf("reset runtime dir: %v", err)
 	}
 
@@ -426,6 +437,56 @@ func TestResetRuntimeDirClearsStaleContents(t *testing.T) {
 	}
 }
 
+func TestResetRuntimeDirPreservesTrackLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	runtimeDir := filepath.Join(tmpDir, ".claw-runtime")
+	trackLog := filepath.Join(runtimeDir, "bot", "track", "mutations.jsonl")
+	if err := os.MkdirAll(filepath.Dir(trackLog), 0o755); err != nil {
+		t.Fatalf("create track dir: %v", err)
+	}
+	if err := os.WriteFile(trackLog, []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("write track log: %v", err)
+	}
+	staleFile := filepath.Join(runtimeDir, "bot", "CLAWDAPUS.md")
+	if err := os.WriteFile(staleFile, []byte("stale"), 0o644); err != nil {
+		t.Fatalf("write stale file: %v", err)
+	}
+
+	if err := resetRuntimeDir(runtimeDir, nil); err != nil {
+		t.Fatalf("reset runtime dir: %v", err)
+	}
+
+	if _, err := os.Stat(trackLog); err != nil {
+		t.Fatalf("expected track log to survive reset: %v", err)
+	}
+	if _, err := os.Stat(staleFile); !os.IsNotExist(err) {
+		t.Fatalf("expected stale file to be removed, got err=%v", err)
+	}
+}
+
+func TestMountTrackLogAddsWritableMountAndEnv(t *testing.T) {
+	svcRuntimeDir := t.TempDir()
+	result := &driver.MaterializeResult{}
+
+	if err := mountTrackLog(result, svcRuntimeDir); err != nil {
+		t.Fatalf("mountTrackLog: %v", err)
+	}
+
+	if len(result.Mounts) != 1 {
+		t.Fatalf("expected one mount, got %+v", result.Mounts)
+	}
+	m := result.Mounts[0]
+	if m.HostPath != filepath.Join(svcRuntimeDir, "track") || m.ContainerPath != "/claw/track" || m.ReadOnly {
+		t.Fatalf("unexpected track mount: %+v", m)
+	}
+	if _, err := os.Stat(m.HostPath); err != nil {
+		t.Fatalf("expected host track dir: %v", err)
+	}
+	if got := result.Environment["CLAW_TRACK_LOG"]; got != "/claw/track/mutations.jsonl" {
+		t.Fatalf("unexpected CLAW_TRACK_LOG: %q", got)
+	}
+}
+
 func TestMaterializeServiceSurfaceGuidesAppendsCustomServiceManuals(t *testing.T) {
 	tmpDir := t.TempDir()
 	runtimeDir := filepath.Join(tmpDir, "runtime")
@@ -546,9 +607,11 @@ func TestRuntimeConsumerServicesIncludesManagedServicesAndInfra(t *testing.T) {
 		},
 		[]pod.CllamaProxyConfig{{ProxyType: "passthrough"}},
 		&pod.ClawdashConfig{},
+		&pod.EgressConfig{},
+		&pod.MockLLMConfig{},
 	)
 
-	want := []string{"assistant", "clawdash", "cllama", "worker-0", "worker-1"}
+	want := []string{"assistant", "claw-egress", "claw-mock-llm", "clawdash", "cllama", "worker-0", "worker-1"}
 	if !slices.Equal(services, want) {
 		t.Fatalf("unexpected runtime consumer services: got %v want %v", services, want)
 	}
@@ -562,6 +625,8 @@ func TestRuntimeConsumerServicesDeduplicatesAndSorts(t *testing.T) {
 		},
 		[]pod.CllamaProxyConfig{{ProxyType: "passthrough"}, {ProxyType: "passthrough"}},
 		nil,
+		nil,
+		nil,
 	)
 
 	want := []string{"alpha", "cllama", "zeta"}
@@ -649,7 +714,7 @@ func TestResolveManagedServiceImageBuildOnlyClawfile(t *testing.T) {
 		return nil
 	}
 
-	imageRef, err := resolveManagedServiceImage(tmpDir, p, "bot", svc)
+	imageRef, err := resolveManagedServiceImage(tmpDir, p, "bot", svc, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -721,7 +786,7 @@ func TestResolveManagedServiceImageBuildsPlainDockerfile(t *testing.T) {
 		return nil
 	}
 
-	imageRef, err := resolveManagedServiceImage(tmpDir, p, "bot", svc)
+	imageRef, err := resolveManagedServiceImage(tmpDir, p, "bot", svc, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -753,7 +818,7 @@ func TestResolveManagedServiceImageRequiresImageOrBuild(t *testing.T) {
 	defer func() { imageExistsLocally = prevExists }()
 	imageExistsLocally = func(string) bool { return false }
 
-	_, err := resolveManagedServiceImage(t.TempDir(), p, "bot", svc)
+	_, err := resolveManagedServiceImage(t.TempDir(), p, "bot", svc, false)
 	if err == nil {
 		t.Fatal("expected missing image/build error")
 	}
@@ -910,7 +975,7 @@ func TestResolveServiceSurfaceSkillsFallsBackWhenNoEmitExists(t *testing.T) {
 		},
 	}
 
-	updatedSurfaces, skills, err := resolveServiceSurfaceSkills(t.TempDir(), tmpDir, p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{})
+	updatedSurfaces, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), tmpDir, p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -964,7 +1029,7 @@ func TestResolveServiceSurfaceSkillsPrefersTargetEmit(t *testing.T) {
 		},
 	}
 
-	updatedSurfaces, skills, err := resolveServiceSurfaceSkills(t.TempDir(), runtimeDir, p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{})
+	updatedSurfaces, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), runtimeDir, p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -986,6 +1051,316 @@ func TestResolveServiceSurfaceSkillsPrefersTargetEmit(t *testing.T) {
 	}
 }
 
+func TestResolveServiceSurfaceSkillsUsesMCPDiscovery(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevDiscover := discoverMCPTools
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		discoverMCPTools = prevDiscover
+	}()
+
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{MCPEndpoint: "8080/mcp", DiscoveryEnv: "API_KEY"}, nil
+	}
+	discoverMCPTools = func(imageRef string, endpoint runtime.MCPEndpoint, env map[string]string) ([]runtime.MCPTool, error) {
+		if imageRef != "example/scanner:latest" {
+			t.Fatalf("unexpected image ref: %q", imageRef)
+		}
+		if endpoint.Port != "8080" || endpoint.Path != "/mcp" {
+			t.Fatalf("unexpected endpoint: %+v", endpoint)
+		}
+		if len(env) != 1 || env["API_KEY"] != "k" {
+			t.Fatalf("expected only claw.discovery.env names from target service env, got %v", env)
+		}
+		return []runtime.MCPTool{{Name: "get_price", Description: "Current token price"}}, nil
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "market-scanner", Ports: []string{"8080"}}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"market-scanner": {Image: "example/scanner:latest", Environment: map[string]string{"API_KEY": "k", "DB_PASSWORD": "secret"}},
+		},
+	}
+
+	updatedSurfaces, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(skills) != 1 || updatedSurfaces[0].SkillName != "surface-market-scanner.md" {
+		t.Fatalf("unexpected skills %+v / surfaces %+v", skills, updatedSurfaces)
+	}
+	data, err := os.ReadFile(skills[0].HostPath)
+	if err != nil {
+		t.Fatalf("read MCP skill: %v", err)
+	}
+	if !strings.Contains(string(data), "### get_price") || !strings.Contains(string(data), "http://market-scanner:8080/mcp") {
+		t.Fatalf("expected discovered tools in skill, got:\n%s", data)
+	}
+}
+
+func TestResolveServiceSurfaceSkillsDiscoversEachTargetOncePerRun(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevDiscover := discoverMCPTools
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		discoverMCPTools = prevDiscover
+	}()
+
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{MCPEndpoint: "8080/mcp"}, nil
+	}
+	calls := 0
+	discoverMCPTools = func(string, runtime.MCPEndpoint, map[string]string) ([]runtime.MCPTool, error) {
+		calls++
+		return []runtime.MCPTool{{Name: "get_price"}}, nil
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "market-scanner", Ports: []string{"8080"}}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"market-scanner": {Image: "example/scanner:latest"},
+		},
+	}
+	discovery := &skillDiscovery{}
+	for _, consumer := range []string{"bot-a", "bot-b"} {
+		_, skills, origins, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), filepath.Join(t.TempDir(), consumer), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, discovery)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", consumer, err)
+		}
+		data, err := os.ReadFile(skills[0].HostPath)
+		if err != nil {
+			t.Fatalf("%s: read MCP skill: %v", consumer, err)
+		}
+		if !strings.Contains(string(data), "### get_price") || origins[skills[0].Name].source != skillSourceMCP {
+			t.Fatalf("%s: expected cached MCP skill, got %q from %+v", consumer, data, origins)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected one discovery container per target, got %d", calls)
+	}
+}
+
+func TestResolveServiceSurfaceSkillsFallsBackWhenMCPDiscoveryFails(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevDiscover := discoverMCPTools
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		discoverMCPTools = prevDiscover
+	}()
+
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{MCPEndpoint: "8080/mcp"}, nil
+	}
+	discoverMCPTools = func(string, runtime.MCPEndpoint, map[string]string) ([]runtime.MCPTool, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "market-scanner", Ports: []string{"8080"}}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"market-scanner": {Image: "example/scanner:latest"},
+		},
+	}
+
+	_, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatalf("expected warn+fallback (nil error), got: %v", err)
+	}
+	data, err := os.ReadFile(skills[0].HostPath)
+	if err != nil {
+		t.Fatalf("read fallback skill: %v", err)
+	}
+	if !strings.Contains(string(data), "(service surface)") {
+		t.Fatalf("expected generated fallback skill, got:\n%s", data)
+	}
+}
+
+func TestResolveServiceSurfaceSkillsUsesOpenAPIDiscovery(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevFetch := fetchOpenAPISpec
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		fetchOpenAPISpec = prevFetch
+	}()
+
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{OpenAPI: "/app/openapi.yaml", OpenAPIAuth: "TRADING_API_TOKEN"}, nil
+	}
+	fetchOpenAPISpec = func(imageRef string, source runtime.OpenAPISource, env map[string]string) ([]byte, error) {
+		if imageRef != "example/trading-api:latest" || source.File != "/app/openapi.yaml" {
+			t.Fatalf("unexpected fetch %q %+v", imageRef, source)
+		}
+		return []byte(`openapi: 3.0.0
+info: {title: Trading API, version: "1"}
+components:
+  securitySchemes:
+    bearerAuth: {type: http, scheme: bearer}
+security:
+  - bearerAuth: []
+paths:
+  /orders:
+    post:
+      operationId: placeOrder
+`), nil
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "trading-api", Ports: []string{"4000"}}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"trading-api": {Image: "example/trading-api:latest"},
+		},
+	}
+
+	_, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(skills[0].HostPath)
+	if err != nil {
+		t.Fatalf("read OpenAPI skill: %v", err)
+	}
+	for _, want := range []string{"`POST /orders` (`placeOrder`)", "`$TRADING_API_TOKEN`", "http://trading-api:4000"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in skill, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestResolveServiceSurfaceSkillsPrefersMCPOverOpenAPI(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevDiscover := discoverMCPTools
+	prevFetch := fetchOpenAPISpec
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		discoverMCPTools = prevDiscover
+		fetchOpenAPISpec = prevFetch
+	}()
+
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{MCPEndpoint: "8080/mcp", OpenAPI: "8080/openapi.json"}, nil
+	}
+	discoverMCPTools = func(string, runtime.MCPEndpoint, map[string]string) ([]runtime.MCPTool, error) {
+		return []runtime.MCPTool{{Name: "get_price"}}, nil
+	}
+	fetchOpenAPISpec = func(string, runtime.OpenAPISource, map[string]string) ([]byte, error) {
+		t.Fatal("OpenAPI should not be fetched when MCP discovery succeeds")
+		return nil, nil
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "market-scanner", Ports: []string{"8080"}}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"market-scanner": {Image: "example/scanner:latest"},
+		},
+	}
+
+	_, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(skills[0].HostPath)
+	if err != nil {
+		t.Fatalf("read skill: %v", err)
+	}
+	if !strings.Contains(string(data), "### get_price") {
+		t.Fatalf("expected MCP skill, got:\n%s", data)
+	}
+}
+
+func TestResolveServiceSurfaceSkillsCompilesDescribeBlock(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+	}()
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{}, nil
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "cache", Ports: []string{"6379"}}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"cache": {
+				Image: "redis:7",
+				Describe: &driver.ServiceDescription{
+					Protocol:   "redis",
+					Auth:       []string{"REDIS_PASSWORD"},
+					Operations: []driver.ServiceOperation{{Name: "GET price:<symbol>"}},
+				},
+			},
+		},
+	}
+
+	updatedSurfaces, skills, _, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(skills) != 1 || updatedSurfaces[0].SkillName != "surface-cache.md" {
+		t.Fatalf("unexpected skills %+v / surfaces %+v", skills, updatedSurfaces)
+	}
+	data, err := os.ReadFile(skills[0].HostPath)
+	if err != nil {
+		t.Fatalf("read described skill: %v", err)
+	}
+	for _, want := range []string{"**Protocol:** redis", "`$REDIS_PASSWORD`", "`GET price:<symbol>`"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in skill, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestResolveServiceSurfaceSkillsPrefersEmittedSkillOverDescribe(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevExtract := extractServiceSkillFromImage
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		extractServiceSkillFromImage = prevExtract
+	}()
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{SkillEmit: "/app/SKILL.md"}, nil
+	}
+	extractServiceSkillFromImage = func(string, string) ([]byte, error) {
+		return []byte("# Emitted API skill\n"), nil
+	}
+
+	surfaces := []driver.ResolvedSurface{{Scheme: "service", Target: "api"}}
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{
+			"api": {Image: "example/api", Describe: &driver.ServiceDescription{Description: "Pod description."}},
+		},
+	}
+	updatedSurfaces, skills, origins, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(skills) != 1 || skills[0].Name != "SKILL.md" || updatedSurfaces[0].SkillName != "SKILL.md" {
+		t.Fatalf("expected the emitted skill to win, got %+v / %+v", skills, updatedSurfaces)
+	}
+	if origins["SKILL.md"].source != skillSourceEmitted {
+		t.Fatalf("expected service-emitted origin, got %+v", origins)
+	}
+}
+
 func testInvokeHandles() map[string]*driver.HandleInfo {
 	return map[string]*driver.HandleInfo{
 		"discord": {
@@ -1190,14 +1565,176 @@ func TestDetectCllama(t *testing.T) {
 	}
 }
 
-func TestCollectProxyTypes(t *testing.T) {
+func TestPlanProxyChains(t *testing.T) {
+	claws := map[string]*driver.ResolvedClaw{
+		"bot-a":    {Cllama: []string{"policy", "passthrough"}},
+		"bot-b":    {Cllama: []string{"policy", "passthrough"}},
+		"research": {Cllama: []string{"passthrough"}},
+		"plain":    {},
+	}
+	hops, err := planProxyChains(claws)
+	if err != nil {
+		t.Fatalf("planProxyChains: %v", err)
+	}
+	want := []cllama.Hop{{ProxyType: "passthrough"}, {ProxyType: "policy", Next: "passthrough"}}
+	if !reflect.DeepEqual(hops, want) {
+		t.Errorf("expected %+v, got %+v", want, hops)
+	}
+}
+
+func TestPlanProxyChainsRejectsConflictingChains(t *testing.T) {
 	claws := map[string]*driver.ResolvedClaw{
 		"bot-a": {Cllama: []string{"passthrough"}},
 		"bot-b": {Cllama: []string{"passthrough", "policy"}},
 	}
-	types := collectProxyTypes(claws)
-	if len(types) != 2 || types[0] != "passthrough" || types[1] != "policy" {
-		t.Errorf("expected [passthrough policy], got %v", types)
+	if _, err := planProxyChains(claws); err == nil {
+		t.Fatal("expected error when passthrough is both terminal and forwarding")
+	}
+}
+
+func TestProxyAgentsOnlyIncludesAgentsRoutedThroughProxy(t *testing.T) {
+	claws := map[string]*driver.ResolvedClaw{
+		"research": {Cllama: []string{"passthrough"}},
+		"trader":   {Cllama: []string{"policy"}},
+		"analyst":  {Cllama: []string{"Policy"}},
+	}
+	agents := []string{"analyst", "research", "trader"}
+
+	if got := proxyAgents("policy", claws, agents); !slices.Equal(got, []string{"analyst", "trader"}) {
+		t.Errorf("expected policy agents [analyst trader], got %v", got)
+	}
+	if got := proxyAgents("passthrough", claws, agents); !slices.Equal(got, []string{"research"}) {
+		t.Errorf("expected passthrough agents [research], got %v", got)
+	}
+}
+
+func TestProxyEnvironmentIsolatesCllamaEnvPerProxy(t *testing.T) {
+	p := &pod.Pod{
+		Name: "desk",
+		Services: map[string]*pod.Service{
+			"research": {Claw: &pod.ClawBlock{CllamaEnv: map[string]string{"OPENROUTER_API_KEY": "or-key"}}},
+			"trader": {Claw: &pod.ClawBlock{CllamaEnv: map[string]string{
+				"ANTHROPIC_API_KEY": "ant-key",
+				"POLICY_RULESET":    "strict",
+			}}},
+		},
+	}
+
+	passthrough, err := proxyEnvironment(p, cllama.Hop{ProxyType: "passthrough"}, []string{"research"})
+	if err != nil {
+		t.Fatalf("proxyEnvironment: %v", err)
+	}
+	if passthrough["OPENROUTER_API_KEY"] != "or-key" || passthrough["CLAW_POD"] != "desk" {
+		t.Errorf("expected research key and pod name, got %v", passthrough)
+	}
+	if _, ok := passthrough["ANTHROPIC_API_KEY"]; ok {
+		t.Errorf("passthrough must not receive keys of agents it does not serve: %v", passthrough)
+	}
+
+	policy, err := proxyEnvironment(p, cllama.Hop{ProxyType: "policy"}, []string{"trader"})
+	if err != nil {
+		t.Fatalf("proxyEnvironment: %v", err)
+	}
+	if policy["ANTHROPIC_API_KEY"] != "ant-key" || policy["POLICY_RULESET"] != "strict" {
+		t.Errorf("expected trader env on terminal policy proxy, got %v", policy)
+	}
+	if _, ok := policy["OPENROUTER_API_KEY"]; ok {
+		t.Errorf("policy must not receive research keys: %v", policy)
+	}
+
+	chained, err := proxyEnvironment(p, cllama.Hop{ProxyType: "policy", Next: "passthrough"}, []string{"trader"})
+	if err != nil {
+		t.Fatalf("proxyEnvironment: %v", err)
+	}
+	if _, ok := chained["ANTHROPIC_API_KEY"]; ok {
+		t.Errorf("non-terminal hop must not receive provider keys: %v", chained)
+	}
+	if chained["POLICY_RULESET"] != "strict" {
+		t.Errorf("non-terminal hop should keep non-provider settings, got %v", chained)
+	}
+}
+
+func TestProxyEnvironmentRejectsConflictingCllamaEnv(t *testing.T) {
+	p := &pod.Pod{
+		Name: "desk",
+		Services: map[string]*pod.Service{
+			"alpha": {Claw: &pod.ClawBlock{CllamaEnv: map[string]string{"OPENROUTER_API_KEY": "key-a"}}},
+			"beta":  {Claw: &pod.ClawBlock{CllamaEnv: map[string]string{"OPENROUTER_API_KEY": "key-b"}}},
+			"gamma": {Claw: &pod.ClawBlock{CllamaEnv: map[string]string{"OPENROUTER_API_KEY": "key-a"}}},
+		},
+	}
+
+	_, err := proxyEnvironment(p, cllama.Hop{ProxyType: "passthrough"}, []string{"alpha", "beta"})
+	if err == nil || !strings.Contains(err.Error(), "OPENROUTER_API_KEY") || !strings.Contains(err.Error(), "cllama-credentials") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+
+	env, err := proxyEnvironment(p, cllama.Hop{ProxyType: "passthrough"}, []string{"alpha", "gamma"})
+	if err != nil || env["OPENROUTER_API_KEY"] != "key-a" {
+		t.Fatalf("expected identical values to merge, got %v, %v", env, err)
+	}
+
+	// Keys dropped on non-terminal hops cannot conflict.
+	if _, err := proxyEnvironment(p, cllama.Hop{ProxyType: "policy", Next: "passthrough"}, []string{"alpha", "beta"}); err != nil {
+		t.Fatalf("expected no conflict on non-terminal hop, got %v", err)
+	}
+}
+
+func TestOffsetPort(t *testing.T) {
+	if got := offsetPort("8181", 2); got != "8183" {
+		t.Errorf("expected 8183, got %q", got)
+	}
+	if got := offsetPort("bogus", 1); got != "bogus" {
+		t.Errorf("expected unparsable port unchanged, got %q", got)
+	}
+}
+
+func TestEnsureInfraImagesPullsNonPassthroughProxyWithoutBuilding(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevFindRepoRoot := findClawdapusRepoRoot
+	prevRunInfra := runInfraDockerCommand
+	defer func() {
+		imageExistsLocally = prevExists
+		findClawdapusRepoRoot = prevFindRepoRoot
+		runInfraDockerCommand = prevRunInfra
+	}()
+
+	imageExistsLocally = func(ref string) bool { return ref == "ghcr.io/mostlydev/cllama:latest" }
+	findClawdapusRepoRoot = func() (string, bool) {
+		t.Fatal("non-passthrough proxies must not be built from the cllama source")
+		return "", false
+	}
+	var calls [][]string
+	runInfraDockerCommand = func(args ...string) error {
+		calls = append(calls, append([]string(nil), args...))
+		return fmt.Errorf("pull failed")
+	}
+
+	proxies := []pod.CllamaProxyConfig{
+		{ProxyType: "passthrough", Image: "ghcr.io/mostlydev/cllama:latest"},
+		{ProxyType: "policy", Image: "ghcr.io/mostlydev/cllama-policy:latest"},
+	}
+	err := ensureInfraImages(true, proxies, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "cllama-policy") {
+		t.Fatalf("expected pull failure for cllama-policy, got %v", err)
+	}
+	want := [][]string{{"pull", "ghcr.io/mostlydev/cllama-policy:latest"}}
+	if !slices.EqualFunc(calls, want, func(a, b []string) bool { return slices.Equal(a, b) }) {
+		t.Fatalf("unexpected docker calls: got %v want %v", calls, want)
+	}
+}
+
+func TestValidateCllamaPolicyScopeRequiresCllama(t *testing.T) {
+	own := &pod.ClawBlock{OwnPolicy: true, CllamaPolicy: &cllama.Policy{DailyBudgetUSD: 5}}
+	if err := validateCllamaPolicyScope("bot", own, &driver.ResolvedClaw{}); err == nil || !strings.Contains(err.Error(), "without cllama") {
+		t.Fatalf("expected cllama-policy without cllama to fail, got %v", err)
+	}
+	if err := validateCllamaPolicyScope("bot", own, &driver.ResolvedClaw{Cllama: []string{"passthrough"}}); err != nil {
+		t.Fatalf("expected policy behind cllama to pass, got %v", err)
+	}
+	defaults := &pod.ClawBlock{CllamaPolicy: &cllama.Policy{DailyBudgetUSD: 5}}
+	if err := validateCllamaPolicyScope("bot", defaults, &driver.ResolvedClaw{}); err != nil {
+		t.Fatalf("expected pod defaults alone to pass, got %v", err)
 	}
 }
 
@@ -1238,3 +1775,54 @@ func TestIsProviderKey(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateCllamaPolicyModelsRejectsDisallowedSlot(t *testing.T) {
+	rc := &driver.ResolvedClaw{Models: map[string]string{
+		"primary":  "anthropic/claude-sonnet-4",
+		"fallback": "openai/gpt-4o",
+	}}
+	policy := &cllama.Policy{AllowedModels: []string{"anthropic/*"}}
+
+	err := validateCllamaPolicyModels("trader", rc, policy)
+	if err == nil || !strings.Contains(err.Error(), `"fallback"`) {
+		t.Fatalf("expected fallback slot rejection, got %v", err)
+	}
+	if err := validateCllamaPolicyModels("trader", rc, nil); err != nil {
+		t.Fatalf("expected nil policy to allow all models, got %v", err)
+	}
+}
+
+func TestBuildContextContractListsIncludesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	enforcePath := filepath.Join(dir, "risk-limits.md")
+	guidePath := filepath.Join(dir, "style.md")
+	if err := os.WriteFile(enforcePath, []byte("No unauthorized trades.\n"), 0o644); err != nil {
+		t.Fatalf("write enforce include: %v", err)
+	}
+	if err := os.WriteFile(guidePath, []byte("Be concise.\n"), 0o644); err != nil {
+		t.Fatalf("write guide include: %v", err)
+	}
+
+	contract, err := buildContextContract([]driver.ResolvedInclude{
+		{ID: "risk_limits", Mode: "enforce", Description: "Hard trading rules", HostPath: enforcePath},
+		{ID: "style", Mode: "guide", HostPath: guidePath},
+	})
+	if err != nil {
+		t.Fatalf("buildContextContract: %v", err)
+	}
+	if len(contract.Includes) != 2 {
+		t.Fatalf("expected 2 includes, got %+v", contract.Includes)
+	}
+	first := contract.Includes[0]
+	if first.ID != "risk_limits" || first.Mode != "enforce" || first.Text != "No unauthorized trades.\n" || first.SHA256 == "" {
+		t.Fatalf("unexpected enforce include: %+v", first)
+	}
+	if contract.Includes[1].Mode != "guide" {
+		t.Fatalf("expected guide include second, got %+v", contract.Includes[1])
+	}
+
+	none, err := buildContextContract(nil)
+	if err != nil || none != nil {
+		t.Fatalf("expected nil contract without includes, got %+v, %v", none, err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configHashLabel carries a digest of everything a runtime consumer starts
+// from: its compose definition and the content of its mounted inputs. A
+// changed digest changes the service definition, so docker compose recreates
+// exactly the services whose materialized config changed and leaves the rest
+// running.
+const configHashLabel = "claw.config-hash"
+
+// serviceConfigHashes digests the compose definition and mounted inputs of
+// each named service. Services missing from the compose file are skipped.
+func serviceConfigHashes(composeYAML []byte, inputs *runtimeInputs, services []string) (map[string]string, error) {
+	var cf struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(composeYAML, &cf); err != nil {
+		return nil, fmt.Errorf("parse compose for config hashes: %w", err)
+	}
+	hashes := make(map[string]string, len(services))
+	for _, name := range services {
+		svc, ok := cf.Services[name]
+		if !ok {
+			continue
+		}
+		// encoding/json sorts map keys, so the definition digests stably.
+		def, err := json.Marshal(svc)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: hash config: %w", name, err)
+		}
+		h := sha256.New()
+		h.Write(def)
+		var files map[string]mountedInput
+		if inputs != nil {
+			files = inputs.Services[name]
+		}
+		paths := make([]string, 0, len(files))
+		for p := range files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			fmt.Fprintf(h, "\n%s %s", p, files[p].SHA256)
+		}
+		hashes[name] = hex.EncodeToString(h.Sum(nil))[:16]
+	}
+	return hashes, nil
+}
+
+// stampConfigHashes sets configHashLabel on each hashed service of a generated
+// compose file, leaving the rest of the document as emitted.
+func stampConfigHashes(composeYAML []byte, hashes map[string]string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(composeYAML, &doc); err != nil {
+		return nil, fmt.Errorf("parse compose for config hashes: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return composeYAML, nil
+	}
+	services := mappingValue(doc.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return composeYAML, nil
+	}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		hash, ok := hashes[services.Content[i].Value]
+		if !ok {
+			continue
+		}
+		svc := services.Content[i+1]
+		labels := mappingValue(svc, "labels")
+		if labels == nil {
+			labels = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(svc, "labels", labels)
+		}
+		switch labels.Kind {
+		case yaml.MappingNode:
+			setMappingValue(labels, configHashLabel, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: hash})
+		case yaml.SequenceNode:
+			labels.Content = append(labels.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: configHashLabel + "=" + hash})
+		default:
+			return nil, fmt.Errorf("service %q: labels must be a map or list", services.Content[i].Value)
+		}
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("stamp config hashes: %w", err)
+	}
+	return out, nil
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces key in m or inserts it in sorted position, which
+// keeps the emitter's sorted key order.
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	at := len(m.Content)
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+		if m.Content[i].Value > key && at == len(m.Content) {
+			at = i
+		}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	m.Content = append(m.Content[:at], append([]*yaml.Node{keyNode, value}, m.Content[at:]...)...)
+}
+
+// previousConfigHashes reads the hashes stamped into an earlier
+// compose.generated.yml. A missing or unreadable file yields none.
+func previousConfigHashes(path string) map[string]string {
+	hashes := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return hashes
+	}
+	var cf struct {
+		Services map[string]struct {
+			Labels interface{} `yaml:"labels"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return hashes
+	}
+	for name, svc := range cf.Services {
+		switch labels := svc.Labels.(type) {
+		case map[string]interface{}:
+			if v, ok := labels[configHashLabel].(string); ok {
+				hashes[name] = v
+			}
+		case []interface{}:
+			for _, item := range labels {
+				if s, ok := item.(string); ok && strings.HasPrefix(s, configHashLabel+"=") {
+					hashes[name] = strings.TrimPrefix(s, configHashLabel+"=")
+				}
+			}
+		}
+	}
+	return hashes
+}
+
+// changedConfigServices splits services into those whose config hash differs
+// from the previous run (or that are new) and those left as they are.
+func changedConfigServices(prev, next map[string]string) (changed, unchanged []string) {
+	names := make([]string, 0, len(next))
+	for name := range next {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prev[name] == next[name] {
+			unchanged = append(unchanged, name)
+		} else {
+			changed = append(changed, name)
+		}
+	}
+	return changed, unchanged
+}
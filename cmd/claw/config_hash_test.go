@@ -0,0 +1,249 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/inspect"
+)
+
+const configHashCompose = `services:
+    bot:
+        environment:
+            LOG_LEVEL: info
+        image: bot:latest
+        labels:
+            claw.pod: ops
+    cllama:
+        image: cllama:latest
+    redis:
+        image: redis:7
+`
+
+func TestServiceConfigHashesTrackDefinitionAndInputs(t *testing.T) {
+	inputs := &runtimeInputs{Services: map[string]map[string]mountedInput{
+		"bot": {"/claw/AGENTS.md": {SHA256: "aaa"}},
+	}}
+	services := []string{"bot", "cllama", "missing"}
+	first, err := serviceConfigHashes([]byte(configHashCompose), inputs, services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first["bot"] == "" || first["cllama"] == "" {
+		t.Fatalf("unexpected hashes: %v", first)
+	}
+
+	again, _ := serviceConfigHashes([]byte(configHashCompose), inputs, services)
+	if !reflect.DeepEqual(first, again) {
+		t.Fatalf("expected stable hashes, got %v and %v", first, again)
+	}
+
+	inputs.Services["bot"]["/claw/AGENTS.md"] = mountedInput{SHA256: "bbb"}
+	contentChanged, _ := serviceConfigHashes([]byte(configHashCompose), inputs, services)
+	if contentChanged["bot"] == first["bot"] || contentChanged["cllama"] != first["cllama"] {
+		t.Fatalf("expected only bot to change on input change: %v -> %v", first, contentChanged)
+	}
+
+	envChanged, _ := serviceConfigHashes([]byte(strings.Replace(configHashCompose, "info", "debug", 1)), inputs, services)
+	if envChanged["bot"] == contentChanged["bot"] {
+		t.Fatal("expected env change to change the hash")
+	}
+}
+
+func TestStampConfigHashesRoundTrip(t *testing.T) {
+	hashes := map[string]string{"bot": "0123456789012345", "cllama": "abcdef0123456789"}
+	stamped, err := stampConfigHashes([]byte(configHashCompose), hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(stamped)
+	if !strings.Contains(out, "claw.config-hash: \"0123456789012345\"\n            claw.pod: ops") {
+		t.Fatalf("expected hash label in sorted position:\n%s", out)
+	}
+	if !strings.Contains(out, "    cllama:\n        image: cllama:latest\n        labels:\n            claw.config-hash: abcdef0123456789\n") {
+		t.Fatalf("expected labels added to cllama:\n%s", out)
+	}
+	if strings.Count(out, configHashLabel) != 2 {
+		t.Fatalf("expected redis to stay unlabeled:\n%s", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "compose.generated.yml")
+	if err := os.WriteFile(path, stamped, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := previousConfigHashes(path); !reflect.DeepEqual(got, hashes) {
+		t.Fatalf("previousConfigHashes = %v, want %v", got, hashes)
+	}
+	if got := previousConfigHashes(filepath.Join(t.TempDir(), "missing.yml")); len(got) != 0 {
+		t.Fatalf("expected no hashes for missing file, got %v", got)
+	}
+}
+
+func TestChangedConfigServices(t *testing.T) {
+	changed, unchanged := changedConfigServices(
+		map[string]string{"bot": "1", "cllama": "2", "gone": "3"},
+		map[string]string{"bot": "1", "cllama": "9", "new": "4"},
+	)
+	if !reflect.DeepEqual(changed, []string{"cllama", "new"}) || !reflect.DeepEqual(unchanged, []string{"bot"}) {
+		t.Fatalf("changed=%v unchanged=%v", changed, unchanged)
+	}
+}
+
+func TestResetRuntimeDirEmptiesLiveMountsInPlace(t *testing.T) {
+	podDir := t.TempDir()
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	configDir := filepath.Join(runtimeDir, "bot", "config")
+	contextDir := filepath.Join(runtimeDir, "context", "cllama")
+	standIn := filepath.Join(runtimeDir, "bot", "skills", "handle-discord.md")
+	for _, dir := range []string{configDir, filepath.Join(contextDir, "bot"), standIn} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePlanFile(t, filepath.Join(configDir, "openclaw.json"), "{}")
+	writePlanFile(t, filepath.Join(contextDir, "bot", "AGENTS.md"), "# bot")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	writePlanFile(t, composePath, `services:
+  bot:
+    volumes:
+      - `+configDir+`:/app/config
+      - `+standIn+`:/claw/skills/handle-discord.md:ro
+      - /etc/hosts:/etc/hosts:ro
+  cllama:
+    volumes:
+      - `+contextDir+`:/claw/context:ro
+`)
+	configBefore, err := os.Stat(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keep := liveRuntimeMounts(runtimeDir, composePath)
+	if len(keep) != 2 {
+		t.Fatalf("expected config and context dirs kept, got %v", keep)
+	}
+	if err := resetRuntimeDir(runtimeDir, keep); err != nil {
+		t.Fatal(err)
+	}
+
+	configAfter, err := os.Stat(configDir)
+	if err != nil {
+		t.Fatalf("expected kept dir to survive: %v", err)
+	}
+	if !os.SameFile(configBefore, configAfter) {
+		t.Fatal("expected kept dir to be emptied in place, not recreated")
+	}
+	for _, gone := range []string{filepath.Join(configDir, "openclaw.json"), filepath.Join(contextDir, "bot"), standIn} {
+		if _, err := os.Stat(gone); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, got err=%v", gone, err)
+		}
+	}
+}
+
+func TestSwapRuntimeDirMovesStagedContentIntoLiveMounts(t *testing.T) {
+	podDir := t.TempDir()
+	live := filepath.Join(podDir, ".claw-runtime")
+	staging := filepath.Join(podDir, runtimeStagingDirName)
+	configDir := filepath.Join(live, "bot", "config")
+	writePlanFile(t, filepath.Join(configDir, "openclaw.json"), `{"old":true}`)
+	writePlanFile(t, filepath.Join(live, "bot", "stale.md"), "stale")
+	writePlanFile(t, filepath.Join(live, "bot", trackRuntimeDirName, "mutations.jsonl"), "{}\n")
+	writePlanFile(t, filepath.Join(staging, "bot", "config", "openclaw.json"), `{"new":true}`)
+	writePlanFile(t, filepath.Join(staging, "pod-manifest.json"), "{}")
+	if err := os.MkdirAll(filepath.Join(staging, "bot", trackRuntimeDirName), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	configBefore, err := os.Stat(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := swapRuntimeDir(staging, live, map[string]struct{}{configDir: {}}); err != nil {
+		t.Fatal(err)
+	}
+
+	configAfter, err := os.Stat(configDir)
+	if err != nil || !os.SameFile(configBefore, configAfter) {
+		t.Fatalf("expected live mount to be refilled in place, err=%v", err)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(configDir, "openclaw.json"):                          `{"new":true}`,
+		filepath.Join(live, "pod-manifest.json"):                           "{}",
+		filepath.Join(live, "bot", trackRuntimeDirName, "mutations.jsonl"): "{}\n",
+	} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Fatalf("%s = %q (%v), want %q", path, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(live, "bot", "stale.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale file removed, got err=%v", err)
+	}
+}
+
+func TestComposeUpKeepsLiveRuntimeDirWhenValidationFails(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+	}()
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) { return &inspect.ClawInfo{}, nil }
+
+	podDir := t.TempDir()
+	podFile := filepath.Join(podDir, "claw-pod.yml")
+	writePlanFile(t, podFile, "x-claw:\n  pod: ops\nservices:\n  bot:\n    image: example/bot\n    x-claw:\n      agent: AGENTS.md\n")
+	livePath := filepath.Join(podDir, ".claw-runtime", "bot", "config", "openclaw.json")
+	writePlanFile(t, livePath, "{}")
+
+	err := runComposeUpWithOptions(podFile, composeUpOptions{})
+	if err == nil || !strings.Contains(err.Error(), "no claw.type label") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if data, err := os.ReadFile(livePath); err != nil || string(data) != "{}" {
+		t.Fatalf("expected live runtime dir untouched, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(podDir, runtimeStagingDirName)); !os.IsNotExist(err) {
+		t.Fatalf("expected staging dir removed, got err=%v", err)
+	}
+}
+
+func TestComposeUpWithoutDetachLeavesLiveStateAlone(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	prevDetach := composeUpDetach
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+		composeUpDetach = prevDetach
+	}()
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) { return &inspect.ClawInfo{ClawType: "generic"}, nil }
+	composeUpDetach = false
+
+	podDir := t.TempDir()
+	podFile := filepath.Join(podDir, "claw-pod.yml")
+	writePlanFile(t, podFile, "x-claw:\n  pod: ops\nservices:\n  bot:\n    image: example/bot\n    x-claw:\n      agent: AGENTS.md\n")
+	writePlanFile(t, filepath.Join(podDir, "AGENTS.md"), "# bot\n")
+	livePath := filepath.Join(podDir, ".claw-runtime", "bot", "stale.json")
+	writePlanFile(t, livePath, "{}")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	writePlanFile(t, composePath, "services: {}\n")
+
+	err := runComposeUpWithOptions(podFile, composeUpOptions{})
+	if err == nil || !strings.Contains(err.Error(), "detached mode") {
+		t.Fatalf("expected detached mode error, got %v", err)
+	}
+	if data, err := os.ReadFile(livePath); err != nil || string(data) != "{}" {
+		t.Fatalf("expected live runtime dir untouched, got %q (%v)", data, err)
+	}
+	if data, _ := os.ReadFile(composePath); string(data) != "services: {}\n" {
+		t.Fatalf("expected compose.generated.yml untouched, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(podDir, runtimeStagingDirName)); !os.IsNotExist(err) {
+		t.Fatalf("expected staging dir removed, got err=%v", err)
+	}
+}
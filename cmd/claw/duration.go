@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseSince parses a look-back window such as 7d, 12h or 30m, as taken by
+// claw recipe --since and claw audit --last. Days are accepted in addition to
+// time.ParseDuration units; "" means no window.
+func parseSince(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q (expected e.g. 7d, 24h)", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q (expected e.g. 7d, 24h)", value)
+	}
+	return d, nil
+}
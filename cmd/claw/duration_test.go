@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    0,
+		"7d":  7 * 24 * time.Hour,
+		"24h": 24 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for in, want := range cases {
+		got, err := parseSince(in)
+		if err != nil || got != want {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseSince("soon"); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
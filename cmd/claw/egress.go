@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mostlydev/clawdapus/internal/cllama"
+	"github.com/mostlydev/clawdapus/internal/driver"
+	"github.com/mostlydev/clawdapus/internal/egress"
+	"github.com/mostlydev/clawdapus/internal/pod"
+)
+
+// egressRuntimeDirName is the runtime subdirectory of the egress proxy.
+const egressRuntimeDirName = "egress"
+
+// egressLogDirName is the subdirectory of egressRuntimeDirName that holds the
+// proxy's denial audit log; it survives resetRuntimeDir like TRACK logs.
+const egressLogDirName = "logs"
+
+// buildEgressPolicy derives per-service allowlists from egress:// surfaces.
+// Enforcement is pod-wide and default-deny: once any claw declares an egress
+// surface, every claw is policed, and it returns nil when none do. Each
+// policed service is allowed its egress:// targets, its cllama proxies, the
+// platforms of its channels and handles, and, when it calls models directly,
+// the APIs of its model providers.
+func buildEgressPolicy(podName string, resolvedClaws map[string]*driver.ResolvedClaw) (*egress.Policy, error) {
+	enforced := false
+	for _, rc := range resolvedClaws {
+		if declaresEgress(rc) {
+			enforced = true
+		}
+	}
+	if !enforced {
+		return nil, nil
+	}
+	policy := egress.NewPolicy(podName)
+	for _, name := range sortedResolvedClawNames(resolvedClaws) {
+		rc := resolvedClaws[name]
+		allow := make([]string, 0)
+		for _, surface := range rc.Surfaces {
+			switch surface.Scheme {
+			case "egress":
+				allow = append(allow, surface.Target)
+			case "channel":
+				allow = append(allow, egress.PlatformDomains(surface.Target)...)
+			}
+		}
+		for platform := range rc.Handles {
+			allow = append(allow, egress.PlatformDomains(platform)...)
+		}
+		for _, proxyType := range rc.Cllama {
+			allow = append(allow, cllama.ProxyServiceName(proxyType))
+		}
+		if len(rc.Cllama) == 0 {
+			for _, model := range rc.Models {
+				if provider, _, ok := strings.Cut(model, "/"); ok {
+					allow = append(allow, egress.ProviderDomains(provider)...)
+				}
+			}
+		}
+		for _, generated := range expandedServiceNames(name, rc.Count) {
+			if err := policy.AddService(generated, allow); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return policy, nil
+}
+
+func declaresEgress(rc *driver.ResolvedClaw) bool {
+	for _, surface := range rc.Surfaces {
+		if surface.Scheme == "egress" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEgressRuntime writes the policy under <runtimeDir>/egress and returns
+// the compose config for the proxy sidecar.
+func writeEgressRuntime(runtimeDir string, policy *egress.Policy) (*pod.EgressConfig, error) {
+	dir := filepath.Join(runtimeDir, egressRuntimeDirName)
+	logDir := filepath.Join(dir, egressLogDirName)
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create egress log dir: %w", err)
+	}
+	policyPath := filepath.Join(dir, "egress-policy.json")
+	if err := policy.Write(policyPath); err != nil {
+		return nil, err
+	}
+
+	proxyURLs := make(map[string]string, len(policy.Services))
+	for service := range policy.Services {
+		proxyURLs[service] = policy.ProxyURL(service)
+	}
+	return &pod.EgressConfig{
+		Image:          egress.ImageRef,
+		PolicyHostPath: policyPath,
+		LogHostDir:     logDir,
+		ProxyURLs:      proxyURLs,
+		PodName:        policy.Pod,
+	}, nil
+}
+
+// previousEgressPolicy returns the policy written by the last run, or nil.
+func previousEgressPolicy(runtimeDir string) *egress.Policy {
+	policy, err := egress.LoadPolicy(filepath.Join(runtimeDir, egressRuntimeDirName, "egress-policy.json"))
+	if err != nil {
+		return nil
+	}
+	return policy
+}
+
+func egressServiceSummary(policy *egress.Policy) string {
+	parts := make([]string, 0, len(policy.Services))
+	for _, name := range sortedPolicyServices(policy) {
+		parts = append(parts, fmt.Sprintf("%s (%d)", name, len(policy.Services[name].Allow)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedPolicyServices(policy *egress.Policy) []string {
+	names := make([]string, 0, len(policy.Services))
+	for name := range policy.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
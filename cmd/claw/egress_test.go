@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+)
+
+func TestBuildEgressPolicyDisabledWithoutEgressSurfaces(t *testing.T) {
+	policy, err := buildEgressPolicy("ops", map[string]*driver.ResolvedClaw{
+		"bot": {Surfaces: []driver.ResolvedSurface{{Scheme: "channel", Target: "discord"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy != nil {
+		t.Fatalf("expected no policy, got %+v", policy)
+	}
+}
+
+func TestBuildEgressPolicyAddsImplicitAllowances(t *testing.T) {
+	policy, err := buildEgressPolicy("ops", map[string]*driver.ResolvedClaw{
+		"bot": {
+			Count:  2,
+			Cllama: []string{"passthrough"},
+			Surfaces: []driver.ResolvedSurface{
+				{Scheme: "egress", Target: "*.example.com"},
+				{Scheme: "channel", Target: "discord"},
+			},
+			Handles: map[string]*driver.HandleInfo{"telegram": {ID: "1"}},
+		},
+		"quiet": {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy == nil {
+		t.Fatal("expected policy")
+	}
+	for _, name := range []string{"bot-0", "bot-1"} {
+		sp := policy.Services[name]
+		if sp == nil {
+			t.Fatalf("expected policy entry for %s", name)
+		}
+		for _, rule := range []string{"*.example.com", "cllama", "discord.com", "api.telegram.org"} {
+			if !slices.Contains(sp.Allow, rule) {
+				t.Fatalf("%s: expected %q in %v", name, rule, sp.Allow)
+			}
+		}
+	}
+	// Enforcement is default-deny: a claw without egress surfaces is policed
+	// with nothing allowed.
+	if sp := policy.Services["quiet"]; sp == nil || len(sp.Allow) != 0 {
+		t.Fatalf("expected quiet to be policed with an empty allowlist, got %+v", sp)
+	}
+}
+
+func TestBuildEgressPolicyAllowsDirectModelProviders(t *testing.T) {
+	policy, err := buildEgressPolicy("ops", map[string]*driver.ResolvedClaw{
+		"direct": {
+			Models:   map[string]string{"primary": "anthropic/claude-sonnet-4", "fallback": "openrouter/openai/gpt-4o"},
+			Surfaces: []driver.ResolvedSurface{{Scheme: "egress", Target: "api.example.com"}},
+		},
+		"proxied": {
+			Cllama:   []string{"passthrough"},
+			Models:   map[string]string{"primary": "anthropic/claude-sonnet-4"},
+			Surfaces: []driver.ResolvedSurface{{Scheme: "egress", Target: "api.example.com"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range []string{"api.example.com", "api.anthropic.com", "openrouter.ai"} {
+		if !slices.Contains(policy.Services["direct"].Allow, rule) {
+			t.Fatalf("direct: expected %q in %v", rule, policy.Services["direct"].Allow)
+		}
+	}
+	if slices.Contains(policy.Services["proxied"].Allow, "api.anthropic.com") {
+		t.Fatalf("proxied: provider API should only be reachable through cllama, got %v", policy.Services["proxied"].Allow)
+	}
+}
+
+func TestBuildEgressPolicyRejectsPathTargets(t *testing.T) {
+	_, err := buildEgressPolicy("ops", map[string]*driver.ResolvedClaw{
+		"bot": {Surfaces: []driver.ResolvedSurface{{Scheme: "egress", Target: "api.example.com/v1"}}},
+	})
+	if err == nil {
+		t.Fatal("expected error for egress target with a path")
+	}
+}
+
+func TestResetRuntimeDirPreservesEgressLogs(t *testing.T) {
+	runtimeDir := t.TempDir()
+	logPath := filepath.Join(runtimeDir, "egress", egressLogDirName, "egress-denied.jsonl")
+	policyPath := filepath.Join(runtimeDir, "egress", "egress-policy.json")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{logPath, policyPath} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := resetRuntimeDir(runtimeDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(logPath); err != nil {
+		t.Fatalf("expected egress log to survive reset: %v", err)
+	}
+	if _, err := os.Stat(policyPath); !os.IsNotExist(err) {
+		t.Fatalf("expected egress policy to be removed, got %v", err)
+	}
+}
+
+func TestResetRuntimeDirClearsServiceLogsDir(t *testing.T) {
+	runtimeDir := t.TempDir()
+	logPath := filepath.Join(runtimeDir, "bot", egressLogDirName, "stale.log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := resetRuntimeDir(runtimeDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(logPath)); !os.IsNotExist(err) {
+		t.Fatalf("expected a service's logs dir to be cleared, got %v", err)
+	}
+	if !isGeneratedRuntimePath(runtimeDir, logPath) {
+		t.Fatal("expected a service's logs dir to count as generated state")
+	}
+	if isGeneratedRuntimePath(runtimeDir, filepath.Join(runtimeDir, egressRuntimeDirName, egressLogDirName, "egress-denied.jsonl")) {
+		t.Fatal("expected the egress denial log to be preserved")
+	}
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// generationsDirName holds one numbered snapshot per claw up, next to the pod
+// file: .claw-generations/<n>/{generation.json,compose.generated.yml,runtime/,inputs/}.
+// runtime/ is the materialized .claw-runtime, pod-manifest.json included,
+// without the preserved TRACK and egress logs. inputs/ holds copies of the
+// read-only mounts from outside it, such as AGENTS.md, which the snapshot's
+// compose file mounts instead of the originals.
+const generationsDirName = ".claw-generations"
+
+// defaultGenerationRetention applies when x-claw.generations is unset.
+const defaultGenerationRetention = 10
+
+const (
+	generationMetaFile    = "generation.json"
+	currentGenerationFile = "current"
+)
+
+// generationNow is the generation timestamp source; tests replace it.
+var generationNow = time.Now
+
+// imageID resolves an image reference to the local image ID; tests replace it.
+var imageID = func(ref string) (string, error) {
+	out, err := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", ref).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker image inspect %q: %w", ref, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+type generation struct {
+	Number  int    `json:"generation"`
+	Created string `json:"created"`
+	Pod     string `json:"pod"`
+	// Images maps each compose service to the ID of the image it ran, so a
+	// rollback can pin it when the tag has moved on.
+	Images map[string]string `json:"images,omitempty"`
+}
+
+func generationsDir(podDir string) string {
+	return filepath.Join(podDir, generationsDirName)
+}
+
+func generationDir(podDir string, n int) string {
+	return filepath.Join(generationsDir(podDir), strconv.Itoa(n))
+}
+
+// recordGeneration snapshots a claw up run as the next generation, makes it
+// current and prunes the oldest generations beyond keep. claw up calls it only
+// once the pod is up and verified, so rollback never lands on a failed apply
+// and the recorded image IDs are the ones compose ran.
+func recordGeneration(podDir, runtimeDir, composePath, podName string, keep int) (int, error) {
+	if err := ensureGenerationsDir(podDir); err != nil {
+		return 0, err
+	}
+	gens, err := listGenerations(podDir)
+	if err != nil {
+		return 0, err
+	}
+	next := 1
+	if len(gens) > 0 {
+		next = gens[len(gens)-1].Number + 1
+	}
+
+	dir := generationDir(podDir, next)
+	staging := dir + ".tmp"
+	if err := os.RemoveAll(staging); err != nil {
+		return 0, fmt.Errorf("generation %d: %w", next, err)
+	}
+	if err := copyTree(runtimeDir, filepath.Join(staging, "runtime"), func(p string) bool {
+		return p != runtimeDir && !isGeneratedRuntimePath(runtimeDir, p)
+	}); err != nil {
+		return 0, fmt.Errorf("generation %d: copy runtime dir: %w", next, err)
+	}
+	composeYAML, err := os.ReadFile(composePath)
+	if err != nil {
+		return 0, fmt.Errorf("generation %d: read compose file: %w", next, err)
+	}
+	composeYAML, err = snapshotMountSources(composeYAML, runtimeDir, staging, dir)
+	if err != nil {
+		return 0, fmt.Errorf("generation %d: %w", next, err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, "compose.generated.yml"), composeYAML, 0o644); err != nil {
+		return 0, fmt.Errorf("generation %d: write compose file: %w", next, err)
+	}
+	images, err := generationImages(composePath)
+	if err != nil {
+		return 0, fmt.Errorf("generation %d: %w", next, err)
+	}
+	meta := generation{
+		Number:  next,
+		Created: generationNow().UTC().Format(time.RFC3339),
+		Pod:     podName,
+		Images:  images,
+	}
+	if err := writeGenerationMeta(staging, meta); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return 0, fmt.Errorf("generation %d: %w", next, err)
+	}
+	if err := setCurrentGeneration(podDir, next); err != nil {
+		return 0, err
+	}
+	if err := pruneGenerations(podDir, keep, next); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// ensureGenerationsDir creates the generations dir readable only by its
+// owner and ignored by git even without claw init: snapshots hold proxy
+// tokens, provider credentials and egress proxy secrets.
+func ensureGenerationsDir(podDir string) error {
+	dir := generationsDir(podDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create generations dir: %w", err)
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return fmt.Errorf("chmod generations dir: %w", err)
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte("*\n"), 0o644); err != nil {
+			return fmt.Errorf("write generations .gitignore: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeGenerationMeta(dir string, meta generation) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("generation %d: encode metadata: %w", meta.Number, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, generationMetaFile), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("generation %d: write metadata: %w", meta.Number, err)
+	}
+	return nil
+}
+
+func readGenerationMeta(podDir string, n int) (generation, error) {
+	g := generation{Number: n}
+	data, err := os.ReadFile(filepath.Join(generationDir(podDir, n), generationMetaFile))
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return g, fmt.Errorf("generation %d: read metadata: %w", n, err)
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return g, fmt.Errorf("generation %d: parse metadata: %w", n, err)
+	}
+	g.Number = n
+	return g, nil
+}
+
+// snapshotMountSources copies the read-only bind-mount sources of a compose
+// file that live outside runtimeDir, the pod-owned inputs recorded in
+// inputs.json, into staging/inputs and returns the compose file with those
+// mounts pointed at dir/inputs, where staging ends up.
+func snapshotMountSources(composeYAML []byte, runtimeDir, staging, dir string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(composeYAML, &doc); err != nil {
+		return nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return composeYAML, nil
+	}
+	services := mappingValue(doc.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return composeYAML, nil
+	}
+	snapshots := make(map[string]string)
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		volumes := mappingValue(services.Content[i+1], "volumes")
+		if volumes == nil || volumes.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range volumes.Content {
+			var raw interface{}
+			if err := item.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("service %q: decode volume: %w", services.Content[i].Value, err)
+			}
+			source, _, readOnly := parseBindMount(raw)
+			if !readOnly || !filepath.IsAbs(source) || isWithinDir(runtimeDir, source) {
+				continue
+			}
+			// Sockets, devices and pipes, such as clawdash's docker.sock,
+			// stay mounted in place.
+			info, err := os.Stat(source)
+			if err != nil || (!info.Mode().IsRegular() && !info.IsDir()) {
+				continue
+			}
+			snapshot, ok := snapshots[source]
+			if !ok {
+				rel := filepath.Join("inputs", strconv.Itoa(len(snapshots)+1), filepath.Base(source))
+				if info.IsDir() {
+					err = copyTree(source, filepath.Join(staging, rel), nil)
+				} else {
+					err = copyFile(source, filepath.Join(staging, rel))
+				}
+				if err != nil {
+					return nil, fmt.Errorf("snapshot mount %q: %w", source, err)
+				}
+				snapshot = filepath.Join(dir, rel)
+				snapshots[source] = snapshot
+			}
+			if item.Kind == yaml.ScalarNode {
+				item.Value = snapshot + strings.TrimPrefix(item.Value, source)
+			} else if node := mappingValue(item, "source"); node != nil {
+				node.Value = snapshot
+			}
+		}
+	}
+	if len(snapshots) == 0 {
+		return composeYAML, nil
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("rewrite compose mounts: %w", err)
+	}
+	return out, nil
+}
+
+func isWithinDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// generationImages returns the ID of every image the compose file runs.
+// Services whose image is not available locally are left out.
+func generationImages(composePath string) (map[string]string, error) {
+	refs, err := composeServiceImages(composePath)
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string]string, len(refs))
+	for service, ref := range refs {
+		if id, err := imageID(ref); err == nil && id != "" {
+			images[service] = id
+		}
+	}
+	return images, nil
+}
+
+func composeServiceImages(composePath string) (map[string]string, error) {
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, fmt.Errorf("read compose file: %w", err)
+	}
+	var cf struct {
+		Services map[string]struct {
+			Image string `yaml:"image"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	refs := make(map[string]string, len(cf.Services))
+	for name, svc := range cf.Services {
+		if svc.Image != "" {
+			refs[name] = svc.Image
+		}
+	}
+	return refs, nil
+}
+
+// pinGenerationImages points each service of a restored compose file whose
+// tag no longer resolves to the image recorded in generation n back at that
+// image, so a rollback runs what the generation ran. It returns a warning for
+// each recorded image that is gone.
+func pinGenerationImages(podDir string, n int, composePath string) ([]string, error) {
+	meta, err := readGenerationMeta(podDir, n)
+	if err != nil || len(meta.Images) == 0 {
+		return nil, err
+	}
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, fmt.Errorf("read compose file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	services := mappingValue(doc.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var warnings []string
+	pinned := false
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		image := mappingValue(services.Content[i+1], "image")
+		recorded := meta.Images[name]
+		if image == nil || recorded == "" {
+			continue
+		}
+		if current, err := imageID(image.Value); err == nil && current == recorded {
+			continue
+		}
+		if _, err := imageID(recorded); err != nil {
+			warnings = append(warnings, fmt.Sprintf("service %q: image %s recorded for %s is no longer available; using the tag as it is now", name, recorded, image.Value))
+			continue
+		}
+		fmt.Printf("[claw] %s: %s has moved on; pinning %s\n", name, image.Value, recorded)
+		image.Value = recorded
+		pinned = true
+	}
+	if !pinned {
+		return warnings, nil
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("pin generation images: %w", err)
+	}
+	if err := os.WriteFile(composePath, out, 0o644); err != nil {
+		return nil, fmt.Errorf("write compose file: %w", err)
+	}
+	return warnings, nil
+}
+
+// generationInputDrift compares the inputs a restored generation mounts with
+// the digests it recorded in inputs.json. Mounts snapshotted into the
+// generation never drift; older generations mount pod-owned files in place,
+// and each one edited since is reported.
+func generationInputDrift(podDir string, n int, composePath string) ([]string, error) {
+	recorded, err := loadRuntimeInputs(filepath.Join(generationDir(podDir, n), "runtime"))
+	if err != nil || recorded == nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, fmt.Errorf("read compose file: %w", err)
+	}
+	current, err := collectRuntimeInputs(data, filepath.Join(podDir, ".claw-runtime"))
+	if err != nil {
+		return nil, err
+	}
+	var drift []string
+	for service, files := range recorded.Services {
+		for target, was := range files {
+			now, ok := current.Services[service][target]
+			switch {
+			case !ok:
+				drift = append(drift, fmt.Sprintf("service %q: %s (%s) no longer exists", service, target, was.Source))
+			case now.SHA256 != was.SHA256:
+				drift = append(drift, fmt.Sprintf("service %q: %s (%s) changed since the generation was recorded", service, target, now.Source))
+			}
+		}
+	}
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// listGenerations returns the recorded generations, oldest first.
+func listGenerations(podDir string) ([]generation, error) {
+	entries, err := os.ReadDir(generationsDir(podDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read generations: %w", err)
+	}
+	gens := make([]generation, 0, len(entries))
+	for _, entry := range entries {
+		n, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		g, err := readGenerationMeta(podDir, n)
+		if err != nil {
+			return nil, err
+		}
+		gens = append(gens, g)
+	}
+	sort.Slice(gens, func(i, j int) bool { return gens[i].Number < gens[j].Number })
+	return gens, nil
+}
+
+// currentGeneration returns the generation last applied by claw up or claw
+// rollback, or 0 when none was recorded.
+func currentGeneration(podDir string) int {
+	data, err := os.ReadFile(filepath.Join(generationsDir(podDir), currentGenerationFile))
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func setCurrentGeneration(podDir string, n int) error {
+	path := filepath.Join(generationsDir(podDir), currentGenerationFile)
+	if err := os.WriteFile(path, []byte(strconv.Itoa(n)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write current generation: %w", err)
+	}
+	return nil
+}
+
+// pruneGenerations deletes the oldest generations until keep remain. The
+// current generation is never deleted.
+func pruneGenerations(podDir string, keep, current int) error {
+	if keep < 1 {
+		keep = defaultGenerationRetention
+	}
+	gens, err := listGenerations(podDir)
+	if err != nil {
+		return err
+	}
+	excess := len(gens) - keep
+	for _, g := range gens {
+		if excess <= 0 {
+			break
+		}
+		if g.Number == current {
+			continue
+		}
+		if err := os.RemoveAll(generationDir(podDir, g.Number)); err != nil {
+			return fmt.Errorf("prune generation %d: %w", g.Number, err)
+		}
+		excess--
+	}
+	return nil
+}
+
+// restoreGeneration puts generation n back in place: .claw-runtime is reset as
+// claw up would reset it and refilled from the snapshot, and the snapshot's
+// compose file replaces compose.generated.yml. It returns the compose path.
+func restoreGeneration(podDir string, n int) (string, error) {
+	dir := generationDir(podDir, n)
+	if _, err := os.Stat(filepath.Join(dir, "compose.generated.yml")); err != nil {
+		return "", fmt.Errorf("generation %d not found in %s", n, generationsDir(podDir))
+	}
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	if err := resetRuntimeDir(runtimeDir, liveRuntimeMounts(runtimeDir, composePath)); err != nil {
+		return "", fmt.Errorf("reset runtime dir: %w", err)
+	}
+	if err := copyTree(filepath.Join(dir, "runtime"), runtimeDir, nil); err != nil {
+		return "", fmt.Errorf("generation %d: restore runtime dir: %w", n, err)
+	}
+	if err := copyFile(filepath.Join(dir, "compose.generated.yml"), composePath); err != nil {
+		return "", fmt.Errorf("generation %d: restore compose file: %w", n, err)
+	}
+	return composePath, nil
+}
+
+// copyTree copies src into dst, keeping file modes and symlinks. skip, when
+// set, excludes paths under src (and everything below excluded directories).
+func copyTree(src, dst string, skip func(string) bool) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == src {
+				return nil
+			}
+			return err
+		}
+		if skip != nil && skip(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFileMode(p, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return copyFileMode(src, dst, info.Mode().Perm())
+}
+
+func copyFileMode(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
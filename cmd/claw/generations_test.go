@@ -0,0 +1,268 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/clawdash"
+)
+
+func TestRecordGenerationSnapshotsAndPrunes(t *testing.T) {
+	orig := generationNow
+	generationNow = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { generationNow = orig }()
+
+	podDir := t.TempDir()
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	writePlanFile(t, filepath.Join(runtimeDir, "bot", "config.json"), "{}")
+	writePlanFile(t, filepath.Join(runtimeDir, "bot", "track", "mutations.jsonl"), "{}\n")
+	writePlanFile(t, composePath, "services: {}\n")
+
+	for i := 1; i <= 3; i++ {
+		n, err := recordGeneration(podDir, runtimeDir, composePath, "ops", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != i {
+			t.Fatalf("expected generation %d, got %d", i, n)
+		}
+	}
+
+	gens, err := listGenerations(podDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 2 || gens[0].Number != 2 || gens[1].Number != 3 {
+		t.Fatalf("expected generations 2 and 3 kept, got %+v", gens)
+	}
+	if gens[1].Pod != "ops" || gens[1].Created != "2026-01-02T03:04:05Z" {
+		t.Fatalf("unexpected metadata: %+v", gens[1])
+	}
+	if currentGeneration(podDir) != 3 {
+		t.Fatalf("expected current generation 3, got %d", currentGeneration(podDir))
+	}
+
+	if info, err := os.Stat(generationsDir(podDir)); err != nil || info.Mode().Perm() != 0o700 {
+		t.Fatalf("expected generations dir 0700, got %v (%v)", info, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(generationsDir(podDir), ".gitignore")); string(data) != "*\n" {
+		t.Fatalf("expected generations dir to ignore itself, got %q", data)
+	}
+
+	snapshot := filepath.Join(generationDir(podDir, 3), "runtime")
+	if _, err := os.Stat(filepath.Join(snapshot, "bot", "config.json")); err != nil {
+		t.Fatalf("expected runtime config in snapshot: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(snapshot, "bot", "track")); !os.IsNotExist(err) {
+		t.Fatalf("expected TRACK logs left out of snapshot, got err=%v", err)
+	}
+}
+
+func TestPruneGenerationsKeepsCurrent(t *testing.T) {
+	podDir := t.TempDir()
+	for _, n := range []int{1, 2, 3} {
+		if err := os.MkdirAll(generationDir(podDir, n), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pruneGenerations(podDir, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	gens, _ := listGenerations(podDir)
+	if len(gens) != 2 || gens[0].Number != 1 || gens[1].Number != 3 {
+		t.Fatalf("expected current generation 1 to survive pruning, got %+v", gens)
+	}
+}
+
+func TestRestoreGenerationAndPreviousGeneration(t *testing.T) {
+	podDir := t.TempDir()
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	cfg := filepath.Join(runtimeDir, "bot", "config.json")
+
+	writePlanFile(t, cfg, `{"model":"a"}`)
+	writePlanFile(t, composePath, "services: {a: {}}\n")
+	if _, err := recordGeneration(podDir, runtimeDir, composePath, "ops", 0); err != nil {
+		t.Fatal(err)
+	}
+	writePlanFile(t, cfg, `{"model":"b"}`)
+	writePlanFile(t, filepath.Join(runtimeDir, "bot", "extra.json"), "{}")
+	writePlanFile(t, composePath, "services: {b: {}}\n")
+	if _, err := recordGeneration(podDir, runtimeDir, composePath, "ops", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, err := previousGeneration(podDir, currentGeneration(podDir))
+	if err != nil || prev != 1 {
+		t.Fatalf("expected previous generation 1, got %d, %v", prev, err)
+	}
+	if _, err := previousGeneration(podDir, 1); err == nil {
+		t.Fatal("expected no generation before 1")
+	}
+
+	got, err := restoreGeneration(podDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != composePath {
+		t.Fatalf("unexpected compose path %q", got)
+	}
+	if data, _ := os.ReadFile(cfg); string(data) != `{"model":"a"}` {
+		t.Fatalf("expected generation 1 config restored, got %q", data)
+	}
+	if data, _ := os.ReadFile(composePath); !strings.Contains(string(data), "a: {}") {
+		t.Fatalf("expected generation 1 compose restored, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(runtimeDir, "bot", "extra.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected files from later generations removed, got err=%v", err)
+	}
+	if _, err := restoreGeneration(podDir, 9); err == nil {
+		t.Fatal("expected missing generation to fail")
+	}
+}
+
+func TestManifestDriversSkipsNonClawServices(t *testing.T) {
+	manifest := &clawdash.PodManifest{Services: map[string]clawdash.ServiceManifest{
+		"bot":   {ClawType: "openclaw", Count: 2},
+		"redis": {ImageRef: "redis:7"},
+	}}
+	drivers, resolved, err := manifestDrivers(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drivers) != 1 || drivers["bot"] == nil || resolved["bot"].Count != 2 || resolved["bot"].ServiceName != "bot" {
+		t.Fatalf("unexpected drivers=%v resolved=%+v", drivers, resolved)
+	}
+
+	manifest.Services["bad"] = clawdash.ServiceManifest{ClawType: "nope"}
+	if _, _, err := manifestDrivers(manifest); err == nil {
+		t.Fatal("expected unknown claw type to fail")
+	}
+}
+
+func TestGenerationSnapshotsPodOwnedInputs(t *testing.T) {
+	podDir := t.TempDir()
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	agents := filepath.Join(podDir, "AGENTS.md")
+	config := filepath.Join(runtimeDir, "bot", "config.json")
+	writePlanFile(t, agents, "# v1\n")
+	writePlanFile(t, config, "{}")
+	compose := "services:\n  bot:\n    volumes:\n      - " + agents + ":/claw/AGENTS.md:ro\n      - " + config + ":/claw/config.json:ro\n"
+	writePlanFile(t, composePath, compose)
+	inputs, err := collectRuntimeInputs([]byte(compose), runtimeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRuntimeInputs(runtimeDir, inputs); err != nil {
+		t.Fatal(err)
+	}
+	n, err := recordGeneration(podDir, runtimeDir, composePath, "ops", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePlanFile(t, agents, "# v2\n")
+	restored, err := restoreGeneration(podDir, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(restored)
+	snapshot := filepath.Join(generationDir(podDir, n), "inputs", "1", "AGENTS.md")
+	if !strings.Contains(string(data), snapshot+":/claw/AGENTS.md:ro") || !strings.Contains(string(data), config+":/claw/config.json:ro") {
+		t.Fatalf("expected only the pod-owned mount pointed at the snapshot:\n%s", data)
+	}
+	if got, _ := os.ReadFile(snapshot); string(got) != "# v1\n" {
+		t.Fatalf("expected snapshot of the recorded content, got %q", got)
+	}
+	drift, err := generationInputDrift(podDir, n, restored)
+	if err != nil || len(drift) != 0 {
+		t.Fatalf("expected no drift from a snapshotted generation, got %v (%v)", drift, err)
+	}
+
+	// Generations recorded before inputs were snapshotted mount the pod file in place.
+	writePlanFile(t, filepath.Join(generationDir(podDir, n), "compose.generated.yml"), compose)
+	if restored, err = restoreGeneration(podDir, n); err != nil {
+		t.Fatal(err)
+	}
+	drift, err = generationInputDrift(podDir, n, restored)
+	if err != nil || len(drift) != 1 || !strings.Contains(drift[0], "/claw/AGENTS.md") {
+		t.Fatalf("expected AGENTS.md drift reported, got %v (%v)", drift, err)
+	}
+}
+
+func TestGenerationImagesPinMovedTags(t *testing.T) {
+	ids := map[string]string{"bot:latest": "sha256:aaa", "redis:7": "sha256:rrr", "sha256:aaa": "sha256:aaa"}
+	orig := imageID
+	imageID = func(ref string) (string, error) {
+		if id, ok := ids[ref]; ok {
+			return id, nil
+		}
+		return "", os.ErrNotExist
+	}
+	defer func() { imageID = orig }()
+
+	podDir := t.TempDir()
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	writePlanFile(t, filepath.Join(runtimeDir, "pod-manifest.json"), "{}")
+	writePlanFile(t, composePath, "services:\n  bot:\n    image: bot:latest\n  cache:\n    image: redis:7\n")
+	n, err := recordGeneration(podDir, runtimeDir, composePath, "ops", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := readGenerationMeta(podDir, n)
+	if err != nil || meta.Images["bot"] != "sha256:aaa" || meta.Images["cache"] != "sha256:rrr" {
+		t.Fatalf("expected image IDs recorded, got %+v (%v)", meta, err)
+	}
+
+	ids["bot:latest"] = "sha256:bbb"
+	delete(ids, "redis:7")
+	delete(ids, "sha256:rrr")
+	restored, err := restoreGeneration(podDir, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings, err := pinGenerationImages(podDir, n, restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(restored)
+	if !strings.Contains(string(data), "image: sha256:aaa") || !strings.Contains(string(data), "image: redis:7") {
+		t.Fatalf("expected bot pinned to its recorded image:\n%s", data)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"cache"`) {
+		t.Fatalf("expected a warning for the missing cache image, got %v", warnings)
+	}
+}
+
+func TestGenerationLeavesSocketMountsInPlace(t *testing.T) {
+	podDir := t.TempDir()
+	runtimeDir := filepath.Join(podDir, ".claw-runtime")
+	composePath := filepath.Join(podDir, "compose.generated.yml")
+	sock := filepath.Join(podDir, "d.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer l.Close()
+	writePlanFile(t, filepath.Join(runtimeDir, "pod-manifest.json"), "{}")
+	writePlanFile(t, composePath, "services:\n  clawdash:\n    volumes:\n      - "+sock+":/var/run/docker.sock:ro\n")
+
+	n, err := recordGeneration(podDir, runtimeDir, composePath, "ops", 0)
+	if err != nil {
+		t.Fatalf("expected a socket mount not to break the generation: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(generationDir(podDir, n), "compose.generated.yml"))
+	if !strings.Contains(string(data), sock+":/var/run/docker.sock:ro") {
+		t.Fatalf("expected socket mount left in place:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(generationDir(podDir, n), "inputs")); !os.IsNotExist(err) {
+		t.Fatalf("expected no snapshot for a socket, got err=%v", err)
+	}
+}
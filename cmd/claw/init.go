@@ -366,7 +366,7 @@ func runInitScaffold(dir string, opts initScaffoldOptions, interactive bool) err
 	gitignorePath := filepath.Join(dir, ".gitignore")
 	_, gitignoreExistedErr := os.Stat(gitignorePath)
 	gitignoreExisted := gitignoreExistedErr == nil
-	added, err := appendMissingGitignoreEntries(gitignorePath, []string{".env", "*.generated.*"})
+	added, err := appendMissingGitignoreEntries(gitignorePath, []string{".env", "*.generated.*", ".claw-tokens.json", ".claw-generations/", runtimeStagingDirName + "/"})
 	if err != nil {
 		return err
 	}
@@ -112,7 +112,7 @@ func TestInitScaffoldAppendsGitignoreEntries(t *testing.T) {
 		t.Fatalf("read .gitignore: %v", err)
 	}
 	gitignore := string(gitignoreData)
-	for _, expected := range []string{"node_modules/", ".env", "*.generated.*"} {
+	for _, expected := range []string{"node_modules/", ".env", "*.generated.*", ".claw-tokens.json", ".claw-generations/", runtimeStagingDirName + "/"} {
 		if !strings.Contains(gitignore, expected) {
 			t.Errorf("expected .gitignore to contain %q, got:\n%s", expected, gitignore)
 		}
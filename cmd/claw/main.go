@@ -1,9 +1,18 @@
 package main
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"os"
+)
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
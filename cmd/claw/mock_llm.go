@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mostlydev/clawdapus/internal/mockllm"
+	"github.com/mostlydev/clawdapus/internal/pod"
+)
+
+// configureMockLLM enables the pod mock LLM when requested by --mock-llm or
+// x-claw.mock-llm, resolving and validating its reply script. It reports
+// whether the mock is on.
+func configureMockLLM(podDir string, p *pod.Pod, requested bool) (bool, error) {
+	if requested && p.MockLLM == nil {
+		p.MockLLM = &pod.MockLLMConfig{}
+	}
+	if p.MockLLM == nil {
+		return false, nil
+	}
+
+	if p.MockLLM.Script != "" {
+		scriptPath := p.MockLLM.Script
+		if !filepath.IsAbs(scriptPath) {
+			scriptPath = filepath.Join(podDir, scriptPath)
+		}
+		if _, err := mockllm.LoadScript(scriptPath); err != nil {
+			return false, fmt.Errorf("x-claw.mock-llm: %w", err)
+		}
+		p.MockLLM.ScriptHostPath = scriptPath
+	}
+	p.MockLLM.Image = mockllm.ImageRef
+	p.MockLLM.PodName = p.Name
+	return true, nil
+}
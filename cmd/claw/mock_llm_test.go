@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/mockllm"
+	"github.com/mostlydev/clawdapus/internal/pod"
+)
+
+func TestConfigureMockLLMFromFlag(t *testing.T) {
+	p := &pod.Pod{Name: "ci"}
+	enabled, err := configureMockLLM(t.TempDir(), p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled || p.MockLLM == nil {
+		t.Fatal("expected --mock-llm to enable the mock")
+	}
+	if p.MockLLM.Image != mockllm.ImageRef || p.MockLLM.PodName != "ci" || p.MockLLM.ScriptHostPath != "" {
+		t.Fatalf("unexpected config: %+v", p.MockLLM)
+	}
+}
+
+func TestConfigureMockLLMOffByDefault(t *testing.T) {
+	p := &pod.Pod{Name: "ci"}
+	enabled, err := configureMockLLM(t.TempDir(), p, false)
+	if err != nil || enabled || p.MockLLM != nil {
+		t.Fatalf("expected mock off, got enabled=%v cfg=%+v err=%v", enabled, p.MockLLM, err)
+	}
+}
+
+func TestConfigureMockLLMResolvesScript(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "replies.json"), []byte(`{"default":"ok"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := &pod.Pod{Name: "ci", MockLLM: &pod.MockLLMConfig{Script: "./replies.json"}}
+	if _, err := configureMockLLM(dir, p, false); err != nil {
+		t.Fatal(err)
+	}
+	if p.MockLLM.ScriptHostPath != filepath.Join(dir, "replies.json") {
+		t.Fatalf("unexpected script host path %q", p.MockLLM.ScriptHostPath)
+	}
+
+	p.MockLLM = &pod.MockLLMConfig{Script: "./missing.json"}
+	if _, err := configureMockLLM(dir, p, true); err == nil || !strings.Contains(err.Error(), "x-claw.mock-llm") {
+		t.Fatalf("expected missing script error, got %v", err)
+	}
+}
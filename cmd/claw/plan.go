@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mostlydev/clawdapus/internal/secretscan"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [pod-file]",
+	Short: "Show what 'claw up' would change without touching Docker",
+	Long: `Run the full claw up resolve/validate/materialize pipeline into a scratch
+directory and compare the result with the current compose.generated.yml and
+.claw-runtime. Images are inspected but never built or pulled, no discovery
+containers are started (skills emitted by images or discovered over MCP and
+OpenAPI are reused from .claw-runtime), and docker compose is not called.
+Exits 2 when anything would change and 1 on errors.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podFile := composePodFile
+		if podFile == "" && len(args) > 0 {
+			podFile = args[0]
+		}
+		if podFile == "" {
+			podFile = "claw-pod.yml"
+		}
+		return runComposePlan(podFile)
+	},
+}
+
+// planChangesExitCode is the exit status when the plan has changes; errors
+// exit 1.
+const planChangesExitCode = 2
+
+// planMaxDiffLines caps the line diff printed per file.
+const planMaxDiffLines = 20
+
+// planMaxDiffInput skips line diffs of files too large to diff usefully.
+const planMaxDiffInput = 256 << 10
+
+func runComposePlan(podFile string) error {
+	podDir, err := filepath.Abs(filepath.Dir(podFile))
+	if err != nil {
+		return fmt.Errorf("resolve pod directory: %w", err)
+	}
+	scratch, err := os.MkdirTemp("", "claw-plan-")
+	if err != nil {
+		return fmt.Errorf("create plan scratch dir: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := runComposeUpWithOptions(podFile, composeUpOptions{planDir: scratch, skipImageBuilds: true}); err != nil {
+		return err
+	}
+
+	current, err := loadPlanSide(podDir, nil)
+	if err != nil {
+		return err
+	}
+	planned, err := loadPlanSide(scratch, strings.NewReplacer(scratch, podDir))
+	if err != nil {
+		return err
+	}
+
+	changes := diffPlan(current, planned, newPlanRedactor(current, planned))
+	printPlan(os.Stdout, changes)
+	if len(changes) > 0 {
+		return &exitCodeError{code: planChangesExitCode, err: fmt.Errorf("plan: %d service(s) would change", len(changes))}
+	}
+	return nil
+}
+
+// planSide is one state of a pod: the compose services and the digests of
+// their read-only mounted files.
+type planSide struct {
+	services map[string]map[string]interface{}
+	inputs   map[string]map[string]mountedInput
+	rewrite  *strings.Replacer // maps scratch paths to pod paths; nil for the current state
+}
+
+func loadPlanSide(dir string, rewrite *strings.Replacer) (*planSide, error) {
+	side := &planSide{
+		services: make(map[string]map[string]interface{}),
+		inputs:   make(map[string]map[string]mountedInput),
+		rewrite:  rewrite,
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "compose.generated.yml"))
+	if os.IsNotExist(err) {
+		return side, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read compose.generated.yml: %w", err)
+	}
+
+	text := string(data)
+	if rewrite != nil {
+		text = rewrite.Replace(text)
+	}
+	var cf struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(text), &cf); err != nil {
+		return nil, fmt.Errorf("parse compose.generated.yml: %w", err)
+	}
+	if cf.Services != nil {
+		side.services = cf.Services
+	}
+	// The config hash summarizes the rest of the service; report the causes.
+	for _, svc := range side.services {
+		if labels, ok := svc["labels"].(map[string]interface{}); ok {
+			delete(labels, configHashLabel)
+		}
+	}
+
+	inputs, err := loadRuntimeInputs(filepath.Join(dir, ".claw-runtime"))
+	if err != nil {
+		return nil, err
+	}
+	if inputs == nil {
+		// Runtime dirs written before the inputs record: digest what is on disk.
+		if inputs, err = collectRuntimeInputs(data, filepath.Join(dir, ".claw-runtime")); err != nil {
+			return nil, err
+		}
+	}
+	side.inputs = inputs.Services
+	return side, nil
+}
+
+// serviceChange is the plan for one compose service. Kind is '+' (added),
+// '-' (removed) or '~' (changed).
+type serviceChange struct {
+	Name  string
+	Kind  byte
+	Lines []string
+}
+
+func diffPlan(current, planned *planSide, redact *planRedactor) []serviceChange {
+	names := make(map[string]struct{})
+	for name := range current.services {
+		names[name] = struct{}{}
+	}
+	for name := range planned.services {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changes := make([]serviceChange, 0)
+	for _, name := range sorted {
+		oldSvc, hadOld := current.services[name]
+		newSvc, hasNew := planned.services[name]
+		switch {
+		case !hadOld:
+			changes = append(changes, serviceChange{Name: name, Kind: '+'})
+		case !hasNew:
+			changes = append(changes, serviceChange{Name: name, Kind: '-'})
+		default:
+			lines := diffServiceEnv(oldSvc["environment"], newSvc["environment"], redact)
+			lines = append(lines, diffServiceMounts(oldSvc["volumes"], newSvc["volumes"])...)
+			lines = append(lines, diffServiceKeys(oldSvc, newSvc, redact)...)
+			lines = append(lines, diffServiceFiles(current.inputs[name], planned.inputs[name], planned.rewrite, redact)...)
+			if len(lines) > 0 {
+				changes = append(changes, serviceChange{Name: name, Kind: '~', Lines: lines})
+			}
+		}
+	}
+	return changes
+}
+
+func diffServiceEnv(oldRaw, newRaw interface{}, redact *planRedactor) []string {
+	oldEnv, newEnv := composeEnvMap(oldRaw), composeEnvMap(newRaw)
+	keys := make(map[string]struct{})
+	for k := range oldEnv {
+		keys[k] = struct{}{}
+	}
+	for k := range newEnv {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	lines := make([]string, 0)
+	for _, k := range sorted {
+		oldVal, hadOld := oldEnv[k]
+		newVal, hasNew := newEnv[k]
+		switch {
+		case !hadOld:
+			lines = append(lines, fmt.Sprintf("+ env %s=%s", k, redact.envValue(k, newVal)))
+		case !hasNew:
+			lines = append(lines, fmt.Sprintf("- env %s", k))
+		case oldVal != newVal:
+			if isSecretEnvKey(k) {
+				lines = append(lines, fmt.Sprintf("~ env %s (value redacted)", k))
+			} else {
+				lines = append(lines, fmt.Sprintf("~ env %s: %s -> %s", k, redact.envValue(k, oldVal), redact.envValue(k, newVal)))
+			}
+		}
+	}
+	return lines
+}
+
+// composeEnvMap reads a compose environment block in map or "KEY=value" list
+// form.
+func composeEnvMap(raw interface{}) map[string]string {
+	out := make(map[string]string)
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if val == nil {
+				out[k] = ""
+				continue
+			}
+			out[k] = fmt.Sprint(val)
+		}
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				continue
+			}
+			k, val, _ := strings.Cut(s, "=")
+			out[k] = val
+		}
+	}
+	return out
+}
+
+func diffServiceMounts(oldRaw, newRaw interface{}) []string {
+	oldSet, newSet := mountStrings(oldRaw), mountStrings(newRaw)
+	lines := make([]string, 0)
+	for _, m := range sortedSetKeys(oldSet) {
+		if _, ok := newSet[m]; !ok {
+			lines = append(lines, "- mount "+m)
+		}
+	}
+	for _, m := range sortedSetKeys(newSet) {
+		if _, ok := oldSet[m]; !ok {
+			lines = append(lines, "+ mount "+m)
+		}
+	}
+	return lines
+}
+
+func mountStrings(raw interface{}) map[string]struct{} {
+	out := make(map[string]struct{})
+	list, _ := raw.([]interface{})
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			out[v] = struct{}{}
+		default:
+			out[compactYAML(v)] = struct{}{}
+		}
+	}
+	return out
+}
+
+// diffServiceKeys compares every compose key other than environment and
+// volumes, which have their own sections.
+func diffServiceKeys(oldSvc, newSvc map[string]interface{}, redact *planRedactor) []string {
+	keys := make(map[string]struct{})
+	for k := range oldSvc {
+		keys[k] = struct{}{}
+	}
+	for k := range newSvc {
+		keys[k] = struct{}{}
+	}
+	delete(keys, "environment")
+	delete(keys, "volumes")
+
+	lines := make([]string, 0)
+	for _, k := range sortedSetKeys(keys) {
+		oldVal, hadOld := oldSvc[k]
+		newVal, hasNew := newSvc[k]
+		switch {
+		case !hadOld:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", k, redact.text(compactYAML(newVal))))
+		case !hasNew:
+			lines = append(lines, fmt.Sprintf("- %s", k))
+		case !reflect.DeepEqual(oldVal, newVal):
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", k, redact.text(compactYAML(oldVal)), redact.text(compactYAML(newVal))))
+		}
+	}
+	return lines
+}
+
+// diffServiceFiles compares mounted file digests by container path and, when
+// both versions are on disk, prints a redacted line diff. Pod-owned files are
+// mounted in place, so only their digest shows they changed.
+func diffServiceFiles(oldFiles, newFiles map[string]mountedInput, rewrite *strings.Replacer, redact *planRedactor) []string {
+	paths := make(map[string]struct{})
+	for p := range oldFiles {
+		paths[p] = struct{}{}
+	}
+	for p := range newFiles {
+		paths[p] = struct{}{}
+	}
+
+	lines := make([]string, 0)
+	for _, p := range sortedSetKeys(paths) {
+		oldFile, hadOld := oldFiles[p]
+		newFile, hasNew := newFiles[p]
+		switch {
+		case !hadOld:
+			lines = append(lines, "+ file "+p)
+		case !hasNew:
+			lines = append(lines, "- file "+p)
+		case oldFile.SHA256 != newFile.SHA256:
+			if newFile.Source == oldFile.Source {
+				lines = append(lines, "~ file "+p+" (changed since last claw up)")
+				continue
+			}
+			diff, comparable := fileLineDiff(oldFile.Source, newFile.Source, rewrite)
+			if comparable && len(diff) == 0 {
+				// Only scratch paths differed.
+				continue
+			}
+			lines = append(lines, "~ file "+p)
+			if isSecretFile(newFile.Source) {
+				lines = append(lines, "    (content redacted)")
+				continue
+			}
+			for _, d := range diff {
+				lines = append(lines, "    "+redact.text(d))
+			}
+		}
+	}
+	return lines
+}
+
+// fileLineDiff returns the changed lines between two text files. comparable is
+// false when either side is missing, binary or too large to diff.
+func fileLineDiff(oldPath, newPath string, rewrite *strings.Replacer) ([]string, bool) {
+	oldData, err := readInputFile(oldPath)
+	if err != nil || len(oldData) > planMaxDiffInput || bytes.IndexByte(oldData, 0) >= 0 {
+		return nil, false
+	}
+	newData, err := readInputFile(newPath)
+	if err != nil || len(newData) > planMaxDiffInput || bytes.IndexByte(newData, 0) >= 0 {
+		return nil, false
+	}
+	newText := string(newData)
+	if rewrite != nil {
+		newText = rewrite.Replace(newText)
+	}
+	if string(oldData) == newText {
+		return nil, true
+	}
+	return lineDiff(splitLines(string(oldData)), splitLines(newText), planMaxDiffLines), true
+}
+
+// lineDiff returns "- " and "+ " lines from a longest-common-subsequence diff,
+// truncated to max lines.
+func lineDiff(a, b []string, max int) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	out := make([]string, 0)
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	if len(out) > max {
+		more := len(out) - max
+		out = append(out[:max], fmt.Sprintf("... %d more changed line(s)", more))
+	}
+	return out
+}
+
+// isSecretFile treats owner-only files (credentials.json, the egress policy)
+// as secret: their diffs are never printed.
+func isSecretFile(p string) bool {
+	info, err := os.Stat(p)
+	return err != nil || info.Mode().Perm()&0o077 == 0
+}
+
+// isSecretEnvKey reports whether an env value must not be printed.
+func isSecretEnvKey(k string) bool {
+	if isProviderKey(k) {
+		return true
+	}
+	upper := strings.ToUpper(k)
+	for _, marker := range []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "PRIVATE", "_KEY"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// planRedactor hides secret values: every value of a secret env key and the
+// password of proxy URLs on either side, plus anything secretscan flags.
+type planRedactor struct {
+	secrets []string
+}
+
+func newPlanRedactor(sides ...*planSide) *planRedactor {
+	seen := make(map[string]struct{})
+	r := &planRedactor{}
+	add := func(s string) {
+		if len(s) < 4 {
+			return
+		}
+		if _, ok := seen[s]; ok {
+			return
+		}
+		seen[s] = struct{}{}
+		r.secrets = append(r.secrets, s)
+	}
+	for _, side := range sides {
+		for _, svc := range side.services {
+			for k, v := range composeEnvMap(svc["environment"]) {
+				if isSecretEnvKey(k) {
+					add(v)
+				}
+				if u, err := url.Parse(v); err == nil && u.User != nil {
+					if pw, ok := u.User.Password(); ok {
+						add(pw)
+					}
+				}
+			}
+		}
+	}
+	// Longest first so a secret containing another is replaced whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+func (r *planRedactor) text(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, "[redacted]")
+	}
+	return secretscan.Redact(s)
+}
+
+func (r *planRedactor) envValue(k, v string) string {
+	if isSecretEnvKey(k) {
+		return "(redacted)"
+	}
+	v = r.text(v)
+	if len(v) > 80 {
+		v = v[:77] + "..."
+	}
+	return fmt.Sprintf("%q", v)
+}
+
+func compactYAML(v interface{}) string {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := strings.TrimSpace(string(data))
+	if strings.Contains(s, "\n") {
+		// Flow style keeps maps and lists on one line.
+		var node yaml.Node
+		if err := node.Encode(v); err == nil {
+			setFlowStyle(&node)
+			if flow, err := yaml.Marshal(&node); err == nil {
+				s = strings.TrimSpace(string(flow))
+			}
+		}
+	}
+	if len(s) > 120 {
+		s = s[:117] + "..."
+	}
+	return s
+}
+
+func setFlowStyle(n *yaml.Node) {
+	n.Style |= yaml.FlowStyle
+	for _, c := range n.Content {
+		setFlowStyle(c)
+	}
+}
+
+func sortedSetKeys(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func printPlan(w io.Writer, changes []serviceChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes. compose.generated.yml and .claw-runtime are up to date.")
+		return
+	}
+	var added, changed, removed int
+	fmt.Fprintln(w)
+	for _, c := range changes {
+		switch c.Kind {
+		case '+':
+			added++
+			fmt.Fprintf(w, "+ %s (new service)\n", c.Name)
+		case '-':
+			removed++
+			fmt.Fprintf(w, "- %s (removed)\n", c.Name)
+		default:
+			changed++
+			fmt.Fprintf(w, "~ %s\n", c.Name)
+		}
+		for _, line := range c.Lines {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	fmt.Fprintf(w, "\nPlan: %d to add, %d to change, %d to remove.\n", added, changed, removed)
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
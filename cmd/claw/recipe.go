@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mostlydev/clawdapus/internal/clawfile"
+	"github.com/mostlydev/clawdapus/internal/recipe"
+)
+
+var (
+	recipeSince  string
+	recipeFormat string
+	recipeNoSave bool
+)
+
+var replicaSuffixPattern = regexp.MustCompile(`-[0-9]+$`)
+
+var recipeCmd = &cobra.Command{
+	Use:   "recipe <service>",
+	Short: "Suggest image promotions from TRACK mutation logs",
+	Long: "Reads the TRACK mutation log of a service, resolves installs and removals into\n" +
+		"a net set of pinned packages per manager, and prints Dockerfile RUN lines.\n" +
+		"The recipe is saved under .claw-recipes/<service>/ for 'claw bake --from-recipe'.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podDir, err := resolvePodDir()
+		if err != nil {
+			return err
+		}
+		return runRecipe(podDir, args[0], recipeSince, recipeFormat, !recipeNoSave)
+	},
+}
+
+func runRecipe(podDir, service, since, format string, save bool) error {
+	window, err := parseSince(since)
+	if err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	if format != "run" && format != "json" {
+		return fmt.Errorf("--format: expected run or json, got %q", format)
+	}
+
+	service = resolveRuntimeServiceName(podDir, service)
+	logPath := trackLogHostPath(podDir, service)
+	mutations, err := recipe.LoadMutations(logPath)
+	if err != nil {
+		return err
+	}
+	if len(mutations) == 0 {
+		return fmt.Errorf("service %q: no TRACK mutations recorded at %s (does its Clawfile declare TRACK?)", service, logPath)
+	}
+
+	var sinceTime time.Time
+	if window > 0 {
+		sinceTime = time.Now().UTC().Add(-window)
+	}
+	r := recipe.Build(service, mutations, sinceTime)
+
+	if format == "json" {
+		data, err := r.Marshal()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	} else {
+		printRecipe(r)
+	}
+	for _, s := range r.Skipped {
+		fmt.Fprintf(os.Stderr, "[claw] warning: not promoted: %s\n", s)
+	}
+
+	if r.Empty() || !save {
+		return nil
+	}
+	path, err := recipe.Save(recipeDir(podDir, service), r)
+	if err != nil {
+		return err
+	}
+	rel, relErr := filepath.Rel(podDir, path)
+	if relErr != nil {
+		rel = path
+	}
+	fmt.Fprintf(os.Stderr, "[claw] recipe saved to %s\n", rel)
+	fmt.Fprintf(os.Stderr, "[claw] apply with: claw bake %s --from-recipe latest\n", service)
+	return nil
+}
+
+func printRecipe(r *recipe.Recipe) {
+	if r.Empty() {
+		fmt.Printf("[claw] %s: nothing to promote\n", r.Service)
+		return
+	}
+	for _, manager := range r.Managers() {
+		specs := make([]string, 0, len(r.Packages[manager]))
+		for _, pkg := range r.Packages[manager] {
+			if pkg.Version == "" {
+				specs = append(specs, pkg.Name)
+				continue
+			}
+			specs = append(specs, pkg.Name+" "+pkg.Version)
+		}
+		fmt.Printf("  %s: %s\n", manager, strings.Join(specs, ", "))
+	}
+	fmt.Println()
+	for _, line := range r.RunLines() {
+		fmt.Println(line)
+	}
+}
+
+// resolvePodDir returns the directory holding claw-pod.yml (--file or cwd).
+func resolvePodDir() (string, error) {
+	if composePodFile != "" {
+		abs, err := filepath.Abs(composePodFile)
+		if err != nil {
+			return "", fmt.Errorf("resolve pod file path %q: %w", composePodFile, err)
+		}
+		return filepath.Dir(abs), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("resolve current directory: %w", err)
+	}
+	return cwd, nil
+}
+
+// resolveRuntimeServiceName maps a replica name (svc-0) to its base service when
+// only the base service has a runtime dir: replicas share one runtime dir.
+func resolveRuntimeServiceName(podDir, service string) string {
+	if _, err := os.Stat(filepath.Join(podDir, ".claw-runtime", service)); err == nil {
+		return service
+	}
+	base := replicaSuffixPattern.ReplaceAllString(service, "")
+	if base == service {
+		return service
+	}
+	if _, err := os.Stat(filepath.Join(podDir, ".claw-runtime", base)); err == nil {
+		return base
+	}
+	return service
+}
+
+func trackLogHostPath(podDir, service string) string {
+	return filepath.Join(podDir, ".claw-runtime", service, trackRuntimeDirName, filepath.Base(clawfile.TrackLogPath))
+}
+
+func recipeDir(podDir, service string) string {
+	return filepath.Join(podDir, ".claw-recipes", service)
+}
+
+func init() {
+	recipeCmd.Flags().StringVar(&recipeSince, "since", "", "Only consider mutations within this window (e.g. 7d, 24h)")
+	recipeCmd.Flags().StringVar(&recipeFormat, "format", "run", "Output format (run, json)")
+	recipeCmd.Flags().BoolVar(&recipeNoSave, "no-save", false, "Print the recipe without saving it under .claw-recipes/")
+	rootCmd.AddCommand(recipeCmd)
+}
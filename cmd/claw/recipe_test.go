@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/recipe"
+)
+
+func writeTrackLog(t *testing.T, podDir, service, content string) {
+	t.Helper()
+	path := trackLogHostPath(podDir, service)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create track dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write track log: %v", err)
+	}
+}
+
+func TestRunRecipeSavesRecipeForReplicaBaseService(t *testing.T) {
+	podDir := t.TempDir()
+	writeTrackLog(t, podDir, "crusher", `{"timestamp":"2026-03-02T10:00:00Z","manager":"pip","command":"pip","action":"install","args":["install","tiktoken"],"exit_code":0,"versions":{"tiktoken":"0.7.0"}}`+"\n")
+
+	if err := runRecipe(podDir, "crusher-0", "", "run", true); err != nil {
+		t.Fatalf("runRecipe: %v", err)
+	}
+
+	latest, err := recipe.Latest(recipeDir(podDir, "crusher"))
+	if err != nil {
+		t.Fatalf("expected saved recipe: %v", err)
+	}
+	r, err := recipe.Load(latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Service != "crusher" {
+		t.Fatalf("expected recipe for base service, got %q", r.Service)
+	}
+	if got := r.RunLines(); len(got) != 1 || got[0] != "RUN pip install --no-cache-dir tiktoken==0.7.0" {
+		t.Fatalf("unexpected RUN lines: %q", got)
+	}
+}
+
+func TestRunRecipeWithoutTrackLogFails(t *testing.T) {
+	err := runRecipe(t.TempDir(), "crusher", "", "run", true)
+	if err == nil || !strings.Contains(err.Error(), "no TRACK mutations recorded") {
+		t.Fatalf("expected missing log error, got %v", err)
+	}
+}
+
+func TestRunRecipeRejectsUnknownFormat(t *testing.T) {
+	err := runRecipe(t.TempDir(), "crusher", "", "yaml", false)
+	if err == nil || !strings.Contains(err.Error(), "--format") {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mostlydev/clawdapus/internal/clawdash"
+	"github.com/mostlydev/clawdapus/internal/driver"
+)
+
+var rollbackList bool
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [generation]",
+	Short: "Re-apply a previous claw up generation",
+	Long: `Restore the runtime dir and compose.generated.yml recorded by an earlier
+'claw up' and apply them with docker compose, then run the same post-apply
+verification as 'claw up'. Nothing is re-resolved or re-materialized: mounted
+inputs come from the generation's own copies, and services whose image tag
+has moved are pinned to the image ID the generation ran. Without an argument,
+rolls back to the generation before the current one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podFile := composePodFile
+		if podFile == "" {
+			podFile = "claw-pod.yml"
+		}
+		podDir, err := filepath.Abs(filepath.Dir(podFile))
+		if err != nil {
+			return fmt.Errorf("resolve pod directory: %w", err)
+		}
+		if rollbackList {
+			return printGenerations(os.Stdout, podDir)
+		}
+		target := 0
+		if len(args) == 1 {
+			target, err = strconv.Atoi(args[0])
+			if err != nil || target < 1 {
+				return fmt.Errorf("claw rollback: invalid generation %q", args[0])
+			}
+		}
+		return runRollback(podDir, target)
+	},
+}
+
+func runRollback(podDir string, target int) error {
+	current := currentGeneration(podDir)
+	if target == 0 {
+		var err error
+		if target, err = previousGeneration(podDir, current); err != nil {
+			return err
+		}
+	}
+
+	composePath, err := restoreGeneration(podDir, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[claw] restored generation %d\n", target)
+	drift, err := generationInputDrift(podDir, target, composePath)
+	if err != nil {
+		return err
+	}
+	pinWarnings, err := pinGenerationImages(podDir, target, composePath)
+	if err != nil {
+		return err
+	}
+	for _, warning := range append(drift, pinWarnings...) {
+		fmt.Printf("[claw] warning: generation %d: %s\n", target, warning)
+	}
+
+	manifest, err := loadPodManifest(filepath.Join(podDir, ".claw-runtime", "pod-manifest.json"))
+	if err != nil {
+		return err
+	}
+	drivers, resolvedClaws, err := manifestDrivers(manifest)
+	if err != nil {
+		return err
+	}
+
+	if err := runComposeDockerCommand("compose", "-f", composePath, "up", "-d"); err != nil {
+		return fmt.Errorf("docker compose up failed: %w", err)
+	}
+	if err := postApplyServices(composePath, drivers, resolvedClaws); err != nil {
+		return err
+	}
+	if err := setCurrentGeneration(podDir, target); err != nil {
+		return err
+	}
+	fmt.Printf("[claw] pod rolled back to generation %d\n", target)
+	return nil
+}
+
+// previousGeneration returns the newest generation older than current. With
+// no current pointer, the newest generation counts as current.
+func previousGeneration(podDir string, current int) (int, error) {
+	gens, err := listGenerations(podDir)
+	if err != nil {
+		return 0, err
+	}
+	if current == 0 && len(gens) > 0 {
+		current = gens[len(gens)-1].Number
+	}
+	prev := 0
+	for _, g := range gens {
+		if g.Number < current {
+			prev = g.Number
+		}
+	}
+	if prev == 0 {
+		return 0, fmt.Errorf("claw rollback: no generation before %d in %s", current, generationsDir(podDir))
+	}
+	return prev, nil
+}
+
+func loadPodManifest(path string) (*clawdash.PodManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pod manifest: %w", err)
+	}
+	var manifest clawdash.PodManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse pod manifest %q: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// manifestDrivers rebuilds what PostApply needs from a recorded pod manifest:
+// the driver, ordinal count and invocations of every claw service.
+func manifestDrivers(manifest *clawdash.PodManifest) (map[string]driver.Driver, map[string]*driver.ResolvedClaw, error) {
+	drivers := make(map[string]driver.Driver)
+	resolvedClaws := make(map[string]*driver.ResolvedClaw)
+	for name, svc := range manifest.Services {
+		if svc.ClawType == "" {
+			continue
+		}
+		d, err := driver.Lookup(svc.ClawType)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service %q: %w", name, err)
+		}
+		drivers[name] = d
+		resolvedClaws[name] = &driver.ResolvedClaw{
+			ServiceName: name,
+			ImageRef:    svc.ImageRef,
+			ClawType:    svc.ClawType,
+			Count:       svc.Count,
+			Models:      svc.Models,
+			Handles:     svc.Handles,
+			Invocations: svc.Invocations,
+			Cllama:      svc.Cllama,
+		}
+	}
+	return drivers, resolvedClaws, nil
+}
+
+func printGenerations(w io.Writer, podDir string) error {
+	gens, err := listGenerations(podDir)
+	if err != nil {
+		return err
+	}
+	if len(gens) == 0 {
+		fmt.Fprintf(w, "No generations recorded in %s\n", generationsDir(podDir))
+		return nil
+	}
+	current := currentGeneration(podDir)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "GENERATION\tCREATED\tPOD\t")
+	for i := len(gens) - 1; i >= 0; i-- {
+		g := gens[i]
+		marker := ""
+		if g.Number == current {
+			marker = "(current)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", g.Number, g.Created, g.Pod, marker)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	rollbackCmd.Flags().BoolVar(&rollbackList, "list", false, "List recorded generations")
+	rootCmd.AddCommand(rollbackCmd)
+}
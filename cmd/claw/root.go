@@ -15,8 +15,21 @@ var rootCmd = &cobra.Command{
 	Use:          "claw",
 	Short:        "Infrastructure-layer governance for AI agent containers",
 	SilenceUsage: true,
+	// main prints errors, leaving out exitCodeError results.
+	SilenceErrors: true,
 }
 
 func init() {
 	rootCmd.Version = fmt.Sprintf("%s (%s)", version, commit)
 }
+
+// exitCodeError makes claw exit with code instead of 1, for results such as
+// "claw plan found changes" that callers need to tell apart from failures.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+
+func (e *exitCodeError) Unwrap() error { return e.err }
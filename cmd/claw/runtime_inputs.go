@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runtimeInputsFile records, per compose service, the digest of every file
+// bind-mounted read-only into it. claw plan compares it across runs; pod-owned
+// files such as AGENTS.md are mounted in place, so the record is the only
+// trace of their previous content.
+const runtimeInputsFile = "inputs.json"
+
+const runtimeInputsVersion = 1
+
+type runtimeInputs struct {
+	Version  int                                `json:"version"`
+	Services map[string]map[string]mountedInput `json:"services"`
+}
+
+// mountedInput is one file as seen inside a container: its host source and
+// the digest of its normalized content.
+type mountedInput struct {
+	Source string `json:"source"`
+	SHA256 string `json:"sha256"`
+}
+
+// volatileJSONKeys are generation timestamps that change on every run without
+// changing behaviour (openclaw jobs.json). They are ignored when comparing.
+var volatileJSONKeys = map[string]struct{}{
+	"createdAtMs": {},
+	"updatedAtMs": {},
+}
+
+type composeVolumesFile struct {
+	Services map[string]struct {
+		Volumes []interface{} `yaml:"volumes"`
+	} `yaml:"services"`
+}
+
+// collectRuntimeInputs digests the inputs bind-mounted into every service of a
+// generated compose file: read-only mounts, and read-write mounts that claw
+// generates under runtimeDir (openclaw.json, jobs.json, the persona copy).
+// Other read-write mounts and the preserved log directories hold state, not
+// inputs.
+func collectRuntimeInputs(composeYAML []byte, runtimeDir string) (*runtimeInputs, error) {
+	var cf composeVolumesFile
+	if err := yaml.Unmarshal(composeYAML, &cf); err != nil {
+		return nil, fmt.Errorf("parse compose for runtime inputs: %w", err)
+	}
+	inputs := &runtimeInputs{Version: runtimeInputsVersion, Services: make(map[string]map[string]mountedInput)}
+	for name, svc := range cf.Services {
+		files := make(map[string]mountedInput)
+		for _, raw := range svc.Volumes {
+			source, target, readOnly := parseBindMount(raw)
+			if !filepath.IsAbs(source) || (!readOnly && !isGeneratedRuntimePath(runtimeDir, source)) {
+				continue
+			}
+			if err := digestMountSource(source, target, files); err != nil {
+				return nil, fmt.Errorf("service %q: digest mount %q: %w", name, source, err)
+			}
+		}
+		if len(files) > 0 {
+			inputs.Services[name] = files
+		}
+	}
+	return inputs, nil
+}
+
+// isGeneratedRuntimePath reports whether p lies under runtimeDir outside the
+// directories resetRuntimeDir preserves.
+func isGeneratedRuntimePath(runtimeDir, p string) bool {
+	rel, err := filepath.Rel(runtimeDir, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) > 1 {
+		if isPreservedRuntimeDir(parts[0], parts[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBindMount accepts the short "src:dst[:mode]" and long {type: bind}
+// compose volume forms.
+func parseBindMount(raw interface{}) (source, target string, readOnly bool) {
+	switch v := raw.(type) {
+	case string:
+		parts := strings.Split(v, ":")
+		if len(parts) < 2 {
+			return "", "", false
+		}
+		readOnly = len(parts) > 2 && strings.Contains(","+parts[2]+",", ",ro,")
+		return parts[0], parts[1], readOnly
+	case map[string]interface{}:
+		if t, _ := v["type"].(string); t != "" && t != "bind" {
+			return "", "", false
+		}
+		source, _ = v["source"].(string)
+		target, _ = v["target"].(string)
+		readOnly, _ = v["read_only"].(bool)
+		return source, target, readOnly
+	}
+	return "", "", false
+}
+
+// digestMountSource adds the regular files under source to files, keyed by
+// their container path. Missing sources and special files are skipped.
+func digestMountSource(source, target string, files map[string]mountedInput) error {
+	info, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().IsRegular() {
+		sum, err := digestInputFile(source)
+		if err != nil {
+			return err
+		}
+		files[target] = mountedInput{Source: source, SHA256: sum}
+		return nil
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	return filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		sum, err := digestInputFile(p)
+		if err != nil {
+			return err
+		}
+		files[path.Join(target, filepath.ToSlash(rel))] = mountedInput{Source: p, SHA256: sum}
+		return nil
+	})
+}
+
+func digestInputFile(p string) (string, error) {
+	data, err := readInputFile(p)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readInputFile returns a file's content with volatileJSONKeys removed from
+// JSON documents.
+func readInputFile(p string) ([]byte, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(filepath.Ext(p), ".json") {
+		return data, nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return data, nil
+	}
+	if !stripVolatileJSON(doc) {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return data, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// stripVolatileJSON deletes volatileJSONKeys in place and reports whether any
+// were found.
+func stripVolatileJSON(v interface{}) bool {
+	found := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if _, volatile := volatileJSONKeys[k]; volatile {
+				delete(t, k)
+				found = true
+				continue
+			}
+			if stripVolatileJSON(child) {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, child := range t {
+			if stripVolatileJSON(child) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// relocateRuntimeInputs rewrites the sources recorded under from to the same
+// paths under to.
+func relocateRuntimeInputs(inputs *runtimeInputs, from, to string) {
+	for _, files := range inputs.Services {
+		for target, in := range files {
+			if rel, err := filepath.Rel(from, in.Source); err == nil && isWithinDir(from, in.Source) {
+				in.Source = filepath.Join(to, rel)
+				files[target] = in
+			}
+		}
+	}
+}
+
+func writeRuntimeInputs(runtimeDir string, inputs *runtimeInputs) error {
+	data, err := json.MarshalIndent(inputs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal runtime inputs: %w", err)
+	}
+	if err := os.MkdirAll(runtimeDir, 0o700); err != nil {
+		return fmt.Errorf("create runtime dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(runtimeDir, runtimeInputsFile), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write runtime inputs: %w", err)
+	}
+	return nil
+}
+
+// loadRuntimeInputs reads a previous record. It returns nil, nil when the
+// runtime dir predates the record.
+func loadRuntimeInputs(runtimeDir string) (*runtimeInputs, error) {
+	p := filepath.Join(runtimeDir, runtimeInputsFile)
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read runtime inputs: %w", err)
+	}
+	var inputs runtimeInputs
+	if err := json.Unmarshal(data, &inputs); err != nil {
+		return nil, fmt.Errorf("parse runtime inputs %q: %w", p, err)
+	}
+	if inputs.Version != runtimeInputsVersion {
+		return nil, fmt.Errorf("runtime inputs %q: unsupported version %d", p, inputs.Version)
+	}
+	if inputs.Services == nil {
+		inputs.Services = make(map[string]map[string]mountedInput)
+	}
+	return &inputs, nil
+}
+
+// liveRuntimeMounts returns the directories under runtimeDir that services of
+// the previous compose file bind-mount. Docker creates an empty directory in
+// place of a missing file source, so empty read-only mount sources are left
+// out and get deleted.
+func liveRuntimeMounts(runtimeDir, composePath string) map[string]struct{} {
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil
+	}
+	var cf composeVolumesFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil
+	}
+	keep := make(map[string]struct{})
+	for _, svc := range cf.Services {
+		for _, raw := range svc.Volumes {
+			source, _, readOnly := parseBindMount(raw)
+			if !isGeneratedRuntimePath(runtimeDir, source) {
+				continue
+			}
+			info, err := os.Stat(source)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			if readOnly {
+				if entries, err := os.ReadDir(source); err != nil || len(entries) == 0 {
+					continue
+				}
+			}
+			keep[filepath.Clean(source)] = struct{}{}
+		}
+	}
+	return keep
+}
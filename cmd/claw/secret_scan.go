@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+	"github.com/mostlydev/clawdapus/internal/secretscan"
+)
+
+// scanServiceArtifacts fails closed when the contract, includes, skills or
+// persona about to be mounted into an agent contain suspected secrets.
+func scanServiceArtifacts(podDir, name string, rc *driver.ResolvedClaw, allow secretscan.Allowlist) error {
+	paths := make([]string, 0, 2+len(rc.Includes)+len(rc.Skills))
+	paths = append(paths, rc.AgentHostPath)
+	for _, include := range rc.Includes {
+		paths = append(paths, include.HostPath)
+	}
+	for _, skill := range rc.Skills {
+		paths = append(paths, skill.HostPath)
+	}
+	paths = append(paths, rc.PersonaHostPath)
+
+	seen := make(map[string]struct{}, len(paths))
+	findings := make([]secretscan.Finding, 0)
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+		if _, dup := seen[path]; dup {
+			continue
+		}
+		seen[path] = struct{}{}
+		pathFindings, err := secretscan.ScanPath(path)
+		if err != nil {
+			return fmt.Errorf("service %q: secret scan %q: %w", name, path, err)
+		}
+		findings = append(findings, pathFindings...)
+	}
+
+	return secretFindingsError(podDir, name, "agent artifacts", allow.Filter(findings))
+}
+
+// secretFindingsError reports filtered findings as one error, or nil when
+// there are none.
+func secretFindingsError(podDir, name, what string, findings []secretscan.Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(findings))
+	for _, f := range findings {
+		if rel, err := filepath.Rel(podDir, f.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			f.Path = rel
+		}
+		lines = append(lines, "  "+f.String())
+	}
+	return fmt.Errorf("service %q: %d suspected secret(s) in %s:\n%s\nremove them, or add the fingerprints of false positives to %s",
+		name, len(findings), what, strings.Join(lines, "\n"), secretscan.AllowlistFile)
+}
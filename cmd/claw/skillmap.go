@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+	"github.com/mostlydev/clawdapus/internal/inspect"
+	"github.com/mostlydev/clawdapus/internal/pod"
+	"github.com/mostlydev/clawdapus/internal/runtime"
+)
+
+// Skill sources, as reported by claw skillmap.
+const (
+	skillSourceDescribe = "describe"        // pod x-claw.describe on a service surface target
+	skillSourceEmitted  = "service-emitted" // claw.skill.emit, own image or service surface target
+	skillSourceMCP      = "mcp"             // MCP tools/list discovery
+	skillSourceOpenAPI  = "openapi"         // OpenAPI catalog discovery
+	skillSourceFallback = "fallback"        // generated service connection stub
+	skillSourceChannel  = "channel"         // channel surface skill
+	skillSourceInclude  = "include"         // x-claw.include reference entry
+	skillSourceHandle   = "handle"          // x-claw.handles identity skill
+	skillSourceImage    = "image"           // claw.skill.* image labels
+	skillSourcePod      = "pod"             // x-claw.skills
+)
+
+type skillOrigin struct {
+	source  string
+	surface string
+}
+
+// skillCandidate is one skill offered to a service before precedence is
+// applied.
+type skillCandidate struct {
+	skill   driver.ResolvedSkill
+	source  string
+	surface string
+}
+
+// skillMapEntry reports one candidate skill of a service. ShadowedBy names the
+// source of the skill that replaced it; it is empty for mounted skills.
+type skillMapEntry struct {
+	Name          string
+	Source        string
+	Surface       string
+	HostPath      string
+	ContainerPath string
+	ShadowedBy    string
+}
+
+func skillCandidates(skills []driver.ResolvedSkill, source string) []skillCandidate {
+	out := make([]skillCandidate, 0, len(skills))
+	for _, sk := range skills {
+		out = append(out, skillCandidate{skill: sk, source: source})
+	}
+	return out
+}
+
+// channelSkillSurface returns the channel surface a surface-<platform>.md
+// skill was generated for.
+func channelSkillSurface(surfaces []driver.ResolvedSurface, skillName string) string {
+	for _, surface := range surfaces {
+		if surface.Scheme == "channel" && fmt.Sprintf("surface-%s.md", strings.TrimSpace(surface.Target)) == skillName {
+			return "channel://" + surface.Target
+		}
+	}
+	return ""
+}
+
+// resolveAgentContract returns the contract filename a claw service mounts and
+// its host path: the pod's x-claw.agent, else the image's claw.agent label.
+func resolveAgentContract(podDir string, svc *pod.Service, info *inspect.ClawInfo) (string, string, error) {
+	agentFile := info.Agent
+	if svc.Claw.Agent != "" {
+		contract, err := runtime.ResolveContract(podDir, svc.Claw.Agent)
+		if err != nil {
+			return "", "", err
+		}
+		// Use the basename from the pod-level agent path
+		return filepath.Base(svc.Claw.Agent), contract.HostPath, nil
+	}
+	if agentFile == "" {
+		return "", "", nil
+	}
+	contract, err := runtime.ResolveContract(podDir, agentFile)
+	if err != nil {
+		return "", "", err
+	}
+	return agentFile, contract.HostPath, nil
+}
+
+// serviceSurfacesWithPorts returns a claw service's parsed surfaces with the
+// expose: and ports: of each service target merged in, since both describe
+// reachable container ports.
+func serviceSurfacesWithPorts(p *pod.Pod, svc *pod.Service) []driver.ResolvedSurface {
+	surfaces := svc.Claw.Surfaces
+	for i := range surfaces {
+		if surfaces[i].Scheme == "service" {
+			if targetSvc, ok := p.Services[surfaces[i].Target]; ok {
+				surfaces[i].Ports = mergedPorts(targetSvc.Expose, targetSvc.Ports)
+			}
+		}
+	}
+	return surfaces
+}
+
+// serviceSkillCandidates gathers every skill offered to a claw service, lowest
+// precedence first: generated surface, channel, include and handle skills,
+// then image labels, the image's own claw.skill.emit and pod x-claw.skills.
+// Generated skills are written under svcRuntimeDir. It also returns the
+// surfaces with the skill name each service surface resolved to.
+func serviceSkillCandidates(podDir, svcRuntimeDir string, p *pod.Pod, name string, svc *pod.Service, info *inspect.ClawInfo, surfaces []driver.ResolvedSurface, includeSkills []driver.ResolvedSkill, imageRefs map[string]string, infos map[string]*inspect.ClawInfo, discovery *skillDiscovery) ([]driver.ResolvedSurface, []skillCandidate, error) {
+	imageSkills, err := runtime.ResolveSkills(podDir, info.Skills)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %q: %w", name, err)
+	}
+	var emittedSkills []driver.ResolvedSkill
+	if info.SkillEmit != "" {
+		emitSkill, err := resolveSkillEmit(name, svcRuntimeDir, svc.Image, info.SkillEmit, discovery)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service %q: resolve emitted skill: %w", name, err)
+		}
+		if emitSkill != nil {
+			emittedSkills = append(emittedSkills, *emitSkill)
+		}
+	}
+	surfaces, surfaceSkills, surfaceOrigins, err := resolveServiceSurfaceSkillOrigins(podDir, svcRuntimeDir, p, surfaces, imageRefs, infos, discovery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %q: resolve service surface skills: %w", name, err)
+	}
+	// Add channel surface skills (surface-discord.md etc.)
+	channelSkills, err := resolveChannelGeneratedSkills(svcRuntimeDir, surfaces)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %q: resolve generated channel skills: %w", name, err)
+	}
+	handleSkills, err := resolveHandleSkills(svcRuntimeDir, svc.Claw.Handles)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %q: resolve handle skills: %w", name, err)
+	}
+	podSkills, err := runtime.ResolveSkills(podDir, svc.Claw.Skills)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %q: %w", name, err)
+	}
+
+	candidates := make([]skillCandidate, 0)
+	for _, sk := range surfaceSkills {
+		origin := surfaceOrigins[sk.Name]
+		candidates = append(candidates, skillCandidate{skill: sk, source: origin.source, surface: origin.surface})
+	}
+	for _, sk := range channelSkills {
+		candidates = append(candidates, skillCandidate{skill: sk, source: skillSourceChannel, surface: channelSkillSurface(surfaces, sk.Name)})
+	}
+	candidates = append(candidates, skillCandidates(includeSkills, skillSourceInclude)...)
+	candidates = append(candidates, skillCandidates(handleSkills, skillSourceHandle)...)
+	candidates = append(candidates, skillCandidates(imageSkills, skillSourceImage)...)
+	candidates = append(candidates, skillCandidates(emittedSkills, skillSourceEmitted)...)
+	candidates = append(candidates, skillCandidates(podSkills, skillSourcePod)...)
+	return surfaces, candidates, nil
+}
+
+// resolveSkillCandidates applies skill precedence to candidates given lowest
+// first: a later skill replaces an earlier one with the same name, keeping its
+// position. It returns the effective skills and
+// an inventory listing every candidate, each mounted skill followed by the
+// candidates it shadowed.
+func resolveSkillCandidates(candidates []skillCandidate) ([]driver.ResolvedSkill, []skillMapEntry) {
+	var order []string
+	winner := make(map[string]int)
+	shadowed := make(map[string][]int)
+	for i, c := range candidates {
+		prev, ok := winner[c.skill.Name]
+		if !ok {
+			order = append(order, c.skill.Name)
+		} else {
+			shadowed[c.skill.Name] = append([]int{prev}, shadowed[c.skill.Name]...)
+		}
+		winner[c.skill.Name] = i
+	}
+
+	skills := make([]driver.ResolvedSkill, 0, len(order))
+	inventory := make([]skillMapEntry, 0, len(candidates))
+	for _, name := range order {
+		w := candidates[winner[name]]
+		skills = append(skills, w.skill)
+		inventory = append(inventory, newSkillMapEntry(w, ""))
+		for _, i := range shadowed[name] {
+			inventory = append(inventory, newSkillMapEntry(candidates[i], w.source))
+		}
+	}
+	return skills, inventory
+}
+
+func newSkillMapEntry(c skillCandidate, shadowedBy string) skillMapEntry {
+	return skillMapEntry{
+		Name:       c.skill.Name,
+		Source:     c.source,
+		Surface:    c.surface,
+		HostPath:   c.skill.HostPath,
+		ShadowedBy: shadowedBy,
+	}
+}
+
+// skillContainerPath is where a driver's skill directory layout mounts a skill.
+func skillContainerPath(result *driver.MaterializeResult, name string) string {
+	if result.SkillLayout == "directory" {
+		// Claude Code format: skills/name/SKILL.md
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		return filepath.Join(result.SkillDir, stem, "SKILL.md")
+	}
+	return filepath.Join(result.SkillDir, name)
+}
+
+var skillmapCmd = &cobra.Command{
+	Use:   "skillmap <service>",
+	Short: "Show the skills a claw service would receive and where each comes from",
+	Long: `Resolve the skills of one claw service the way claw up does and list every
+candidate skill: its source (image label, pod, service-emitted, describe, MCP,
+OpenAPI or fallback discovery, channel, handle, include), the surface it came
+from, its container path, and whether a higher-priority source shadowed it.
+Generated skills are written to a scratch directory; no image is built, no
+discovery container is started (skills emitted by images or discovered over
+MCP and OpenAPI are read from .claw-runtime), and no compose file is emitted.
+Replica names such as bot-0 map to their service.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podFile := composePodFile
+		if podFile == "" {
+			podFile = "claw-pod.yml"
+		}
+		return runSkillmap(os.Stdout, podFile, args[0])
+	},
+}
+
+func runSkillmap(w io.Writer, podFile, service string) error {
+	f, err := os.Open(podFile)
+	if err != nil {
+		return fmt.Errorf("open pod file: %w", err)
+	}
+	defer f.Close()
+	p, err := pod.Parse(f)
+	if err != nil {
+		return err
+	}
+	podDir, err := filepath.Abs(filepath.Dir(podFile))
+	if err != nil {
+		return fmt.Errorf("resolve pod directory: %w", err)
+	}
+	if err := resolveRuntimePlaceholders(podDir, p); err != nil {
+		return fmt.Errorf("resolve x-claw runtime placeholders: %w", err)
+	}
+	name, svc := skillmapService(p, service)
+	if svc == nil {
+		return fmt.Errorf("service %q is not a claw service in %s", service, podFile)
+	}
+
+	scratch, err := os.MkdirTemp("", "claw-skillmap-")
+	if err != nil {
+		return fmt.Errorf("create skillmap scratch dir: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+	runtimeDir := filepath.Join(scratch, ".claw-runtime")
+	svcRuntimeDir := filepath.Join(runtimeDir, name)
+	if err := os.MkdirAll(svcRuntimeDir, 0o700); err != nil {
+		return fmt.Errorf("create skillmap scratch dir: %w", err)
+	}
+
+	imageRef, err := resolveManagedServiceImage(podDir, p, name, svc, true)
+	if err != nil {
+		return err
+	}
+	info, err := inspectClawImage(imageRef)
+	if err != nil {
+		return fmt.Errorf("inspect image %q for service %q: %w", imageRef, name, err)
+	}
+	if info.ClawType == "" {
+		return fmt.Errorf("service %q: image %q has no claw.type label", name, imageRef)
+	}
+	agentFile, agentHostPath, err := resolveAgentContract(podDir, svc, info)
+	if err != nil {
+		return fmt.Errorf("service %q: %w", name, err)
+	}
+	_, includeSkills, err := materializeContractIncludes(podDir, svcRuntimeDir, agentHostPath, svc.Claw.Include)
+	if err != nil {
+		return fmt.Errorf("service %q: materialize contract includes: %w", name, err)
+	}
+	discovery := &skillDiscovery{
+		skipImageBuilds:   true,
+		scratchRuntimeDir: runtimeDir,
+		liveRuntimeDir:    filepath.Join(podDir, ".claw-runtime"),
+	}
+	imageRefs := map[string]string{name: imageRef}
+	infos := map[string]*inspect.ClawInfo{name: info}
+	surfaces, candidates, err := serviceSkillCandidates(podDir, svcRuntimeDir, p, name, svc, info, serviceSurfacesWithPorts(p, svc), includeSkills, imageRefs, infos, discovery)
+	if err != nil {
+		return err
+	}
+	skills, inventory := resolveSkillCandidates(candidates)
+
+	// Container paths follow the driver's skill layout, which only its
+	// Materialize reports; without it they are left blank.
+	if d, err := driver.Lookup(info.ClawType); err == nil {
+		rc := &driver.ResolvedClaw{
+			ServiceName:   name,
+			ImageRef:      imageRef,
+			ClawType:      info.ClawType,
+			Agent:         agentFile,
+			AgentHostPath: agentHostPath,
+			Models:        info.Models,
+			Handles:       svc.Claw.Handles,
+			Count:         svc.Claw.Count,
+			Environment:   svc.Environment,
+			Surfaces:      surfaces,
+			Skills:        skills,
+		}
+		if result, err := d.Materialize(rc, driver.MaterializeOpts{RuntimeDir: svcRuntimeDir, PodName: p.Name}); err == nil && result.SkillDir != "" {
+			for i := range inventory {
+				inventory[i].ContainerPath = skillContainerPath(result, inventory[i].Name)
+			}
+		}
+	}
+
+	printSkillMap(w, name, inventory, scratch)
+	return nil
+}
+
+// skillmapService finds the claw service named service, or the one it is a
+// replica of.
+func skillmapService(p *pod.Pod, service string) (string, *pod.Service) {
+	if svc, ok := p.Services[service]; ok && svc.Claw != nil {
+		return service, svc
+	}
+	for name, svc := range p.Services {
+		if svc.Claw == nil {
+			continue
+		}
+		for _, replica := range expandedServiceNames(name, svc.Claw.Count) {
+			if replica == service {
+				return name, svc
+			}
+		}
+	}
+	return "", nil
+}
+
+// printSkillMap renders an inventory. Host paths inside the scratch runtime
+// dir are generated files and are not shown.
+func printSkillMap(w io.Writer, service string, inventory []skillMapEntry, scratch string) {
+	if len(inventory) == 0 {
+		fmt.Fprintf(w, "%s: no skills\n", service)
+		return
+	}
+	fmt.Fprintf(w, "%s skills:\n", service)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SKILL\tSOURCE\tSURFACE\tCONTAINER PATH\tSTATUS\t")
+	for _, e := range inventory {
+		status := "mounted"
+		if e.ShadowedBy != "" {
+			status = "shadowed by " + e.ShadowedBy
+		}
+		source := e.Source
+		if e.HostPath != "" && scratch != "" && !strings.HasPrefix(e.HostPath, scratch) {
+			source += " (" + e.HostPath + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", e.Name, source, dashIfEmpty(e.Surface), dashIfEmpty(e.ContainerPath), status)
+	}
+	tw.Flush()
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(skillmapCmd)
+}
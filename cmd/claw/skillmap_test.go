@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+	"github.com/mostlydev/clawdapus/internal/inspect"
+	"github.com/mostlydev/clawdapus/internal/pod"
+)
+
+func TestResolveSkillCandidatesAppliesPrecedence(t *testing.T) {
+	surface := driver.ResolvedSkill{Name: "surface-api.md", HostPath: "/rt/surface-api.md"}
+	handle := driver.ResolvedSkill{Name: "handle-discord.md", HostPath: "/rt/handle-discord.md"}
+	image := driver.ResolvedSkill{Name: "surface-api.md", HostPath: "/img/surface-api.md"}
+	podSkill := driver.ResolvedSkill{Name: "surface-api.md", HostPath: "/pod/surface-api.md"}
+	custom := driver.ResolvedSkill{Name: "custom.md", HostPath: "/pod/custom.md"}
+
+	candidates := []skillCandidate{
+		{skill: surface, source: skillSourceFallback, surface: "service://api"},
+		{skill: handle, source: skillSourceHandle},
+		{skill: image, source: skillSourceImage},
+		{skill: podSkill, source: skillSourcePod},
+		{skill: custom, source: skillSourcePod},
+	}
+	skills, inventory := resolveSkillCandidates(candidates)
+
+	want := []driver.ResolvedSkill{podSkill, handle, custom}
+	if !reflect.DeepEqual(skills, want) {
+		t.Fatalf("skills = %+v, want %+v", skills, want)
+	}
+
+	got := make([]string, 0, len(inventory))
+	for _, e := range inventory {
+		got = append(got, e.Name+"/"+e.Source+"/"+e.ShadowedBy)
+	}
+	wantInventory := []string{
+		"surface-api.md/pod/",
+		"surface-api.md/image/pod",
+		"surface-api.md/fallback/pod",
+		"handle-discord.md/handle/",
+		"custom.md/pod/",
+	}
+	if !reflect.DeepEqual(got, wantInventory) {
+		t.Fatalf("inventory = %v, want %v", got, wantInventory)
+	}
+	if inventory[2].Surface != "service://api" {
+		t.Fatalf("expected shadowed surface skill to keep its surface, got %+v", inventory[2])
+	}
+}
+
+func TestResolveServiceSurfaceSkillOriginsReportsSource(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+	}()
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) {
+		return &inspect.ClawInfo{}, nil
+	}
+
+	surfaces := []driver.ResolvedSurface{
+		{Scheme: "service", Target: "api"},
+		{Scheme: "service", Target: "redis"},
+	}
+	p := &pod.Pod{Services: map[string]*pod.Service{
+		"api":   {Image: "example/api"},
+		"redis": {Image: "redis:7", Describe: &driver.ServiceDescription{Description: "cache"}},
+	}}
+	_, _, origins, err := resolveServiceSurfaceSkillOrigins(t.TempDir(), t.TempDir(), p, surfaces, map[string]string{}, map[string]*inspect.ClawInfo{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]skillOrigin{
+		"surface-api.md":   {source: skillSourceFallback, surface: "service://api"},
+		"surface-redis.md": {source: skillSourceDescribe, surface: "service://redis"},
+	}
+	if !reflect.DeepEqual(origins, want) {
+		t.Fatalf("origins = %+v, want %+v", origins, want)
+	}
+}
+
+func TestSkillContainerPathFollowsLayout(t *testing.T) {
+	flat := &driver.MaterializeResult{SkillDir: "/claw/skills"}
+	if got := skillContainerPath(flat, "trade.md"); got != "/claw/skills/trade.md" {
+		t.Fatalf("flat layout path = %q", got)
+	}
+	dir := &driver.MaterializeResult{SkillDir: "/home/node/.claude/skills", SkillLayout: "directory"}
+	if got := skillContainerPath(dir, "trade.md"); got != "/home/node/.claude/skills/trade/SKILL.md" {
+		t.Fatalf("directory layout path = %q", got)
+	}
+}
+
+func TestPrintSkillMapShowsShadowing(t *testing.T) {
+	var buf bytes.Buffer
+	printSkillMap(&buf, "bot", []skillMapEntry{
+		{Name: "custom.md", Source: skillSourcePod, HostPath: "/pod/custom.md", ContainerPath: "/claw/skills/custom.md"},
+		{Name: "custom.md", Source: skillSourceImage, HostPath: "/scratch/bot/skills/custom.md", ContainerPath: "/claw/skills/custom.md", ShadowedBy: skillSourcePod},
+	}, "/scratch")
+	out := buf.String()
+	for _, want := range []string{"pod (/pod/custom.md)", "shadowed by pod", "mounted"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("skill map missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "/scratch/") {
+		t.Fatalf("expected scratch host paths hidden:\n%s", out)
+	}
+}
+
+func TestRunSkillmapResolvesReplicaWithoutEmittingCompose(t *testing.T) {
+	prevExists := imageExistsLocally
+	prevInspect := inspectClawImage
+	defer func() {
+		imageExistsLocally = prevExists
+		inspectClawImage = prevInspect
+	}()
+	imageExistsLocally = func(string) bool { return true }
+	inspectClawImage = func(string) (*inspect.ClawInfo, error) { return &inspect.ClawInfo{ClawType: "generic"}, nil }
+
+	podDir := t.TempDir()
+	podFile := filepath.Join(podDir, "claw-pod.yml")
+	writePlanFile(t, podFile, "x-claw:\n  pod: ops\nservices:\n  bot:\n    image: example/bot\n    x-claw:\n      agent: AGENTS.md\n      count: 2\n      skills:\n        - ./skills/custom.md\n")
+	writePlanFile(t, filepath.Join(podDir, "AGENTS.md"), "# bot\n")
+	writePlanFile(t, filepath.Join(podDir, "skills", "custom.md"), "# custom\n")
+
+	var buf bytes.Buffer
+	if err := runSkillmap(&buf, podFile, "bot-1"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "custom.md") {
+		t.Fatalf("expected pod skill in skill map:\n%s", buf.String())
+	}
+	for _, name := range []string{"compose.generated.yml", ".claw-runtime", runtimeStagingDirName} {
+		if _, err := os.Stat(filepath.Join(podDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected no %s in pod dir, got err=%v", name, err)
+		}
+	}
+
+	if err := runSkillmap(&buf, podFile, "bot-2"); err == nil {
+		t.Fatal("expected error for a replica beyond count")
+	}
+}
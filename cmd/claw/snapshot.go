@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/spf13/cobra"
+
+	"github.com/mostlydev/clawdapus/internal/persona"
+	"github.com/mostlydev/clawdapus/internal/secretscan"
+)
+
+var (
+	snapshotAs         string
+	snapshotPersonaRef string
+	snapshotNoPersona  bool
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot <service>",
+	Short: "Capture a running claw as an image and a persona artifact",
+	Long: "Commits the running container's writable state to a new image that keeps the\n" +
+		"original claw.* labels plus provenance labels, and pushes the service's persona\n" +
+		"workspace as an OCI artifact.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if strings.TrimSpace(snapshotAs) == "" {
+			return fmt.Errorf("--as <image-ref> is required")
+		}
+		generatedPath, err := resolveComposeGeneratedPath()
+		if err != nil {
+			return err
+		}
+		return runSnapshot(generatedPath, args[0], snapshotAs, snapshotPersonaRef, !snapshotNoPersona)
+	},
+}
+
+func runSnapshot(generatedPath, service, imageRef, personaRef string, withPersona bool) error {
+	ids, err := resolveContainerIDs(generatedPath, service)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 1 {
+		return fmt.Errorf("service %q has %d containers; snapshot one replica at a time", service, len(ids))
+	}
+	containerID := ids[0]
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	info, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("inspect container %s: %w", shortContainerID(containerID), err)
+	}
+	img, _, err := cli.ImageInspectWithRaw(ctx, info.Image)
+	if err != nil {
+		return fmt.Errorf("inspect image %s: %w", info.Image, err)
+	}
+
+	var containerLabels, imageLabels map[string]string
+	var containerEnv, imageEnv []string
+	sourceImage := info.Image
+	if info.Config != nil {
+		containerLabels = info.Config.Labels
+		containerEnv = info.Config.Env
+		if info.Config.Image != "" {
+			sourceImage = info.Config.Image
+		}
+	}
+	if img.Config != nil {
+		imageLabels = img.Config.Labels
+		imageEnv = img.Config.Env
+	}
+	if imageLabels["claw.type"] == "" {
+		return fmt.Errorf("service %q: container image has no claw.type label (not a claw)", service)
+	}
+
+	provenance := map[string]string{
+		"claw.snapshot.source-image":    sourceImage,
+		"claw.snapshot.source-image-id": info.Image,
+		"claw.snapshot.pod":             containerLabels["claw.pod"],
+		"claw.snapshot.service":         service,
+		"claw.snapshot.created":         time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// The persona is packed locally first so the image can record its digest,
+	// and pushed only once the image is committed.
+	var personaArtifact *persona.Artifact
+	if withPersona {
+		podDir := filepath.Dir(generatedPath)
+		personaDir := filepath.Join(podDir, ".claw-runtime", resolveRuntimeServiceName(podDir, service), "persona")
+		if _, err := os.Stat(personaDir); err == nil {
+			if personaRef == "" {
+				personaRef = derivePersonaRef(imageRef)
+			}
+			if personaRef == "" {
+				fmt.Fprintf(os.Stderr, "[claw] warning: %s: persona not captured: %q is not a registry ref; pass --persona-ref\n", service, imageRef)
+			} else {
+				if err := scanSnapshotPersona(podDir, service, personaDir); err != nil {
+					return err
+				}
+				personaArtifact, err = persona.Pack(ctx, personaDir, personaRef, provenance)
+				if err != nil {
+					return fmt.Errorf("service %q: %w", service, err)
+				}
+				defer personaArtifact.Close()
+				provenance["claw.snapshot.persona"] = personaRef + "@" + personaArtifact.Digest
+			}
+		}
+	}
+
+	resp, err := cli.ContainerCommit(ctx, containerID, container.CommitOptions{
+		Reference: imageRef,
+		Comment:   fmt.Sprintf("claw snapshot of %s", service),
+		Changes:   snapshotChanges(imageLabels, provenance, imageEnv, containerEnv),
+		Pause:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("commit container %s: %w", shortContainerID(containerID), err)
+	}
+	fmt.Printf("[claw] %s: snapshot %s (%s)\n", service, imageRef, shortContainerID(strings.TrimPrefix(resp.ID, "sha256:")))
+
+	if personaArtifact != nil {
+		if err := personaArtifact.Push(ctx); err != nil {
+			return fmt.Errorf("service %q: snapshot %s committed, but its persona was not pushed: %w", service, imageRef, err)
+		}
+		fmt.Printf("[claw] %s: pushed persona %s (%s)\n", service, personaRef, personaArtifact.Digest)
+	}
+	return nil
+}
+
+// scanSnapshotPersona refuses to publish a persona the agent has written
+// credentials into. The pod's secret allowlist applies as it does on claw up.
+func scanSnapshotPersona(podDir, service, personaDir string) error {
+	allow, err := secretscan.LoadAllowlist(filepath.Join(podDir, secretscan.AllowlistFile))
+	if err != nil {
+		return err
+	}
+	findings, err := secretscan.ScanPath(personaDir)
+	if err != nil {
+		return fmt.Errorf("service %q: secret scan %q: %w", service, personaDir, err)
+	}
+	return secretFindingsError(podDir, service, "the captured persona", allow.Filter(findings))
+}
+
+// snapshotChanges builds the Dockerfile-style changes applied on commit. Image
+// labels are carried over and provenance labels added. Environment variables
+// that only exist on the container (cllama tokens, credentials injected by
+// claw up) are blanked so secrets are never baked into the snapshot.
+func snapshotChanges(imageLabels, provenance map[string]string, imageEnv, containerEnv []string) []string {
+	labels := make(map[string]string, len(imageLabels)+len(provenance))
+	for k, v := range imageLabels {
+		labels[k] = v
+	}
+	for k, v := range provenance {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changes := make([]string, 0, len(keys)+len(containerEnv))
+	for _, k := range keys {
+		changes = append(changes, fmt.Sprintf("LABEL %s=%s", strconv.Quote(k), strconv.Quote(labels[k])))
+	}
+
+	imageKeys := make(map[string]struct{}, len(imageEnv))
+	for _, kv := range imageEnv {
+		imageKeys[envKey(kv)] = struct{}{}
+	}
+	runtimeKeys := make([]string, 0)
+	for _, kv := range containerEnv {
+		key := envKey(kv)
+		if _, ok := imageKeys[key]; ok || key == "" {
+			continue
+		}
+		runtimeKeys = append(runtimeKeys, key)
+	}
+	sort.Strings(runtimeKeys)
+	for _, key := range runtimeKeys {
+		changes = append(changes, fmt.Sprintf("ENV %s=", key))
+	}
+	return changes
+}
+
+func envKey(kv string) string {
+	if idx := strings.Index(kv, "="); idx >= 0 {
+		return kv[:idx]
+	}
+	return kv
+}
+
+// derivePersonaRef returns <repository>:<tag>-persona for registry image refs
+// (first path component is a host), or "" for local-only refs.
+func derivePersonaRef(imageRef string) string {
+	ref := strings.TrimSpace(imageRef)
+	if at := strings.Index(ref, "@"); at >= 0 {
+		ref = ref[:at]
+	}
+	slash := strings.Index(ref, "/")
+	if slash < 0 {
+		return ""
+	}
+	host := ref[:slash]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return ""
+	}
+	repo, tag := ref, "latest"
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		repo, tag = ref[:colon], ref[colon+1:]
+	}
+	return repo + ":" + tag + "-persona"
+}
+
+func init() {
+	snapshotCmd.Flags().StringVar(&snapshotAs, "as", "", "Image ref for the snapshot (e.g. ghcr.io/org/crusher:good-day)")
+	snapshotCmd.Flags().StringVar(&snapshotPersonaRef, "persona-ref", "", "OCI ref for the persona artifact (default: <as>-persona for registry refs)")
+	snapshotCmd.Flags().BoolVar(&snapshotNoPersona, "no-persona", false, "Skip capturing the persona workspace")
+	rootCmd.AddCommand(snapshotCmd)
+}
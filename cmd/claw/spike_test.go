@@ -306,14 +306,14 @@ func TestSpikeComposeUp(t *testing.T) {
 	// ── Verify cllama context artifacts ─────────────────────────────────────
 
 	for _, agent := range []string{"tiverton", "westin", "allen", "logan", "micro"} {
-		agentDir := filepath.Join(runtimeDir, "context", agent)
+		agentDir := filepath.Join(runtimeDir, "context", "cllama", agent)
 		for _, rel := range []string{"AGENTS.md", "CLAWDAPUS.md", "metadata.json"} {
 			if _, err := os.Stat(filepath.Join(agentDir, rel)); err != nil {
 				t.Errorf("cllama context missing %s/%s: %v", agent, rel, err)
 			}
 		}
 	}
-	metaPath := filepath.Join(runtimeDir, "context", "tiverton", "metadata.json")
+	metaPath := filepath.Join(runtimeDir, "context", "cllama", "tiverton", "metadata.json")
 	metaData := spikeReadFile(t, metaPath)
 	var meta map[string]interface{}
 	if err := json.Unmarshal([]byte(metaData), &meta); err != nil {
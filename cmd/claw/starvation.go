@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mostlydev/clawdapus/internal/pod"
+)
+
+// checkComposeCredentialSources extends credential starvation beyond the pod
+// environment block: env_file contents, build args and compose secrets mounted
+// into a cllama agent must not carry provider keys either.
+func checkComposeCredentialSources(podDir string, p *pod.Pod, name string, svc *pod.Service) error {
+	envFiles, err := composeEnvFiles(svc.Compose["env_file"])
+	if err != nil {
+		return fmt.Errorf("service %q: env_file: %w", name, err)
+	}
+	for _, envFile := range envFiles {
+		filePath := envFile.path
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(podDir, filePath)
+		}
+		fileEnv, err := readDotEnvFile(filePath)
+		if err != nil {
+			if os.IsNotExist(err) && !envFile.required {
+				continue
+			}
+			return fmt.Errorf("service %q: read env_file %q for credential starvation: %w", name, envFile.path, err)
+		}
+		for _, k := range sortedKeys(fileEnv) {
+			if isProviderKey(k) {
+				return fmt.Errorf("service %q: provider key %q found in env_file %q; cllama requires credential starvation (move provider keys to x-claw.cllama-env)", name, k, envFile.path)
+			}
+		}
+	}
+
+	for _, k := range composeBuildArgKeys(svc.Compose["build"]) {
+		if isProviderKey(k) {
+			return fmt.Errorf("service %q: provider key %q found in build args; cllama requires credential starvation", name, k)
+		}
+	}
+
+	secretDefs, _ := p.Compose["secrets"].(map[string]interface{})
+	for _, ref := range composeSecretRefs(svc.Compose["secrets"]) {
+		if isProviderKey(secretKeyName(ref.source)) || isProviderKey(secretKeyName(ref.target)) {
+			return fmt.Errorf("service %q: provider key secret %q targeted at cllama agent; cllama requires credential starvation (move provider keys to x-claw.cllama-env)", name, ref.source)
+		}
+		def, _ := secretDefs[ref.source].(map[string]interface{})
+		if envKey, _ := def["environment"].(string); isProviderKey(envKey) {
+			return fmt.Errorf("service %q: secret %q is sourced from provider key %q; cllama requires credential starvation (move provider keys to x-claw.cllama-env)", name, ref.source, envKey)
+		}
+	}
+	return nil
+}
+
+type composeEnvFile struct {
+	path     string
+	required bool
+}
+
+// composeEnvFiles accepts the compose env_file forms: a string, a list of
+// strings, or a list of {path, required} maps.
+func composeEnvFiles(raw interface{}) ([]composeEnvFile, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []composeEnvFile{{path: v, required: true}}, nil
+	case []interface{}:
+		out := make([]composeEnvFile, 0, len(v))
+		for i, item := range v {
+			switch entry := item.(type) {
+			case string:
+				out = append(out, composeEnvFile{path: entry, required: true})
+			case map[string]interface{}:
+				p, _ := entry["path"].(string)
+				if p == "" {
+					return nil, fmt.Errorf("entry %d: path is required", i)
+				}
+				required := true
+				if r, ok := entry["required"].(bool); ok {
+					required = r
+				}
+				out = append(out, composeEnvFile{path: p, required: required})
+			default:
+				return nil, fmt.Errorf("entry %d: unsupported type %T", i, item)
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", raw)
+	}
+}
+
+// composeBuildArgKeys returns the arg names of a compose build block, in
+// either map or "KEY=value" list form.
+func composeBuildArgKeys(raw interface{}) []string {
+	build, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0)
+	switch args := build["args"].(type) {
+	case map[string]interface{}:
+		for k := range args {
+			keys = append(keys, k)
+		}
+	case []interface{}:
+		for _, item := range args {
+			if s, ok := item.(string); ok {
+				k, _, _ := strings.Cut(s, "=")
+				keys = append(keys, strings.TrimSpace(k))
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type composeSecretRef struct {
+	source string
+	target string
+}
+
+// composeSecretRefs returns a service's secrets in short (string) or long
+// ({source, target}) form. The target defaults to the source name.
+func composeSecretRefs(raw interface{}) []composeSecretRef {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]composeSecretRef, 0, len(list))
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			out = append(out, composeSecretRef{source: v, target: v})
+		case map[string]interface{}:
+			source, _ := v["source"].(string)
+			target, _ := v["target"].(string)
+			if target == "" {
+				target = source
+			}
+			out = append(out, composeSecretRef{source: source, target: target})
+		}
+	}
+	return out
+}
+
+// secretKeyName maps a secret name or target path to the env-style key it
+// most likely holds: "/run/secrets/openai_api_key" -> "OPENAI_API_KEY".
+func secretKeyName(name string) string {
+	base := path.Base(strings.TrimSpace(name))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	return strings.ToUpper(strings.ReplaceAll(base, "-", "_"))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/pod"
+)
+
+func TestCheckComposeCredentialSourcesRejectsEnvFileKeys(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bot.env"), []byte("LOG_LEVEL=debug\nOPENAI_API_KEY=sk-test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := &pod.Pod{Services: map[string]*pod.Service{}}
+	svc := &pod.Service{Compose: map[string]interface{}{
+		"env_file": []interface{}{
+			"./bot.env",
+			map[string]interface{}{"path": "./missing.env", "required": false},
+		},
+	}}
+
+	err := checkComposeCredentialSources(dir, p, "bot", svc)
+	if err == nil || !strings.Contains(err.Error(), `provider key "OPENAI_API_KEY" found in env_file "./bot.env"`) {
+		t.Fatalf("expected env_file starvation error, got %v", err)
+	}
+}
+
+func TestCheckComposeCredentialSourcesRequiresListedEnvFiles(t *testing.T) {
+	p := &pod.Pod{Services: map[string]*pod.Service{}}
+	svc := &pod.Service{Compose: map[string]interface{}{"env_file": "./missing.env"}}
+	if err := checkComposeCredentialSources(t.TempDir(), p, "bot", svc); err == nil {
+		t.Fatal("expected error for missing required env_file")
+	}
+}
+
+func TestCheckComposeCredentialSourcesRejectsBuildArgs(t *testing.T) {
+	p := &pod.Pod{Services: map[string]*pod.Service{}}
+	for _, args := range []interface{}{
+		map[string]interface{}{"ANTHROPIC_API_KEY": "x"},
+		[]interface{}{"VERSION=1", "ANTHROPIC_API_KEY=x"},
+	} {
+		svc := &pod.Service{Compose: map[string]interface{}{
+			"build": map[string]interface{}{"context": ".", "args": args},
+		}}
+		err := checkComposeCredentialSources(t.TempDir(), p, "bot", svc)
+		if err == nil || !strings.Contains(err.Error(), "build args") {
+			t.Fatalf("expected build args starvation error for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestCheckComposeCredentialSourcesRejectsProviderSecrets(t *testing.T) {
+	p := &pod.Pod{
+		Services: map[string]*pod.Service{},
+		Compose: map[string]interface{}{
+			"secrets": map[string]interface{}{
+				"llm_key":     map[string]interface{}{"environment": "OPENROUTER_API_KEY"},
+				"webhook-key": map[string]interface{}{"file": "./webhook.txt"},
+				"db_pass":     map[string]interface{}{"file": "./db.txt"},
+			},
+		},
+	}
+
+	cases := map[string]interface{}{
+		"by name":        []interface{}{"db_pass", map[string]interface{}{"source": "db_pass", "target": "/run/secrets/openai_api_key"}},
+		"by environment": []interface{}{"llm_key"},
+	}
+	for label, secrets := range cases {
+		svc := &pod.Service{Compose: map[string]interface{}{"secrets": secrets}}
+		if err := checkComposeCredentialSources(t.TempDir(), p, "bot", svc); err == nil {
+			t.Fatalf("%s: expected secret starvation error", label)
+		}
+	}
+
+	ok := &pod.Service{Compose: map[string]interface{}{"secrets": []interface{}{"db_pass", "webhook-key"}}}
+	if err := checkComposeCredentialSources(t.TempDir(), p, "bot", ok); err != nil {
+		t.Fatalf("expected non-provider secrets to pass, got %v", err)
+	}
+}
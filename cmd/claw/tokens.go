@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage cllama bearer tokens",
+}
+
+var tokensRotateCmd = &cobra.Command{
+	Use:   "rotate [service...]",
+	Short: "Rotate cllama bearer tokens and recreate the affected containers",
+	Long: `Reissue the persistent cllama bearer tokens of the named pod services
+(every cllama agent when none are named), then re-run 'claw up -d'. Only
+those agents and the proxies in their chains change config, so only they are
+recreated.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		podFile := composePodFile
+		if podFile == "" {
+			podFile = "claw-pod.yml"
+		}
+		composeUpDetach = true
+		return runComposeUpWithOptions(podFile, composeUpOptions{
+			rotateTokens:   true,
+			rotateServices: args,
+		})
+	},
+}
+
+// tokenRotationServices validates the services requested for rotation against
+// the pod's cllama agents. An empty request selects every agent.
+func tokenRotationServices(requested, cllamaAgents []string) ([]string, error) {
+	if len(requested) == 0 {
+		out := append([]string(nil), cllamaAgents...)
+		sort.Strings(out)
+		return out, nil
+	}
+
+	known := make(map[string]struct{}, len(cllamaAgents))
+	for _, name := range cllamaAgents {
+		known[name] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(requested))
+	out := make([]string, 0, len(requested))
+	for _, name := range requested {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("claw tokens rotate: service %q does not use cllama", name)
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func init() {
+	tokensCmd.AddCommand(tokensRotateCmd)
+	rootCmd.AddCommand(tokensCmd)
+}
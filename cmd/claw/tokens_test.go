@@ -0,0 +1,22 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenRotationServicesDefaultsToAllAgents(t *testing.T) {
+	got, err := tokenRotationServices(nil, []string{"tiverton", "allen"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"allen", "tiverton"}) {
+		t.Fatalf("expected every cllama agent, got %v", got)
+	}
+}
+
+func TestTokenRotationServicesRejectsNonCllamaService(t *testing.T) {
+	if _, err := tokenRotationServices([]string{"redis"}, []string{"tiverton"}); err == nil {
+		t.Fatal("expected error for service without cllama")
+	}
+}
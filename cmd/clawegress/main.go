@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/egress"
+)
+
+func main() {
+	cfg := loadConfig()
+
+	if len(os.Args) > 1 && strings.TrimSpace(os.Args[1]) == "-healthcheck" {
+		if err := runHealthcheck(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+type config struct {
+	Addr       string
+	PolicyPath string
+	LogDir     string
+}
+
+func loadConfig() config {
+	return config{
+		Addr:       envOr("CLAW_EGRESS_ADDR", ":"+egress.Port),
+		PolicyPath: envOr("CLAW_EGRESS_POLICY", egress.PolicyPath),
+		LogDir:     envOr("CLAW_EGRESS_LOG_DIR", egress.LogDir),
+	}
+}
+
+func run(cfg config) error {
+	policy, err := egress.LoadPolicy(cfg.PolicyPath)
+	if err != nil {
+		return fmt.Errorf("clawegress: %w", err)
+	}
+
+	// Denials go to stdout (docker logs) and to an append-only audit file.
+	audit := io.Writer(os.Stdout)
+	if cfg.LogDir != "" {
+		f, err := os.OpenFile(filepath.Join(cfg.LogDir, egress.DeniedLogName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("clawegress: open audit log: %w", err)
+		}
+		defer f.Close()
+		audit = io.MultiWriter(os.Stdout, f)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           egress.NewProxy(policy, audit),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "clawegress listening on %s (%d services)\n", cfg.Addr, len(policy.Services))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-sigCh:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func runHealthcheck(cfg config) error {
+	addr := cfg.Addr
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://" + addr + egress.HealthcheckPath)
+	if err != nil {
+		return fmt.Errorf("clawegress healthcheck: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("clawegress healthcheck: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	return v
+}
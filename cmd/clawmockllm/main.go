@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/mockllm"
+)
+
+func main() {
+	cfg := loadConfig()
+
+	if len(os.Args) > 1 && strings.TrimSpace(os.Args[1]) == "-healthcheck" {
+		if err := runHealthcheck(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+type config struct {
+	Addr       string
+	ScriptPath string
+}
+
+func loadConfig() config {
+	return config{
+		Addr:       envOr("CLAW_MOCK_LLM_ADDR", ":"+mockllm.Port),
+		ScriptPath: strings.TrimSpace(os.Getenv("CLAW_MOCK_LLM_SCRIPT")),
+	}
+}
+
+func run(cfg config) error {
+	script, err := mockllm.LoadScript(cfg.ScriptPath)
+	if err != nil {
+		return fmt.Errorf("clawmockllm: %w", err)
+	}
+
+	// Completions are logged to stdout (docker logs) for test assertions.
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mockllm.NewHandler(script, os.Stdout),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "clawmockllm listening on %s (%d scripted rules)\n", cfg.Addr, len(script.Rules))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-sigCh:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func runHealthcheck(cfg config) error {
+	addr := cfg.Addr
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://" + addr + mockllm.HealthcheckPath)
+	if err != nil {
+		return fmt.Errorf("clawmockllm healthcheck: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("clawmockllm healthcheck: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	return v
+}
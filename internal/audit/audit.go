@@ -0,0 +1,241 @@
+// Package audit reads the structured JSON logs cllama proxies write to stdout.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record types defined by the cllama spec, plus error records from the
+// reference proxy.
+const (
+	TypeRequest      = "request"
+	TypeResponse     = "response"
+	TypeIntervention = "intervention"
+	TypeDriftScore   = "drift_score"
+	TypeError        = "error"
+)
+
+// Record is one audit log entry.
+type Record struct {
+	Time         time.Time `json:"ts"`
+	Proxy        string    `json:"proxy,omitempty"`
+	ClawID       string    `json:"claw_id"`
+	Type         string    `json:"type"`
+	Model        string    `json:"model,omitempty"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	LatencyMS    int64     `json:"latency_ms,omitempty"`
+	Intervention string    `json:"intervention,omitempty"`
+	Reason       string    `json:"intervention_reason,omitempty"`
+	DriftScore   *float64  `json:"drift_score,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// rawRecord accepts both field spellings in use: the spec's timestamp and
+// intervention_reason, and the reference proxy's ts and intervention, which
+// is either a string or an object with type and reason.
+type rawRecord struct {
+	Timestamp    string          `json:"timestamp"`
+	TS           string          `json:"ts"`
+	ClawID       string          `json:"claw_id"`
+	Type         string          `json:"type"`
+	Model        string          `json:"model"`
+	StatusCode   int             `json:"status_code"`
+	LatencyMS    float64         `json:"latency_ms"`
+	Intervention json.RawMessage `json:"intervention"`
+	Reason       string          `json:"intervention_reason"`
+	DriftScore   *float64        `json:"drift_score"`
+	Score        *float64        `json:"score"`
+	Error        string          `json:"error"`
+}
+
+// Read decodes audit records from proxy output. Lines that are not JSON audit
+// records, such as startup messages, are skipped.
+func Read(r io.Reader, proxy string) ([]Record, error) {
+	var out []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, '{'); i > 0 {
+			// docker compose logs may prefix lines with a timestamp.
+			line = line[i:]
+		}
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		rec, ok := parseRecord([]byte(line))
+		if !ok {
+			continue
+		}
+		rec.Proxy = proxy
+		out = append(out, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return out, nil
+}
+
+func parseRecord(line []byte) (Record, bool) {
+	var raw rawRecord
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return Record{}, false
+	}
+	if raw.ClawID == "" || raw.Type == "" {
+		return Record{}, false
+	}
+	ts := raw.TS
+	if ts == "" {
+		ts = raw.Timestamp
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return Record{}, false
+	}
+	rec := Record{
+		Time:       t.UTC(),
+		ClawID:     raw.ClawID,
+		Type:       raw.Type,
+		Model:      raw.Model,
+		StatusCode: raw.StatusCode,
+		LatencyMS:  int64(raw.LatencyMS),
+		Reason:     raw.Reason,
+		DriftScore: raw.DriftScore,
+		Error:      raw.Error,
+	}
+	if rec.DriftScore == nil {
+		rec.DriftScore = raw.Score
+	}
+	if len(raw.Intervention) > 0 && string(raw.Intervention) != "null" {
+		var kind string
+		if err := json.Unmarshal(raw.Intervention, &kind); err == nil {
+			rec.Intervention = kind
+		} else {
+			var obj struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			}
+			if err := json.Unmarshal(raw.Intervention, &obj); err == nil {
+				rec.Intervention = obj.Type
+				if rec.Reason == "" {
+					rec.Reason = obj.Reason
+				}
+			}
+		}
+	}
+	if rec.Type == TypeIntervention && rec.Intervention == "" {
+		rec.Intervention = "unspecified"
+	}
+	return rec, true
+}
+
+// Load reads a persisted audit sink: a JSONL file, or a directory whose
+// *.jsonl and *.log files are read in name order. The proxy is taken from
+// each file's base name.
+func Load(path string) ([]Record, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit sink: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("audit sink: %w", err)
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if !entry.IsDir() && (ext == ".jsonl" || ext == ".log") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	var out []Record
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("audit sink: %w", err)
+		}
+		proxy := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		records, err := Read(f, proxy)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("audit sink %s: %w", file, err)
+		}
+		out = append(out, records...)
+	}
+	return out, nil
+}
+
+// Filter selects records. Zero fields match everything.
+type Filter struct {
+	// Agents lists the claw_ids to keep; callers expand a counted service
+	// into its replicas (agent-0, agent-1, ...).
+	Agents       []string
+	Since        time.Time
+	Model        string
+	Type         string
+	Intervention string
+}
+
+// Match reports whether r passes the filter. A model matches with or without
+// its provider prefix; intervention types compare case-insensitively with
+// '-' and ' ' treated as '_'. Setting Intervention selects records that carry
+// an intervention, whatever their type (a proxy may attach one to a response);
+// "any" selects all of them.
+func (f Filter) Match(r Record) bool {
+	if len(f.Agents) > 0 && !slices.Contains(f.Agents, r.ClawID) {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if f.Model != "" && r.Model != f.Model && modelName(r.Model) != f.Model {
+		return false
+	}
+	if f.Type != "" && r.Type != f.Type {
+		return false
+	}
+	if f.Intervention != "" {
+		if r.Intervention == "" {
+			return false
+		}
+		if want := normalizeKind(f.Intervention); want != "any" && normalizeKind(r.Intervention) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the records matching f, oldest first.
+func (f Filter) Apply(records []Record) []Record {
+	out := make([]Record, 0, len(records))
+	for _, r := range records {
+		if f.Match(r) {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+func modelName(model string) string {
+	if i := strings.Index(model, "/"); i >= 0 {
+		return model[i+1:]
+	}
+	return model
+}
+
+func normalizeKind(kind string) string {
+	return strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(strings.TrimSpace(kind)))
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const proxyLog = `2026/03/01 10:00:00 cllama-passthrough API listening on :8080
+{"ts":"2026-03-01T10:00:01Z","claw_id":"crusher-0","type":"request","model":"openai/gpt-4o"}
+{"ts":"2026-03-01T10:00:02Z","claw_id":"crusher-0","type":"response","model":"openai/gpt-4o","status_code":200,"latency_ms":1250}
+{"timestamp":"2026-03-01T11:00:00Z","claw_id":"crusher-1","type":"intervention","model":"anthropic/claude-sonnet","intervention":{"type":"output_modified","reason":"financial advice detected"}}
+{"ts":"2026-03-01T12:00:00Z","claw_id":"crusher-12","type":"intervention","intervention":"tool-dropped","intervention_reason":"enforce: no shell"}
+{"ts":"2026-03-01T12:30:00Z","claw_id":"crusher","type":"drift_score","score":0.31}
+{"ts":"2026-03-01T13:00:00Z","claw_id":"other","type":"request","model":"gpt-4o"}
+{"ts":"2026-03-01T13:00:00Z","type":"request"}
+{"partial":
+`
+
+func TestReadParsesSpecAndReferenceFields(t *testing.T) {
+	records, err := Read(strings.NewReader(proxyLog), "cllama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("expected 6 records, got %d: %+v", len(records), records)
+	}
+	resp := records[1]
+	if resp.Proxy != "cllama" || resp.StatusCode != 200 || resp.LatencyMS != 1250 {
+		t.Fatalf("unexpected response record: %+v", resp)
+	}
+	obj := records[2]
+	if obj.Intervention != "output_modified" || obj.Reason != "financial advice detected" {
+		t.Fatalf("expected object intervention parsed: %+v", obj)
+	}
+	str := records[3]
+	if str.Intervention != "tool-dropped" || str.Reason != "enforce: no shell" {
+		t.Fatalf("expected string intervention parsed: %+v", str)
+	}
+	if drift := records[4]; drift.DriftScore == nil || *drift.DriftScore != 0.31 {
+		t.Fatalf("expected drift score parsed: %+v", drift)
+	}
+}
+
+func TestFilterMatchesReplicasWindowModelAndIntervention(t *testing.T) {
+	records, err := Read(strings.NewReader(proxyLog), "cllama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func(f Filter) int { return len(f.Apply(records)) }
+
+	if got := count(Filter{Agents: []string{"crusher-0", "crusher-1"}}); got != 3 {
+		t.Fatalf("expected the listed replicas only, got %d", got)
+	}
+	if got := count(Filter{Agents: []string{"crusher-1"}}); got != 1 {
+		t.Fatalf("expected replica name to match only itself, got %d", got)
+	}
+	if got := count(Filter{Since: time.Date(2026, 3, 1, 11, 30, 0, 0, time.UTC)}); got != 3 {
+		t.Fatalf("expected window to drop older records, got %d", got)
+	}
+	if got := count(Filter{Model: "gpt-4o"}); got != 3 {
+		t.Fatalf("expected model to match with and without provider, got %d", got)
+	}
+	if got := count(Filter{Intervention: "any"}); got != 2 {
+		t.Fatalf("expected all interventions, got %d", got)
+	}
+	if got := count(Filter{Intervention: "TOOL_DROPPED"}); got != 1 {
+		t.Fatalf("expected normalized intervention type match, got %d", got)
+	}
+	if got := count(Filter{Type: TypeRequest, Agents: []string{"other"}}); got != 1 {
+		t.Fatalf("expected type filter, got %d", got)
+	}
+}
+
+func TestFilterInterventionMatchesAnyRecordType(t *testing.T) {
+	log := `{"ts":"2026-03-01T10:00:00Z","claw_id":"crusher-0","type":"response","model":"openai/gpt-4o","status_code":200,"intervention":{"type":"output_modified","reason":"pii redacted"}}
+{"ts":"2026-03-01T10:00:01Z","claw_id":"crusher-0","type":"response","model":"openai/gpt-4o","status_code":200}
+`
+	records, err := Read(strings.NewReader(log), "cllama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []Filter{{Intervention: "any"}, {Intervention: "output-modified"}} {
+		got := f.Apply(records)
+		if len(got) != 1 || got[0].Type != TypeResponse || got[0].Reason != "pii redacted" {
+			t.Fatalf("%+v: expected the response carrying an intervention, got %+v", f, got)
+		}
+	}
+}
+
+func TestLoadReadsSinkDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cllama-policy.jsonl"), []byte(proxyLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(proxyLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	records, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 6 || records[0].Proxy != "cllama-policy" {
+		t.Fatalf("expected records from the .jsonl file only, got %d: %+v", len(records), records)
+	}
+	if _, err := Load(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("expected missing sink to fail")
+	}
+}
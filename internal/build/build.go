@@ -9,6 +9,7 @@ import (
 
 	"github.com/mostlydev/clawdapus/internal/clawfile"
 	"github.com/mostlydev/clawdapus/internal/driver"
+	_ "github.com/mostlydev/clawdapus/internal/driver/generic"
 	_ "github.com/mostlydev/clawdapus/internal/driver/microclaw"
 	_ "github.com/mostlydev/clawdapus/internal/driver/nanobot"
 	_ "github.com/mostlydev/clawdapus/internal/driver/nanoclaw"
@@ -37,6 +38,18 @@ func Generate(clawfilePath string) (string, error) {
 	if err := ensureBaseImage(parsed, d); err != nil {
 		return "", err
 	}
+	if len(parsed.Config.Tracks) > 0 {
+		// The TRACK block switches to root and must hand back the user the
+		// image would otherwise run as, including one inherited from its base.
+		base := clawfile.FinalStageBaseImage(parsed)
+		if base != "" && !strings.EqualFold(base, "scratch") {
+			user, err := baseImageUser(base)
+			if err != nil {
+				return "", fmt.Errorf("resolve user of base image %q: %w", base, err)
+			}
+			parsed.BaseImageUser = user
+		}
+	}
 
 	rendered, err := clawfile.Emit(parsed)
 	if err != nil {
@@ -102,6 +115,24 @@ func BuildFromGenerated(generatedPath string, tag string) error {
 	return nil
 }
 
+// baseImageUser returns the configured user of an image, pulling it when it
+// is not available locally. Tests replace it.
+var baseImageUser = func(imageRef string) (string, error) {
+	if !ImageExistsLocally(imageRef) {
+		pull := exec.Command("docker", "pull", imageRef)
+		pull.Stdout = os.Stdout
+		pull.Stderr = os.Stderr
+		if err := pull.Run(); err != nil {
+			return "", fmt.Errorf("pull: %w", err)
+		}
+	}
+	out, err := exec.Command("docker", "image", "inspect", "--format", "{{.Config.User}}", imageRef).Output()
+	if err != nil {
+		return "", fmt.Errorf("inspect: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // ImageExistsLocally returns true if the given image tag is available in the
 // local Docker daemon.
 func ImageExistsLocally(tag string) bool {
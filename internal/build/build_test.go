@@ -154,3 +154,34 @@ MODEL primary openrouter/anthropic/claude-sonnet-4
 		t.Fatal("missing claw.type=picoclaw label in generated output")
 	}
 }
+
+func TestGenerateResolvesTrackBaseImageUser(t *testing.T) {
+	prev := baseImageUser
+	defer func() { baseImageUser = prev }()
+	var inspected string
+	baseImageUser = func(ref string) (string, error) {
+		inspected = ref
+		return "node", nil
+	}
+
+	dir := t.TempDir()
+	clawfilePath := filepath.Join(dir, "Clawfile")
+	content := "FROM scratch AS unused\nFROM node:22-slim\nCLAW_TYPE nullclaw\nTRACK npm\n"
+	if err := os.WriteFile(clawfilePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	generatedPath, err := Generate(clawfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspected != "node:22-slim" {
+		t.Fatalf("expected final stage base image inspected, got %q", inspected)
+	}
+	out, err := os.ReadFile(generatedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "USER node") {
+		t.Fatalf("expected inherited user restored after TRACK install:\n%s", out)
+	}
+}
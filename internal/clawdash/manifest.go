@@ -8,6 +8,7 @@ type PodManifest struct {
 	PodName  string                     `json:"podName"`
 	Services map[string]ServiceManifest `json:"services"`
 	Proxies  []ProxyManifest            `json:"proxies,omitempty"`
+	Volumes  []VolumeManifest           `json:"volumes,omitempty"`
 }
 
 type ServiceManifest struct {
@@ -36,4 +37,16 @@ type ProxyManifest struct {
 	ProxyType   string `json:"proxyType"`
 	ServiceName string `json:"serviceName"`
 	Image       string `json:"image"`
+	Upstream    string `json:"upstream,omitempty"` // service name of the next hop in the chain
+}
+
+// VolumeManifest describes a top-level volume with an x-claw.access ACL.
+type VolumeManifest struct {
+	Name   string                 `json:"name"`
+	Access []VolumeAccessManifest `json:"access"`
+}
+
+type VolumeAccessManifest struct {
+	Service string `json:"service"` // service name or glob pattern
+	Mode    string `json:"mode"`    // maximum granted mode: read-only or read-write
 }
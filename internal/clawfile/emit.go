@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
 )
 
 func Emit(result *ParseResult) (string, error) {
@@ -13,7 +15,7 @@ func Emit(result *ParseResult) (string, error) {
 	}
 
 	var b strings.Builder
-	generated := buildGeneratedLines(result.Config)
+	generated := buildGeneratedLines(result.Config, finalStageUser(result))
 
 	for _, node := range result.DockerNodes {
 		original := strings.TrimSuffix(node.Original, "\n")
@@ -36,10 +38,10 @@ func Emit(result *ParseResult) (string, error) {
 	return b.String(), nil
 }
 
-func buildGeneratedLines(config *ClawConfig) []string {
+func buildGeneratedLines(config *ClawConfig, finalUser string) []string {
 	lines := make([]string, 0)
 	lines = append(lines, buildLabelLines(config)...)
-	lines = append(lines, buildInfraLines(config)...)
+	lines = append(lines, buildInfraLines(config, finalUser)...)
 	return lines
 }
 
@@ -99,8 +101,73 @@ func buildLabelLines(config *ClawConfig) []string {
 	return lines
 }
 
-func buildInfraLines(_ *ClawConfig) []string {
-	return nil
+func buildInfraLines(config *ClawConfig, finalUser string) []string {
+	return buildTrackLines(config.Tracks, finalUser)
+}
+
+// finalStageUser returns the user the final build stage runs as: its last
+// USER, else the effective user of the stage it builds on, else
+// BaseImageUser. "" means root.
+func finalStageUser(result *ParseResult) string {
+	stageUsers := make(map[string]string)
+	user := ""
+	alias := ""
+	for _, node := range result.DockerNodes {
+		switch strings.ToLower(node.Value) {
+		case "from":
+			if alias != "" {
+				stageUsers[alias] = user
+			}
+			image, name := fromImageAndAlias(node)
+			alias = name
+			if inherited, ok := stageUsers[strings.ToLower(image)]; ok {
+				user = inherited
+			} else {
+				user = result.BaseImageUser
+			}
+		case "user":
+			if node.Next != nil {
+				user = node.Next.Value
+			}
+		}
+	}
+	if user == "root" || user == "0" || strings.HasPrefix(user, "0:") || strings.HasPrefix(user, "root:") {
+		return ""
+	}
+	return user
+}
+
+// FinalStageBaseImage returns the external image the final build stage
+// descends from, following FROM references to earlier stages.
+func FinalStageBaseImage(result *ParseResult) string {
+	stageImages := make(map[string]string)
+	base := ""
+	for _, node := range result.DockerNodes {
+		if !strings.EqualFold(node.Value, "from") {
+			continue
+		}
+		image, name := fromImageAndAlias(node)
+		if earlier, ok := stageImages[strings.ToLower(image)]; ok {
+			image = earlier
+		}
+		if name != "" {
+			stageImages[name] = image
+		}
+		base = image
+	}
+	return base
+}
+
+// fromImageAndAlias splits "FROM <image> [AS <name>]"; the alias is lowercased.
+func fromImageAndAlias(node *parser.Node) (string, string) {
+	if node.Next == nil {
+		return "", ""
+	}
+	image := node.Next.Value
+	if as := node.Next.Next; as != nil && strings.EqualFold(as.Value, "as") && as.Next != nil {
+		return image, strings.ToLower(as.Next.Value)
+	}
+	return image, ""
 }
 
 func formatLabel(key string, value string) string {
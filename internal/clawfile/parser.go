@@ -29,6 +29,10 @@ var knownDirectives = map[string]bool{
 type ParseResult struct {
 	Config      *ClawConfig
 	DockerNodes []*parser.Node
+	// BaseImageUser is the configured user of the image the final stage
+	// builds on (see FinalStageBaseImage). The builder fills it in; "" means
+	// root.
+	BaseImageUser string
 }
 
 func Parse(r io.Reader) (*ParseResult, error) {
@@ -136,7 +140,7 @@ func Parse(r io.Reader) (*ParseResult, error) {
 
 		case "privilege":
 			if len(args) < 2 {
-				return nil, fmt.Errorf("line %d: PRIVILEGE requires <mode> <user-spec>", node.StartLine)
+				return nil, fmt.Errorf("line %d: PRIVILEGE requires <mode> <value>", node.StartLine)
 			}
 			mode := args[0]
 			config.Privileges[mode] = strings.TrimSpace(strings.TrimPrefix(remainder, mode))
@@ -151,7 +155,13 @@ func Parse(r io.Reader) (*ParseResult, error) {
 			if len(args) == 0 {
 				return nil, fmt.Errorf("line %d: TRACK requires at least one value", node.StartLine)
 			}
-			config.Tracks = append(config.Tracks, args...)
+			for _, manager := range args {
+				manager = strings.ToLower(manager)
+				if err := validateTrackManager(manager); err != nil {
+					return nil, fmt.Errorf("line %d: %w", node.StartLine, err)
+				}
+				config.Tracks = append(config.Tracks, manager)
+			}
 
 		case "act":
 			// Included for forward compatibility with worker mode semantics.
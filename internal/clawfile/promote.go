@@ -0,0 +1,139 @@
+package clawfile
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PromotedBlockMarker starts the comment above a block written by claw bake.
+const PromotedBlockMarker = "promoted by claw bake"
+
+// InsertSteps splices Dockerfile instructions into a Clawfile source right
+// after the last Docker instruction of the final stage, which is where Emit
+// places the generated LABEL block. A block from an earlier promotion, found by
+// its PromotedBlockMarker comment, is replaced rather than appended to, so a
+// recipe with changed pins supersedes the old one. Instructions already present
+// verbatim elsewhere are skipped. comment, when set, is written as a "# " line
+// above the inserted block. The returned count is the number of instructions
+// that were not already in the Clawfile.
+func InsertSteps(source []byte, steps []string, comment string) ([]byte, int, error) {
+	original := source
+	source, previous := removePromotedBlocks(source)
+	parsed, err := Parse(bytes.NewReader(source))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(parsed.DockerNodes) == 0 {
+		return nil, 0, fmt.Errorf("clawfile has no Docker instructions")
+	}
+
+	existing := make(map[string]struct{}, len(parsed.DockerNodes))
+	for _, node := range parsed.DockerNodes {
+		existing[normalizeInstruction(node.Original)] = struct{}{}
+	}
+	pending := make([]string, 0, len(steps))
+	for _, step := range steps {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		if strings.ContainsAny(step, "\r\n") {
+			return nil, 0, fmt.Errorf("step %q must be a single line", step)
+		}
+		if _, ok := existing[normalizeInstruction(step)]; ok {
+			continue
+		}
+		existing[normalizeInstruction(step)] = struct{}{}
+		pending = append(pending, step)
+	}
+	inserted := 0
+	for _, step := range pending {
+		if _, ok := previous[normalizeInstruction(step)]; !ok {
+			inserted++
+		}
+	}
+	if len(pending) == 0 {
+		if len(previous) == 0 {
+			return original, 0, nil
+		}
+		return source, 0, nil
+	}
+
+	block := make([]string, 0, len(pending)+3)
+	if comment != "" {
+		block = append(block, "# "+comment)
+	}
+	user := finalStageUser(parsed)
+	if user != "" {
+		block = append(block, "USER root")
+	}
+	block = append(block, pending...)
+	if user != "" {
+		block = append(block, "USER "+user)
+	}
+
+	last := parsed.DockerNodes[len(parsed.DockerNodes)-1]
+	lines := strings.SplitAfter(string(source), "\n")
+	insertAt := last.EndLine
+	if insertAt > len(lines) {
+		insertAt = len(lines)
+	}
+
+	var b strings.Builder
+	for _, line := range lines[:insertAt] {
+		b.WriteString(line)
+	}
+	if insertAt > 0 && !strings.HasSuffix(lines[insertAt-1], "\n") {
+		b.WriteString("\n")
+	}
+	for _, line := range block {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for _, line := range lines[insertAt:] {
+		b.WriteString(line)
+	}
+	if b.String() == string(original) {
+		return original, 0, nil
+	}
+	return []byte(b.String()), inserted, nil
+}
+
+// removePromotedBlocks strips every block written by an earlier promotion: the
+// marker comment, an optional USER root, the RUN steps, and the USER line that
+// restores the final stage user. It returns the remaining source and the
+// removed RUN steps.
+func removePromotedBlocks(source []byte) ([]byte, map[string]struct{}) {
+	removed := make(map[string]struct{})
+	lines := strings.SplitAfter(string(source), "\n")
+	var b strings.Builder
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(strings.TrimSpace(lines[i]), "# "+PromotedBlockMarker) {
+			b.WriteString(lines[i])
+			continue
+		}
+		j := i + 1
+		switchedToRoot := j < len(lines) && isInstruction(lines[j], "USER") && strings.EqualFold(normalizeInstruction(lines[j]), "USER root")
+		if switchedToRoot {
+			j++
+		}
+		for ; j < len(lines) && isInstruction(lines[j], "RUN"); j++ {
+			removed[normalizeInstruction(lines[j])] = struct{}{}
+		}
+		if switchedToRoot && j < len(lines) && isInstruction(lines[j], "USER") {
+			j++
+		}
+		i = j - 1
+	}
+	return []byte(b.String()), removed
+}
+
+func isInstruction(line, keyword string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 1 && strings.EqualFold(fields[0], keyword)
+}
+
+func normalizeInstruction(instruction string) string {
+	return strings.Join(strings.Fields(instruction), " ")
+}
@@ -0,0 +1,120 @@
+package clawfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInsertStepsAfterFinalStageInstructions(t *testing.T) {
+	source := `FROM python:3.12-slim
+CLAW_TYPE generic
+AGENT AGENTS.md
+RUN pip install --no-cache-dir requests
+TRACK pip
+CMD ["python", "-m", "agent"]
+MODEL primary openrouter/anthropic/claude-sonnet-4
+`
+	out, n, err := InsertSteps([]byte(source), []string{"RUN pip install --no-cache-dir tiktoken==0.7.0"}, "promoted from recipe 20260302T101500Z.json")
+	if err != nil {
+		t.Fatalf("InsertSteps: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 inserted step, got %d", n)
+	}
+	want := `FROM python:3.12-slim
+CLAW_TYPE generic
+AGENT AGENTS.md
+RUN pip install --no-cache-dir requests
+TRACK pip
+CMD ["python", "-m", "agent"]
+# promoted from recipe 20260302T101500Z.json
+RUN pip install --no-cache-dir tiktoken==0.7.0
+MODEL primary openrouter/anthropic/claude-sonnet-4
+`
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	emitted, err := Emit(mustParse(t, string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Index(emitted, "tiktoken==0.7.0") > strings.Index(emitted, "LABEL claw.type") {
+		t.Fatalf("expected promoted step before generated LABEL block:\n%s", emitted)
+	}
+}
+
+func TestInsertStepsIsIdempotentAndRestoresUser(t *testing.T) {
+	source := "FROM node:22-slim\nUSER node\nCLAW_TYPE generic\nRUN npm install -g   @scope/cli@2.1.0"
+	out, n, err := InsertSteps([]byte(source), []string{
+		"RUN npm install -g @scope/cli@2.1.0",
+		"RUN apt-get update && apt-get install -y --no-install-recommends jq=1.6-2.1 && rm -rf /var/lib/apt/lists/*",
+	}, "")
+	if err != nil {
+		t.Fatalf("InsertSteps: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected only the new step inserted, got %d", n)
+	}
+	if !strings.HasSuffix(string(out), "@2.1.0\nUSER root\nRUN apt-get update && apt-get install -y --no-install-recommends jq=1.6-2.1 && rm -rf /var/lib/apt/lists/*\nUSER node\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	again, n, err := InsertSteps(out, []string{"RUN apt-get update && apt-get install -y --no-install-recommends jq=1.6-2.1 && rm -rf /var/lib/apt/lists/*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || string(again) != string(out) {
+		t.Fatalf("expected no-op on repeated promotion, got %d inserted:\n%s", n, again)
+	}
+}
+
+func TestInsertStepsReplacesEarlierPromotedBlock(t *testing.T) {
+	source := "FROM node:22-slim\nUSER node\nCLAW_TYPE generic\nCMD [\"node\"]\nMODEL primary openai/gpt-4o\n"
+	comment := PromotedBlockMarker + " from 20260301T000000Z.json"
+	first, n, err := InsertSteps([]byte(source), []string{
+		"RUN npm install -g @scope/cli@2.1.0",
+		"RUN pip install requests==2.31.0",
+	}, comment)
+	if err != nil || n != 2 {
+		t.Fatalf("first promotion: n=%d err=%v", n, err)
+	}
+
+	second, n, err := InsertSteps(first, []string{
+		"RUN npm install -g @scope/cli@2.2.0",
+		"RUN pip install requests==2.31.0",
+	}, PromotedBlockMarker+" from 20260302T000000Z.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected only the re-pinned step counted, got %d", n)
+	}
+	want := "FROM node:22-slim\nUSER node\nCLAW_TYPE generic\nCMD [\"node\"]\n" +
+		"# " + PromotedBlockMarker + " from 20260302T000000Z.json\n" +
+		"USER root\nRUN npm install -g @scope/cli@2.2.0\nRUN pip install requests==2.31.0\nUSER node\n" +
+		"MODEL primary openai/gpt-4o\n"
+	if string(second) != want {
+		t.Fatalf("expected the earlier block replaced, got:\n%s", second)
+	}
+
+	again, n, err := InsertSteps(second, []string{
+		"RUN npm install -g @scope/cli@2.2.0",
+		"RUN pip install requests==2.31.0",
+	}, PromotedBlockMarker+" from 20260302T000000Z.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || string(again) != string(second) {
+		t.Fatalf("expected no-op on repeated promotion, got %d inserted:\n%s", n, again)
+	}
+}
+
+func mustParse(t *testing.T, source string) *ParseResult {
+	t.Helper()
+	parsed, err := Parse(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
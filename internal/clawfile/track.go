@@ -0,0 +1,192 @@
+package clawfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Well-known paths for TRACK mutation logging. The log directory is mounted
+// from the service runtime dir by claw up so records survive restarts.
+const (
+	TrackLogDir  = "/claw/track"
+	TrackLogPath = TrackLogDir + "/mutations.jsonl"
+	trackLibDir  = "/usr/local/lib/claw/track"
+	trackBinDir  = trackLibDir + "/bin"
+	trackLogger  = trackLibDir + "/claw-track-log"
+)
+
+// trackCommands maps a TRACK package manager to the binaries it wraps.
+var trackCommands = map[string][]string{
+	"apt": {"apt", "apt-get"},
+	"apk": {"apk"},
+	"npm": {"npm"},
+	"pip": {"pip", "pip3"},
+}
+
+// SupportedTrackManagers returns the package managers TRACK can wrap.
+func SupportedTrackManagers() []string {
+	out := make([]string, 0, len(trackCommands))
+	for manager := range trackCommands {
+		out = append(out, manager)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func validateTrackManager(manager string) error {
+	if _, ok := trackCommands[manager]; !ok {
+		return fmt.Errorf("TRACK: unsupported package manager %q (supported: %s)", manager, strings.Join(SupportedTrackManagers(), ", "))
+	}
+	return nil
+}
+
+// trackLoggerScript appends one JSONL mutation record per mutating package
+// manager invocation:
+//
+//	{"timestamp","manager","command","action","args","exit_code","versions"}
+//
+// Usage: claw-track-log <manager> <real-binary> <exit-code> [args...]
+const trackLoggerScript = `#!/bin/sh
+log="${CLAW_TRACK_LOG:-` + TrackLogPath + `}"
+manager="$1"; real="$2"; status="$3"
+shift 3
+
+esc() { printf '%s' "$1" | sed -e 's/\\/\\\\/g' -e 's/"/\\"/g' | tr -d '\000-\037'; }
+
+action=
+skip=
+for a in "$@"; do
+	case "$a" in -*) continue ;; esac
+	action="$a"
+	break
+done
+case "$manager:$action" in
+	apt:install|apt:reinstall|apt:remove|apt:purge|apt:autoremove|apt:upgrade|apt:dist-upgrade|apt:full-upgrade) ;;
+	pip:install|pip:uninstall) ;;
+	npm:install|npm:i|npm:add|npm:update|npm:up|npm:uninstall|npm:remove|npm:rm|npm:un) ;;
+	apk:add|apk:del|apk:upgrade) ;;
+	*) exit 0 ;;
+esac
+
+global=
+pkgs=
+seen_action=
+for a in "$@"; do
+	if [ -n "$skip" ]; then skip=; continue; fi
+	case "$a" in
+		-g|--global) global=-g; continue ;;
+		-r|--requirement|-c|--constraint|-i|--index-url|--extra-index-url|-t|--target|-o|--option|--prefix|-X|--repository) skip=1; continue ;;
+		-*) continue ;;
+	esac
+	if [ -z "$seen_action" ]; then seen_action=1; continue; fi
+	case "$manager" in
+		pip) name=$(printf '%s' "$a" | sed -e 's/[<>=!~;[ ].*//') ;;
+		apt) name=$(printf '%s' "$a" | sed -e 's/[=/].*//') ;;
+		apk) name=$(printf '%s' "$a" | sed -e 's/[<>=~].*//') ;;
+		npm) case "$a" in
+			@*) name=$(printf '%s' "$a" | sed -e 's/^\(@[^@]*\)@.*/\1/') ;;
+			*) name=$(printf '%s' "$a" | sed -e 's/@.*//') ;;
+		esac ;;
+	esac
+	[ -n "$name" ] && pkgs="$pkgs $name"
+done
+
+args=
+for a in "$@"; do
+	args="$args${args:+,}\"$(esc "$a")\""
+done
+
+versions=
+if [ "$status" = "0" ]; then
+	for p in $pkgs; do
+		v=
+		case "$manager" in
+			apt) v=$(dpkg-query -W -f='${Version}' "$p" 2>/dev/null) ;;
+			pip) v=$("$real" show "$p" 2>/dev/null | sed -n 's/^Version: //p' | head -n 1) ;;
+			npm) v=$("$real" ls $global --depth=0 "$p" 2>/dev/null | grep -o "$p@[^ ]*" | head -n 1 | sed 's/.*@//') ;;
+			apk) v=$("$real" info -v "$p" 2>/dev/null | head -n 1 | sed "s/^$p-//") ;;
+		esac
+		versions="$versions${versions:+,}\"$(esc "$p")\":\"$(esc "$v")\""
+	done
+fi
+
+ts=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+mkdir -p "$(dirname "$log")" 2>/dev/null
+printf '{"timestamp":"%s","manager":"%s","command":"%s","action":"%s","args":[%s],"exit_code":%s,"versions":{%s}}\n' \
+	"$ts" "$(esc "$manager")" "$(esc "$(basename "$real")")" "$(esc "$action")" "$args" "$status" "$versions" >> "$log" 2>/dev/null
+exit 0
+`
+
+// trackShimScript returns the wrapper installed ahead of the real binary on
+// PATH. It resolves the real binary by skipping its own directory.
+func trackShimScript(manager, command string) string {
+	return `#!/bin/sh
+real=
+old_ifs=$IFS
+IFS=:
+for dir in $PATH; do
+	[ "$dir" = "` + trackBinDir + `" ] && continue
+	if [ -x "$dir/` + command + `" ]; then real="$dir/` + command + `"; break; fi
+done
+IFS=$old_ifs
+if [ -z "$real" ]; then
+	echo "claw-track: ` + command + ` not found in PATH" >&2
+	exit 127
+fi
+"$real" "$@"
+status=$?
+` + trackLogger + ` ` + manager + ` "$real" "$status" "$@" || true
+exit $status
+`
+}
+
+// buildTrackLines emits Dockerfile instructions that install TRACK wrappers.
+// finalUser is the last USER of the final stage; installation runs as root and
+// the original user is restored afterwards.
+func buildTrackLines(tracks []string, finalUser string) []string {
+	managers := make([]string, 0, len(tracks))
+	seen := make(map[string]struct{}, len(tracks))
+	for _, manager := range tracks {
+		if _, ok := seen[manager]; ok {
+			continue
+		}
+		if _, ok := trackCommands[manager]; !ok {
+			continue
+		}
+		seen[manager] = struct{}{}
+		managers = append(managers, manager)
+	}
+	if len(managers) == 0 {
+		return nil
+	}
+
+	// The shims install under /usr/local, which needs root whatever user the
+	// image runs as; the effective user is restored afterwards.
+	lines := []string{"USER root"}
+	lines = append(lines, fmt.Sprintf("RUN mkdir -p %s && %s", trackBinDir, writeExecutable(trackLogger, trackLoggerScript)))
+	for _, manager := range managers {
+		for _, command := range trackCommands[manager] {
+			path := trackBinDir + "/" + command
+			lines = append(lines, "RUN "+writeExecutable(path, trackShimScript(manager, command)))
+		}
+	}
+	lines = append(lines, fmt.Sprintf("ENV PATH=\"%s:${PATH}\" CLAW_TRACK_LOG=\"%s\"", trackBinDir, TrackLogPath))
+	if finalUser != "" {
+		lines = append(lines, "USER "+finalUser)
+	}
+	return lines
+}
+
+// writeExecutable renders a single-line shell command that writes content to
+// path with printf and marks it executable.
+func writeExecutable(path, content string) string {
+	escaped := strings.NewReplacer(
+		`\`, `\\`,
+		`%`, `%%`,
+		"\n", `\n`,
+		"\t", `\t`,
+		`'`, `'\''`,
+	).Replace(content)
+	return fmt.Sprintf("printf '%s' > %s && chmod 0755 %s", escaped, path, path)
+}
@@ -0,0 +1,192 @@
+package clawfile
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRejectsUnsupportedTrackManager(t *testing.T) {
+	_, err := Parse(strings.NewReader("FROM alpine\nCLAW_TYPE openclaw\nTRACK apt cargo\n"))
+	if err == nil || !strings.Contains(err.Error(), `unsupported package manager "cargo"`) {
+		t.Fatalf("expected unsupported package manager error, got %v", err)
+	}
+}
+
+func TestEmitTrackInstallsWrappers(t *testing.T) {
+	parsed, err := Parse(strings.NewReader("FROM python:3.12-slim\nCLAW_TYPE openclaw\nTRACK apt pip\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := Emit(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"> /usr/local/lib/claw/track/claw-track-log && chmod 0755 /usr/local/lib/claw/track/claw-track-log",
+		"> /usr/local/lib/claw/track/bin/apt && chmod 0755",
+		"> /usr/local/lib/claw/track/bin/apt-get && chmod 0755",
+		"> /usr/local/lib/claw/track/bin/pip && chmod 0755",
+		"> /usr/local/lib/claw/track/bin/pip3 && chmod 0755",
+		`ENV PATH="/usr/local/lib/claw/track/bin:${PATH}" CLAW_TRACK_LOG="/claw/track/mutations.jsonl"`,
+		`LABEL claw.track.1="pip"`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "/bin/npm") {
+		t.Error("did not expect npm wrapper without TRACK npm")
+	}
+	if !strings.Contains(output, "USER root") {
+		t.Error("expected wrapper install to switch to root")
+	}
+	if strings.Count(output, "USER ") != 1 {
+		t.Errorf("did not expect a user restore when the final stage runs as root:\n%s", output)
+	}
+}
+
+func TestEmitTrackRestoresInheritedBaseImageUser(t *testing.T) {
+	parsed, err := Parse(strings.NewReader("FROM node:22-slim\nCLAW_TYPE nullclaw\nTRACK npm\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed.BaseImageUser = "node"
+	output, err := Emit(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootIdx := strings.Index(output, "USER root")
+	restoreIdx := strings.LastIndex(output, "USER node")
+	if rootIdx < 0 || restoreIdx < rootIdx {
+		t.Fatalf("expected inherited user restored after wrapper install:\n%s", output)
+	}
+}
+
+func TestEmitTrackFollowsStageAliasUser(t *testing.T) {
+	input := "FROM alpine AS base\nUSER app\nFROM base\nCLAW_TYPE nullclaw\nTRACK apk\n"
+	parsed, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed.BaseImageUser = "ignored"
+	if got := FinalStageBaseImage(parsed); got != "alpine" {
+		t.Fatalf("expected alias to resolve to alpine, got %q", got)
+	}
+	output, err := Emit(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.LastIndex(output, "USER app") < strings.Index(output, "USER root") {
+		t.Fatalf("expected alias user restored:\n%s", output)
+	}
+	if strings.Contains(output, "USER ignored") {
+		t.Fatalf("expected stage user to win over base image user:\n%s", output)
+	}
+}
+
+func TestEmitTrackRestoresFinalStageUser(t *testing.T) {
+	input := "FROM node:22 AS build\nUSER builder\nFROM node:22-slim\nUSER node\nCLAW_TYPE nullclaw\nTRACK npm\n"
+	parsed, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := Emit(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootIdx := strings.Index(output, "USER root")
+	runIdx := strings.Index(output, "RUN mkdir -p /usr/local/lib/claw/track/bin")
+	restoreIdx := strings.LastIndex(output, "USER node")
+	if rootIdx < 0 || runIdx < rootIdx || restoreIdx < runIdx {
+		t.Fatalf("expected USER root ... RUN ... USER node ordering:\n%s", output)
+	}
+}
+
+func TestWriteExecutableRoundTripsScript(t *testing.T) {
+	shell := lookPathOrSkip(t, "sh")
+	target := filepath.Join(t.TempDir(), "claw-track-log")
+
+	cmd := exec.Command(shell, "-c", writeExecutable(target, trackLoggerScript))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("run printf command: %v\n%s", err, out)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != trackLoggerScript {
+		t.Fatalf("script did not round-trip through printf:\n%s", got)
+	}
+}
+
+func TestTrackLoggerWritesMutationRecord(t *testing.T) {
+	shell := lookPathOrSkip(t, "sh")
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "track", "mutations.jsonl")
+	logger := filepath.Join(dir, "claw-track-log")
+	if err := os.WriteFile(logger, []byte(trackLoggerScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fakePip := filepath.Join(dir, "pip")
+	if err := os.WriteFile(fakePip, []byte("#!/bin/sh\n[ \"$1\" = show ] && echo \"Name: $2\" && echo 'Version: 2.31.0'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) {
+		cmd := exec.Command(shell, append([]string{logger}, args...)...)
+		cmd.Env = append(os.Environ(), "CLAW_TRACK_LOG="+logPath)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("run logger: %v\n%s", err, out)
+		}
+	}
+	run("pip", fakePip, "0", "install", "--quiet", "requests==2.31.0")
+	run("pip", fakePip, "0", "list")
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one mutation record (non-mutating list skipped), got %d:\n%s", len(lines), data)
+	}
+
+	var rec struct {
+		Timestamp string            `json:"timestamp"`
+		Manager   string            `json:"manager"`
+		Command   string            `json:"command"`
+		Action    string            `json:"action"`
+		Args      []string          `json:"args"`
+		ExitCode  int               `json:"exit_code"`
+		Versions  map[string]string `json:"versions"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("decode record %q: %v", lines[0], err)
+	}
+	if rec.Manager != "pip" || rec.Command != "pip" || rec.Action != "install" || rec.ExitCode != 0 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if strings.Join(rec.Args, " ") != "install --quiet requests==2.31.0" {
+		t.Fatalf("unexpected args: %v", rec.Args)
+	}
+	if rec.Versions["requests"] != "2.31.0" {
+		t.Fatalf("expected resolved version for requests, got %v", rec.Versions)
+	}
+	if rec.Timestamp == "" {
+		t.Fatal("expected timestamp")
+	}
+}
+
+func lookPathOrSkip(t *testing.T, name string) string {
+	t.Helper()
+	path, err := exec.LookPath(name)
+	if err != nil {
+		t.Skipf("%s not available: %v", name, err)
+	}
+	return path
+}
@@ -0,0 +1,71 @@
+package cllama
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hop is one proxy service in a pod's cllama topology.
+type Hop struct {
+	ProxyType string
+	Next      string // next proxy type in the chain; "" when this hop calls the provider
+}
+
+// PlanChains merges the ordered proxy chains of every agent (agent -> proxy
+// types, head first) into one hop per proxy type, sorted by type. Each proxy
+// is a single shared service, so a type must forward to the same next hop in
+// every chain it appears in, and no chain may visit a type twice. Together
+// those rules rule out cycles: following Next always walks the tail of a
+// declared chain.
+func PlanChains(chains map[string][]string) ([]Hop, error) {
+	agents := make([]string, 0, len(chains))
+	for agent := range chains {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+
+	next := make(map[string]string)
+	owner := make(map[string]string) // proxy type -> agent whose chain fixed its next hop
+	for _, agent := range agents {
+		chain := make([]string, 0, len(chains[agent]))
+		for _, raw := range chains[agent] {
+			if strings.TrimSpace(raw) == "" {
+				continue
+			}
+			chain = append(chain, ProxyType(raw))
+		}
+		seen := make(map[string]struct{}, len(chain))
+		for i, proxyType := range chain {
+			if _, dup := seen[proxyType]; dup {
+				return nil, fmt.Errorf("service %q: cllama chain %s visits %q twice", agent, strings.Join(chain, " -> "), proxyType)
+			}
+			seen[proxyType] = struct{}{}
+
+			successor := ""
+			if i+1 < len(chain) {
+				successor = chain[i+1]
+			}
+			if prev, ok := next[proxyType]; ok && prev != successor {
+				return nil, fmt.Errorf("service %q: cllama proxy %q forwards to %s, but service %q's chain has it forward to %s",
+					agent, proxyType, describeNext(successor), owner[proxyType], describeNext(prev))
+			}
+			next[proxyType] = successor
+			owner[proxyType] = agent
+		}
+	}
+
+	hops := make([]Hop, 0, len(next))
+	for proxyType, successor := range next {
+		hops = append(hops, Hop{ProxyType: proxyType, Next: successor})
+	}
+	sort.Slice(hops, func(i, j int) bool { return hops[i].ProxyType < hops[j].ProxyType })
+	return hops, nil
+}
+
+func describeNext(proxyType string) string {
+	if proxyType == "" {
+		return "the provider"
+	}
+	return fmt.Sprintf("%q", proxyType)
+}
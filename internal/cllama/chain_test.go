@@ -0,0 +1,55 @@
+package cllama
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanChainsSingleProxy(t *testing.T) {
+	hops, err := PlanChains(map[string][]string{
+		"bot-a": {"passthrough"},
+		"bot-b": {"passthrough"},
+	})
+	if err != nil {
+		t.Fatalf("PlanChains: %v", err)
+	}
+	if want := []Hop{{ProxyType: "passthrough"}}; !reflect.DeepEqual(hops, want) {
+		t.Fatalf("got %+v, want %+v", hops, want)
+	}
+}
+
+func TestPlanChainsOrderedChain(t *testing.T) {
+	hops, err := PlanChains(map[string][]string{
+		"trader":   {"Policy", "passthrough"},
+		"analyst":  {"policy", "passthrough"},
+		"research": {"passthrough"},
+	})
+	if err != nil {
+		t.Fatalf("PlanChains: %v", err)
+	}
+	want := []Hop{
+		{ProxyType: "passthrough"},
+		{ProxyType: "policy", Next: "passthrough"},
+	}
+	if !reflect.DeepEqual(hops, want) {
+		t.Fatalf("got %+v, want %+v", hops, want)
+	}
+}
+
+func TestPlanChainsRejectsConflictingNextHop(t *testing.T) {
+	_, err := PlanChains(map[string][]string{
+		"bot-a": {"passthrough"},
+		"bot-b": {"passthrough", "policy"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `"passthrough" forwards to "policy"`) {
+		t.Fatalf("expected conflicting next hop error, got %v", err)
+	}
+}
+
+func TestPlanChainsRejectsRepeatedType(t *testing.T) {
+	_, err := PlanChains(map[string][]string{"bot": {"policy", "passthrough", "policy"}})
+	if err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Fatalf("expected repeated type error, got %v", err)
+	}
+}
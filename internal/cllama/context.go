@@ -1,6 +1,8 @@
 package cllama
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -12,17 +14,56 @@ type AgentContextInput struct {
 	AgentsMD    string
 	ClawdapusMD string
 	Metadata    map[string]interface{}
+	Policy      *Policy   // written as policy.json when set
+	Contract    *Contract // written as contract.json when set
+
+	// Credentials are provider keys scoped to this agent, written as
+	// owner-only credentials.json so the proxy can pick a key by caller.
+	Credentials map[string]string
+}
+
+// Contract is the structured form of an agent's contract includes, so proxies
+// can find enforce rules without parsing the BEGIN/END markers in AGENTS.md.
+type Contract struct {
+	Includes []ContractInclude `json:"includes"`
+}
+
+// ContractInclude is one x-claw.include entry with its resolved text.
+type ContractInclude struct {
+	ID          string `json:"id"`
+	Mode        string `json:"mode"`
+	Description string `json:"description,omitempty"`
+	SHA256      string `json:"sha256"`
+	Text        string `json:"text"`
+}
+
+// NewContractInclude builds a ContractInclude, hashing the raw file content.
+func NewContractInclude(id, mode, description string, content []byte) ContractInclude {
+	sum := sha256.Sum256(content)
+	return ContractInclude{
+		ID:          id,
+		Mode:        mode,
+		Description: description,
+		SHA256:      hex.EncodeToString(sum[:]),
+		Text:        string(content),
+	}
+}
+
+// ProxyContextDir returns the host context directory of one proxy service:
+// <runtimeDir>/context/<proxy-service>. Each proxy mounts only its own.
+func ProxyContextDir(runtimeDir, proxyType string) string {
+	return filepath.Join(runtimeDir, "context", ProxyServiceName(proxyType))
 }
 
 // GenerateContextDir writes per-agent context files under:
 //
-//	<runtimeDir>/context/<agent-id>/{AGENTS.md,CLAWDAPUS.md,metadata.json}
-func GenerateContextDir(runtimeDir string, agents []AgentContextInput) error {
+//	<contextDir>/<agent-id>/{AGENTS.md,CLAWDAPUS.md,metadata.json[,policy.json][,contract.json][,credentials.json]}
+func GenerateContextDir(contextDir string, agents []AgentContextInput) error {
 	for _, agent := range agents {
 		if agent.AgentID == "" {
 			return fmt.Errorf("agent id must not be empty")
 		}
-		agentDir := filepath.Join(runtimeDir, "context", agent.AgentID)
+		agentDir := filepath.Join(contextDir, agent.AgentID)
 		if err := os.MkdirAll(agentDir, 0700); err != nil {
 			return fmt.Errorf("create context dir for %q: %w", agent.AgentID, err)
 		}
@@ -44,6 +85,36 @@ func GenerateContextDir(runtimeDir string, agents []AgentContextInput) error {
 		if err := os.WriteFile(filepath.Join(agentDir, "metadata.json"), metaJSON, 0644); err != nil {
 			return fmt.Errorf("write metadata.json for %q: %w", agent.AgentID, err)
 		}
+
+		if agent.Policy != nil {
+			policyJSON, err := json.MarshalIndent(agent.Policy, "", "  ")
+			if err != nil {
+				return fmt.Errorf("marshal policy for %q: %w", agent.AgentID, err)
+			}
+			if err := os.WriteFile(filepath.Join(agentDir, "policy.json"), policyJSON, 0644); err != nil {
+				return fmt.Errorf("write policy.json for %q: %w", agent.AgentID, err)
+			}
+		}
+
+		if agent.Contract != nil {
+			contractJSON, err := json.MarshalIndent(agent.Contract, "", "  ")
+			if err != nil {
+				return fmt.Errorf("marshal contract for %q: %w", agent.AgentID, err)
+			}
+			if err := os.WriteFile(filepath.Join(agentDir, "contract.json"), contractJSON, 0644); err != nil {
+				return fmt.Errorf("write contract.json for %q: %w", agent.AgentID, err)
+			}
+		}
+
+		if len(agent.Credentials) > 0 {
+			credsJSON, err := json.MarshalIndent(agent.Credentials, "", "  ")
+			if err != nil {
+				return fmt.Errorf("marshal credentials for %q: %w", agent.AgentID, err)
+			}
+			if err := os.WriteFile(filepath.Join(agentDir, "credentials.json"), credsJSON, 0600); err != nil {
+				return fmt.Errorf("write credentials.json for %q: %w", agent.AgentID, err)
+			}
+		}
 	}
 
 	return nil
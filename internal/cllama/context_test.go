@@ -22,7 +22,7 @@ func TestGenerateContextDirWritesFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	agentsMD, err := os.ReadFile(filepath.Join(dir, "context", "tiverton", "AGENTS.md"))
+	agentsMD, err := os.ReadFile(filepath.Join(dir, "tiverton", "AGENTS.md"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -30,7 +30,7 @@ func TestGenerateContextDirWritesFiles(t *testing.T) {
 		t.Errorf("wrong AGENTS.md: %q", agentsMD)
 	}
 
-	clawdapusMD, err := os.ReadFile(filepath.Join(dir, "context", "tiverton", "CLAWDAPUS.md"))
+	clawdapusMD, err := os.ReadFile(filepath.Join(dir, "tiverton", "CLAWDAPUS.md"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -38,7 +38,7 @@ func TestGenerateContextDirWritesFiles(t *testing.T) {
 		t.Errorf("wrong CLAWDAPUS.md: %q", clawdapusMD)
 	}
 
-	metaRaw, err := os.ReadFile(filepath.Join(dir, "context", "tiverton", "metadata.json"))
+	metaRaw, err := os.ReadFile(filepath.Join(dir, "tiverton", "metadata.json"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -71,10 +71,113 @@ func TestGenerateContextDirMultipleAgents(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if _, err := os.Stat(filepath.Join(dir, "context", "bot-a", "AGENTS.md")); err != nil {
+	if _, err := os.Stat(filepath.Join(dir, "bot-a", "AGENTS.md")); err != nil {
 		t.Errorf("bot-a missing: %v", err)
 	}
-	if _, err := os.Stat(filepath.Join(dir, "context", "bot-b", "AGENTS.md")); err != nil {
+	if _, err := os.Stat(filepath.Join(dir, "bot-b", "AGENTS.md")); err != nil {
 		t.Errorf("bot-b missing: %v", err)
 	}
 }
+
+func TestProxyContextDirIsPerProxyService(t *testing.T) {
+	if got := ProxyContextDir("/rt", "passthrough"); got != filepath.Join("/rt", "context", "cllama") {
+		t.Errorf("unexpected passthrough context dir %q", got)
+	}
+	if got := ProxyContextDir("/rt", "policy"); got != filepath.Join("/rt", "context", "cllama-policy") {
+		t.Errorf("unexpected policy context dir %q", got)
+	}
+}
+
+func TestGenerateContextDirWritesPolicy(t *testing.T) {
+	dir := t.TempDir()
+	agents := []AgentContextInput{
+		{AgentID: "trader", Policy: &Policy{DailyBudgetUSD: 2.5, AllowedModels: []string{"anthropic/*"}}},
+		{AgentID: "analyst"},
+	}
+	if err := GenerateContextDir(dir, agents); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "trader", "policy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var policy map[string]interface{}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy["dailyBudgetUsd"] != 2.5 {
+		t.Errorf("wrong policy: %v", policy)
+	}
+	if _, ok := policy["requestsPerMinute"]; ok {
+		t.Errorf("expected unset limits to be omitted: %v", policy)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "analyst", "policy.json")); !os.IsNotExist(err) {
+		t.Errorf("expected no policy.json without a policy, got err=%v", err)
+	}
+}
+
+func TestGenerateContextDirWritesContract(t *testing.T) {
+	dir := t.TempDir()
+	contract := &Contract{Includes: []ContractInclude{
+		NewContractInclude("risk-limits", "enforce", "Hard risk limits", []byte("Never exceed 2% per trade.\n")),
+	}}
+	if err := GenerateContextDir(dir, []AgentContextInput{{AgentID: "trader", Contract: contract}}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "trader", "contract.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Contract
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Includes) != 1 {
+		t.Fatalf("expected 1 include, got %+v", decoded)
+	}
+	got := decoded.Includes[0]
+	if got.ID != "risk-limits" || got.Mode != "enforce" || got.Description != "Hard risk limits" {
+		t.Errorf("wrong include: %+v", got)
+	}
+	if got.SHA256 != "3d4830d94af7f2cd27ea9b8cdc2b193ccb9a403c6069704d2954786d70afc6a3" || got.Text != "Never exceed 2% per trade.\n" {
+		t.Errorf("wrong hash or text: %+v", got)
+	}
+}
+
+func TestGenerateContextDirWritesOwnerOnlyCredentials(t *testing.T) {
+	dir := t.TempDir()
+	agents := []AgentContextInput{
+		{AgentID: "desk-a", Credentials: map[string]string{"OPENROUTER_API_KEY": "key-a"}},
+		{AgentID: "desk-b"},
+	}
+	if err := GenerateContextDir(dir, agents); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "desk-a", "credentials.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected 0600 credentials.json, got %o", info.Mode().Perm())
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var creds map[string]string
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		t.Fatal(err)
+	}
+	if creds["OPENROUTER_API_KEY"] != "key-a" {
+		t.Errorf("wrong credentials: %v", creds)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "desk-b", "credentials.json")); !os.IsNotExist(err) {
+		t.Errorf("expected no credentials.json without credentials, got err=%v", err)
+	}
+}
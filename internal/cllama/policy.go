@@ -0,0 +1,75 @@
+package cllama
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Policy holds the per-agent limits a cllama proxy is expected to enforce.
+// Zero values mean "no limit"; an empty AllowedModels allows every model.
+type Policy struct {
+	DailyBudgetUSD    float64  `json:"dailyBudgetUsd,omitempty"`
+	MonthlyBudgetUSD  float64  `json:"monthlyBudgetUsd,omitempty"`
+	RequestsPerMinute int      `json:"requestsPerMinute,omitempty"`
+	TokensPerDay      int      `json:"tokensPerDay,omitempty"`
+	AllowedModels     []string `json:"allowedModels,omitempty"`
+	MaxOutputTokens   int      `json:"maxOutputTokens,omitempty"`
+}
+
+// Merge returns defaults overlaid with the fields set in p. Either side may be
+// nil; the result is nil only when both are.
+func (p *Policy) Merge(defaults *Policy) *Policy {
+	if p == nil && defaults == nil {
+		return nil
+	}
+	out := Policy{}
+	if defaults != nil {
+		out = *defaults
+		out.AllowedModels = append([]string(nil), defaults.AllowedModels...)
+	}
+	if p == nil {
+		return &out
+	}
+	if p.DailyBudgetUSD != 0 {
+		out.DailyBudgetUSD = p.DailyBudgetUSD
+	}
+	if p.MonthlyBudgetUSD != 0 {
+		out.MonthlyBudgetUSD = p.MonthlyBudgetUSD
+	}
+	if p.RequestsPerMinute != 0 {
+		out.RequestsPerMinute = p.RequestsPerMinute
+	}
+	if p.TokensPerDay != 0 {
+		out.TokensPerDay = p.TokensPerDay
+	}
+	if len(p.AllowedModels) > 0 {
+		out.AllowedModels = append([]string(nil), p.AllowedModels...)
+	}
+	if p.MaxOutputTokens != 0 {
+		out.MaxOutputTokens = p.MaxOutputTokens
+	}
+	return &out
+}
+
+// AllowsModel reports whether model ("provider/model") matches the allowlist.
+// A "*" in an entry matches any run of characters, including "/", so
+// "openrouter/*" covers "openrouter/anthropic/claude-sonnet-4".
+func (p *Policy) AllowsModel(model string) bool {
+	if p == nil || len(p.AllowedModels) == 0 {
+		return true
+	}
+	for _, pattern := range p.AllowedModels {
+		if modelPattern(pattern).MatchString(model) {
+			return true
+		}
+	}
+	return false
+}
+
+func modelPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -0,0 +1,45 @@
+package cllama
+
+import "testing"
+
+func TestPolicyMergeOverlaysSetFields(t *testing.T) {
+	defaults := &Policy{DailyBudgetUSD: 5, RequestsPerMinute: 30, AllowedModels: []string{"anthropic/*"}}
+	svc := &Policy{DailyBudgetUSD: 1, MaxOutputTokens: 1024}
+
+	got := svc.Merge(defaults)
+	if got.DailyBudgetUSD != 1 || got.RequestsPerMinute != 30 || got.MaxOutputTokens != 1024 {
+		t.Fatalf("unexpected merge: %+v", got)
+	}
+	if len(got.AllowedModels) != 1 || got.AllowedModels[0] != "anthropic/*" {
+		t.Fatalf("expected default allowlist, got %v", got.AllowedModels)
+	}
+
+	var none *Policy
+	if none.Merge(nil) != nil {
+		t.Fatal("expected nil merge of two nil policies")
+	}
+}
+
+func TestPolicyAllowsModel(t *testing.T) {
+	p := &Policy{AllowedModels: []string{"anthropic/*", "openai/gpt-4o-mini"}}
+	cases := map[string]bool{
+		"anthropic/claude-sonnet-4":   true,
+		"openai/gpt-4o-mini":          true,
+		"openai/gpt-4o":               false,
+		"openrouter/anthropic/claude": false,
+	}
+	for model, want := range cases {
+		if got := p.AllowsModel(model); got != want {
+			t.Errorf("AllowsModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+
+	wide := &Policy{AllowedModels: []string{"openrouter/*"}}
+	if !wide.AllowsModel("openrouter/anthropic/claude-sonnet-4") {
+		t.Error("expected * to match across slashes")
+	}
+	var unset *Policy
+	if !unset.AllowsModel("anything/at-all") {
+		t.Error("expected nil policy to allow every model")
+	}
+}
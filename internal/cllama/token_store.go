@@ -0,0 +1,160 @@
+package cllama
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// TokenStoreFile is the pod-relative path of the persistent bearer token store.
+// It lives beside .claw-runtime rather than inside it, so tokens survive the
+// runtime reset at the start of every `claw up`.
+const TokenStoreFile = ".claw-tokens.json"
+
+const tokenStoreVersion = 1
+
+var tokenSecretPattern = regexp.MustCompile(`^[0-9a-f]{48}$`)
+
+// StoredToken is one persisted agent bearer token.
+type StoredToken struct {
+	Service   string    `json:"service"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TokenStore holds the cllama bearer tokens issued to a pod's agents, keyed
+// by agent ID (the expanded compose service name).
+type TokenStore struct {
+	Version int                    `json:"version"`
+	Pod     string                 `json:"pod"`
+	Tokens  map[string]StoredToken `json:"tokens"`
+
+	path          string
+	discardedFrom string
+}
+
+// TokenStorePath returns the token store location for a pod directory.
+func TokenStorePath(podDir string) string {
+	return filepath.Join(podDir, TokenStoreFile)
+}
+
+// LoadTokenStore reads the token store at path. A missing file yields an empty
+// store for podName. Tokens recorded for a different pod are discarded, and
+// DiscardedFrom reports that pod's name.
+func LoadTokenStore(path, podName string) (*TokenStore, error) {
+	store := &TokenStore{
+		Version: tokenStoreVersion,
+		Pod:     podName,
+		Tokens:  make(map[string]StoredToken),
+		path:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read token store %q: %w", path, err)
+	}
+
+	var loaded TokenStore
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("parse token store %q: %w", path, err)
+	}
+	if loaded.Version != tokenStoreVersion {
+		return nil, fmt.Errorf("token store %q: unsupported version %d", path, loaded.Version)
+	}
+	if loaded.Pod != podName {
+		if len(loaded.Tokens) > 0 {
+			store.discardedFrom = loaded.Pod
+		}
+		return store, nil
+	}
+	for agentID, tok := range loaded.Tokens {
+		store.Tokens[agentID] = tok
+	}
+	return store, nil
+}
+
+// DiscardedFrom returns the pod whose stored tokens were discarded on load
+// because the pod name changed, or "".
+func (s *TokenStore) DiscardedFrom() string {
+	return s.discardedFrom
+}
+
+// Token returns the stored token for agentID when it was issued to the same
+// service and is well formed; otherwise it issues and records a new one.
+func (s *TokenStore) Token(agentID, service string) string {
+	if existing, ok := s.Tokens[agentID]; ok && existing.Service == service && validToken(agentID, existing.Token) {
+		return existing.Token
+	}
+	s.Tokens[agentID] = StoredToken{
+		Service:   service,
+		Token:     GenerateToken(agentID),
+		CreatedAt: time.Now().UTC(),
+	}
+	return s.Tokens[agentID].Token
+}
+
+// Rotate discards the stored tokens of every agent belonging to one of the
+// given services and returns the affected agent IDs, sorted. The next Token
+// call for those agents issues fresh tokens.
+func (s *TokenStore) Rotate(services []string) []string {
+	want := make(map[string]struct{}, len(services))
+	for _, service := range services {
+		want[service] = struct{}{}
+	}
+
+	rotated := make([]string, 0)
+	for agentID, tok := range s.Tokens {
+		if _, ok := want[tok.Service]; !ok {
+			continue
+		}
+		delete(s.Tokens, agentID)
+		rotated = append(rotated, agentID)
+	}
+	sort.Strings(rotated)
+	return rotated
+}
+
+// Retain drops tokens for agents not in agentIDs, so removed or scaled-down
+// agents do not keep valid credentials around.
+func (s *TokenStore) Retain(agentIDs []string) {
+	keep := make(map[string]struct{}, len(agentIDs))
+	for _, id := range agentIDs {
+		keep[id] = struct{}{}
+	}
+	for agentID := range s.Tokens {
+		if _, ok := keep[agentID]; !ok {
+			delete(s.Tokens, agentID)
+		}
+	}
+}
+
+// Save writes the store back to its path with owner-only permissions.
+func (s *TokenStore) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal token store: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("write token store %q: %w", s.path, err)
+	}
+	// WriteFile keeps the mode of an existing file; tighten it explicitly.
+	if err := os.Chmod(s.path, 0o600); err != nil {
+		return fmt.Errorf("chmod token store %q: %w", s.path, err)
+	}
+	return nil
+}
+
+func validToken(agentID, token string) bool {
+	prefix := agentID + ":"
+	if len(token) <= len(prefix) || token[:len(prefix)] != prefix {
+		return false
+	}
+	return tokenSecretPattern.MatchString(token[len(prefix):])
+}
@@ -0,0 +1,104 @@
+package cllama
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenStoreReusesTokensAcrossLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TokenStoreFile)
+
+	store, err := LoadTokenStore(path, "desk")
+	if err != nil {
+		t.Fatalf("load empty store: %v", err)
+	}
+	first := store.Token("tiverton", "tiverton")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat store: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600 token store, got %o", info.Mode().Perm())
+	}
+
+	reloaded, err := LoadTokenStore(path, "desk")
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := reloaded.Token("tiverton", "tiverton"); got != first {
+		t.Fatalf("expected persisted token %q, got %q", first, got)
+	}
+}
+
+func TestTokenStoreReissuesOnIdentityChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TokenStoreFile)
+	store, _ := LoadTokenStore(path, "desk")
+	first := store.Token("bot-0", "bot")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// Same agent ID under a different service is a different identity.
+	store, _ = LoadTokenStore(path, "desk")
+	if got := store.Token("bot-0", "other"); got == first {
+		t.Fatal("expected new token when service changes")
+	}
+
+	// Tokens from another pod are never reused.
+	other, _ := LoadTokenStore(path, "other-pod")
+	if other.DiscardedFrom() != "desk" {
+		t.Fatalf("expected discarded pod reported, got %q", other.DiscardedFrom())
+	}
+	if got := other.Token("bot-0", "bot"); got == first {
+		t.Fatal("expected new token for a different pod")
+	}
+}
+
+func TestTokenStoreReissuesMalformedTokens(t *testing.T) {
+	store, _ := LoadTokenStore(filepath.Join(t.TempDir(), TokenStoreFile), "desk")
+	store.Tokens["bot"] = StoredToken{Service: "bot", Token: "other:deadbeef"}
+
+	got := store.Token("bot", "bot")
+	if !strings.HasPrefix(got, "bot:") || !validToken("bot", got) {
+		t.Fatalf("expected regenerated token, got %q", got)
+	}
+}
+
+func TestTokenStoreRotateAndRetain(t *testing.T) {
+	store, _ := LoadTokenStore(filepath.Join(t.TempDir(), TokenStoreFile), "desk")
+	a0 := store.Token("crusher-0", "crusher")
+	store.Token("crusher-1", "crusher")
+	analyst := store.Token("analyst", "analyst")
+
+	rotated := store.Rotate([]string{"crusher"})
+	if strings.Join(rotated, ",") != "crusher-0,crusher-1" {
+		t.Fatalf("unexpected rotated agents: %v", rotated)
+	}
+	if got := store.Token("crusher-0", "crusher"); got == a0 {
+		t.Fatal("expected rotated agent to receive a new token")
+	}
+	if got := store.Token("analyst", "analyst"); got != analyst {
+		t.Fatal("expected untouched agent to keep its token")
+	}
+
+	store.Retain([]string{"analyst"})
+	if len(store.Tokens) != 1 {
+		t.Fatalf("expected only analyst to remain, got %v", store.Tokens)
+	}
+}
+
+func TestLoadTokenStoreRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TokenStoreFile)
+	if err := os.WriteFile(path, []byte(`{"version":99,"pod":"desk","tokens":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTokenStore(path, "desk"); err == nil {
+		t.Fatal("expected version error")
+	}
+}
@@ -0,0 +1,151 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+)
+
+// Container paths used by the generic driver. Runtimes that follow these
+// conventions need no bespoke driver.
+const (
+	contractPath      = "/claw/AGENTS.md"
+	clawdapusPath     = "/claw/CLAWDAPUS.md"
+	runtimeConfigPath = "/claw/claw-runtime.json"
+	personaDir        = "/claw/persona"
+	skillsDir         = "/claw/skills"
+)
+
+// RuntimeConfig is the machine-readable description of a generic claw written
+// to claw-runtime.json. Any runtime can consume it without parsing env vars.
+type RuntimeConfig struct {
+	Version     int                           `json:"version"`
+	Pod         string                        `json:"pod"`
+	Service     string                        `json:"service"`
+	ClawType    string                        `json:"claw_type"`
+	Contract    string                        `json:"contract"`
+	Clawdapus   string                        `json:"clawdapus"`
+	PersonaDir  string                        `json:"persona_dir,omitempty"`
+	SkillsDir   string                        `json:"skills_dir"`
+	Skills      []string                      `json:"skills,omitempty"`
+	Models      map[string]string             `json:"models,omitempty"`
+	LLM         *RuntimeLLM                   `json:"llm,omitempty"`
+	Invocations []RuntimeInvocation           `json:"invocations"`
+	Handles     map[string]*driver.HandleInfo `json:"handles,omitempty"`
+	Surfaces    []RuntimeSurface              `json:"surfaces,omitempty"`
+	Configures  []string                      `json:"configures,omitempty"`
+}
+
+// RuntimeLLM describes how the runtime should reach its LLM provider.
+type RuntimeLLM struct {
+	BaseURL  string   `json:"base_url"`
+	TokenEnv string   `json:"token_env,omitempty"`
+	Proxies  []string `json:"proxies,omitempty"`
+}
+
+// RuntimeInvocation is a scheduled task the runtime is expected to run itself.
+type RuntimeInvocation struct {
+	Schedule string `json:"schedule"`
+	Message  string `json:"message"`
+	Name     string `json:"name,omitempty"`
+	To       string `json:"to,omitempty"`
+}
+
+// RuntimeSurface is a declared surface with its access mode.
+type RuntimeSurface struct {
+	Scheme     string   `json:"scheme"`
+	Target     string   `json:"target"`
+	AccessMode string   `json:"access_mode,omitempty"`
+	Ports      []string `json:"ports,omitempty"`
+	Skill      string   `json:"skill,omitempty"`
+}
+
+// GenerateRuntimeConfig builds the claw-runtime.json document for rc.
+func GenerateRuntimeConfig(rc *driver.ResolvedClaw, podName string) ([]byte, error) {
+	cfg := RuntimeConfig{
+		Version:     1,
+		Pod:         podName,
+		Service:     rc.ServiceName,
+		ClawType:    rc.ClawType,
+		Contract:    contractPath,
+		Clawdapus:   clawdapusPath,
+		SkillsDir:   skillsDir,
+		Models:      rc.Models,
+		Invocations: runtimeInvocations(rc.Invocations),
+		Handles:     rc.Handles,
+		Configures:  rc.Configures,
+	}
+	if rc.PersonaHostPath != "" {
+		cfg.PersonaDir = personaDir
+	}
+	for _, sk := range rc.Skills {
+		cfg.Skills = append(cfg.Skills, sk.Name)
+	}
+	sort.Strings(cfg.Skills)
+
+	if len(rc.Cllama) > 0 {
+		cfg.LLM = &RuntimeLLM{
+			BaseURL:  rc.LLMBaseURL(),
+			TokenEnv: "CLLAMA_TOKEN",
+			Proxies:  append([]string(nil), rc.Cllama...),
+		}
+	} else if rc.MockLLM {
+		// The mock accepts any bearer, so no token env is advertised.
+		cfg.LLM = &RuntimeLLM{BaseURL: rc.LLMBaseURL()}
+	}
+
+	for _, s := range rc.Surfaces {
+		cfg.Surfaces = append(cfg.Surfaces, RuntimeSurface{
+			Scheme:     s.Scheme,
+			Target:     s.Target,
+			AccessMode: s.AccessMode,
+			Ports:      s.Ports,
+			Skill:      s.SkillName,
+		})
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal claw-runtime.json: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// InvocationsJSON returns the compact JSON array exported as CLAW_INVOCATIONS.
+func InvocationsJSON(invocations []driver.Invocation) (string, error) {
+	data, err := json.Marshal(runtimeInvocations(invocations))
+	if err != nil {
+		return "", fmt.Errorf("marshal invocations: %w", err)
+	}
+	return string(data), nil
+}
+
+// ModelEnvKey maps a MODEL slot name to its CLAW_MODEL_<SLOT> env var.
+func ModelEnvKey(slot string) string {
+	var b strings.Builder
+	b.WriteString("CLAW_MODEL_")
+	for _, r := range strings.ToUpper(strings.TrimSpace(slot)) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteByte('_')
+	}
+	return b.String()
+}
+
+func runtimeInvocations(invocations []driver.Invocation) []RuntimeInvocation {
+	out := make([]RuntimeInvocation, 0, len(invocations))
+	for _, inv := range invocations {
+		out = append(out, RuntimeInvocation{
+			Schedule: strings.TrimSpace(inv.Schedule),
+			Message:  strings.TrimSpace(inv.Message),
+			Name:     strings.TrimSpace(inv.Name),
+			To:       strings.TrimSpace(inv.To),
+		})
+	}
+	return out
+}
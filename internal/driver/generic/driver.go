@@ -0,0 +1,178 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/mostlydev/clawdapus/internal/driver"
+	"github.com/mostlydev/clawdapus/internal/driver/shared"
+)
+
+// Driver implements the convention-based generic driver. It does not know the
+// runner: it mounts the contract, persona and skills at fixed paths and exports
+// everything else through CLAW_* env vars and claw-runtime.json.
+type Driver struct{}
+
+func init() {
+	driver.Register("generic", &Driver{})
+}
+
+func (d *Driver) Validate(rc *driver.ResolvedClaw) error {
+	if rc.AgentHostPath == "" {
+		return fmt.Errorf("generic driver: no agent host path specified (no contract, no start)")
+	}
+	if _, err := os.Stat(rc.AgentHostPath); err != nil {
+		return fmt.Errorf("generic driver: agent file %q not found: %w", rc.AgentHostPath, err)
+	}
+
+	for slot, ref := range rc.Models {
+		if strings.Trim(ModelEnvKey(slot), "_") == "CLAW_MODEL" {
+			return fmt.Errorf("generic driver: invalid MODEL slot %q", slot)
+		}
+		if _, _, ok := shared.SplitModelRef(ref); !ok {
+			return fmt.Errorf("generic driver: invalid MODEL %s %q (expected provider/model)", slot, ref)
+		}
+	}
+
+	for i, inv := range rc.Invocations {
+		if len(strings.Fields(inv.Schedule)) != 5 {
+			return fmt.Errorf("generic driver: invocation %d has invalid cron expression %q (expected 5 fields)", i+1, inv.Schedule)
+		}
+		if strings.TrimSpace(inv.Message) == "" {
+			return fmt.Errorf("generic driver: invocation %d has empty message", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (d *Driver) Materialize(rc *driver.ResolvedClaw, opts driver.MaterializeOpts) (*driver.MaterializeResult, error) {
+	podName := opts.PodName
+	if podName == "" {
+		podName = rc.ServiceName
+	}
+
+	clawdapusHostPath := filepath.Join(opts.RuntimeDir, "CLAWDAPUS.md")
+	clawdapusMD := shared.GenerateClawdapusMD(rc, podName)
+	if err := os.WriteFile(clawdapusHostPath, []byte(clawdapusMD), 0o644); err != nil {
+		return nil, fmt.Errorf("generic driver: write CLAWDAPUS.md: %w", err)
+	}
+
+	runtimeConfig, err := GenerateRuntimeConfig(rc, podName)
+	if err != nil {
+		return nil, fmt.Errorf("generic driver: %w", err)
+	}
+	runtimeConfigHostPath := filepath.Join(opts.RuntimeDir, "claw-runtime.json")
+	if err := os.WriteFile(runtimeConfigHostPath, runtimeConfig, 0o644); err != nil {
+		return nil, fmt.Errorf("generic driver: write claw-runtime.json: %w", err)
+	}
+
+	invocations, err := InvocationsJSON(rc.Invocations)
+	if err != nil {
+		return nil, fmt.Errorf("generic driver: %w", err)
+	}
+
+	mounts := []driver.Mount{
+		{HostPath: rc.AgentHostPath, ContainerPath: contractPath, ReadOnly: true},
+		{HostPath: clawdapusHostPath, ContainerPath: clawdapusPath, ReadOnly: true},
+		{HostPath: runtimeConfigHostPath, ContainerPath: runtimeConfigPath, ReadOnly: true},
+	}
+	if rc.PersonaHostPath != "" {
+		mounts = append(mounts, driver.Mount{
+			HostPath:      rc.PersonaHostPath,
+			ContainerPath: personaDir,
+			ReadOnly:      false,
+		})
+	}
+
+	env := map[string]string{
+		"CLAW_MANAGED":        "true",
+		"CLAW_POD":            podName,
+		"CLAW_SERVICE":        rc.ServiceName,
+		"CLAW_CONTRACT_PATH":  contractPath,
+		"CLAW_CLAWDAPUS_PATH": clawdapusPath,
+		"CLAW_RUNTIME_CONFIG": runtimeConfigPath,
+		"CLAW_SKILLS_DIR":     skillsDir,
+		"CLAW_INVOCATIONS":    invocations,
+	}
+	for slot, ref := range rc.Models {
+		env[ModelEnvKey(slot)] = ref
+	}
+	if rc.PersonaHostPath != "" {
+		env["CLAW_PERSONA_DIR"] = personaDir
+	}
+	if baseURL := rc.LLMBaseURL(); baseURL != "" {
+		env["CLAW_LLM_BASE_URL"] = baseURL
+	}
+
+	return &driver.MaterializeResult{
+		Mounts:      mounts,
+		Tmpfs:       []string{"/tmp"},
+		ReadOnly:    true,
+		Restart:     "on-failure",
+		SkillDir:    skillsDir,
+		SkillLayout: "",
+		Environment: env,
+	}, nil
+}
+
+func (d *Driver) PostApply(rc *driver.ResolvedClaw, opts driver.PostApplyOpts) error {
+	if opts.ContainerID == "" {
+		return fmt.Errorf("generic driver: post-apply check failed: no container ID")
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("generic driver: post-apply failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	info, err := cli.ContainerInspect(ctx, opts.ContainerID)
+	if err != nil {
+		return fmt.Errorf("generic driver: post-apply container inspect failed: %w", err)
+	}
+	if info.State == nil || !info.State.Running {
+		status := "unknown"
+		if info.State != nil && info.State.Status != "" {
+			status = info.State.Status
+		}
+		return fmt.Errorf("generic driver: post-apply check failed: container is not running (status: %s)", status)
+	}
+	return nil
+}
+
+func (d *Driver) HealthProbe(ref driver.ContainerRef) (*driver.Health, error) {
+	if ref.ContainerID == "" {
+		return &driver.Health{OK: false, Detail: "no container ID"}, nil
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("generic driver: health probe failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	info, err := cli.ContainerInspect(ctx, ref.ContainerID)
+	if err != nil {
+		return &driver.Health{OK: false, Detail: fmt.Sprintf("container inspect failed: %v", err)}, nil
+	}
+	if info.State == nil || !info.State.Running {
+		status := "unknown"
+		if info.State != nil && info.State.Status != "" {
+			status = info.State.Status
+		}
+		return &driver.Health{OK: false, Detail: fmt.Sprintf("container is not running (status: %s)", status)}, nil
+	}
+	return &driver.Health{OK: true, Detail: "container running"}, nil
+}
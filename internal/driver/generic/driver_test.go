@@ -0,0 +1,162 @@
+package generic
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+)
+
+func TestDriverRegistered(t *testing.T) {
+	d, err := driver.Lookup("generic")
+	if err != nil {
+		t.Fatalf("generic driver not registered: %v", err)
+	}
+	if d == nil {
+		t.Fatal("generic driver is nil")
+	}
+}
+
+func newTestRC(t *testing.T) (*driver.ResolvedClaw, string) {
+	t.Helper()
+	tmp := t.TempDir()
+	agentPath := filepath.Join(tmp, "AGENTS.md")
+	if err := os.WriteFile(agentPath, []byte("# Contract\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rc := &driver.ResolvedClaw{
+		ServiceName:   "runner",
+		ClawType:      "generic",
+		AgentHostPath: agentPath,
+		Models: map[string]string{
+			"primary":  "openrouter/anthropic/claude-sonnet-4",
+			"fallback": "anthropic/claude-haiku-4",
+		},
+		Environment: map[string]string{},
+	}
+	return rc, tmp
+}
+
+func TestValidateRequiresAgentPath(t *testing.T) {
+	d := &Driver{}
+	err := d.Validate(&driver.ResolvedClaw{ServiceName: "runner"})
+	if err == nil {
+		t.Fatal("expected error for missing agent host path")
+	}
+	if !strings.Contains(err.Error(), "no agent host path") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateRejectsInvalidInvocation(t *testing.T) {
+	rc, _ := newTestRC(t)
+	rc.Invocations = []driver.Invocation{{Schedule: "* * *", Message: "run"}}
+
+	err := (&Driver{}).Validate(rc)
+	if err == nil || !strings.Contains(err.Error(), "cron expression") {
+		t.Fatalf("expected cron expression error, got %v", err)
+	}
+}
+
+func TestMaterializeMountsAndEnv(t *testing.T) {
+	rc, tmp := newTestRC(t)
+	rc.PersonaHostPath = filepath.Join(tmp, "persona")
+	rc.Cllama = []string{"passthrough"}
+	rc.Invocations = []driver.Invocation{{Schedule: "0 9 * * 1-5", Message: "Morning report", Name: "report"}}
+	runtimeDir := filepath.Join(tmp, "runtime")
+	if err := os.MkdirAll(runtimeDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := (&Driver{}).Materialize(rc, driver.MaterializeOpts{RuntimeDir: runtimeDir, PodName: "test-pod"})
+	if err != nil {
+		t.Fatalf("Materialize: %v", err)
+	}
+
+	mounts := make(map[string]driver.Mount)
+	for _, m := range result.Mounts {
+		mounts[m.ContainerPath] = m
+	}
+	for _, path := range []string{"/claw/AGENTS.md", "/claw/CLAWDAPUS.md", "/claw/claw-runtime.json"} {
+		m, ok := mounts[path]
+		if !ok {
+			t.Fatalf("expected mount at %s, got %+v", path, result.Mounts)
+		}
+		if !m.ReadOnly {
+			t.Errorf("expected %s to be read-only", path)
+		}
+	}
+	if m, ok := mounts["/claw/persona"]; !ok || m.ReadOnly {
+		t.Errorf("expected writable persona mount, got %+v", m)
+	}
+	if result.SkillDir != "/claw/skills" {
+		t.Errorf("expected skill dir /claw/skills, got %q", result.SkillDir)
+	}
+	if !result.ReadOnly {
+		t.Error("expected read-only rootfs")
+	}
+
+	want := map[string]string{
+		"CLAW_CONTRACT_PATH":  "/claw/AGENTS.md",
+		"CLAW_SKILLS_DIR":     "/claw/skills",
+		"CLAW_MODEL_PRIMARY":  "openrouter/anthropic/claude-sonnet-4",
+		"CLAW_MODEL_FALLBACK": "anthropic/claude-haiku-4",
+		"CLAW_LLM_BASE_URL":   "http://cllama:8080/v1",
+		"CLAW_PERSONA_DIR":    "/claw/persona",
+		"CLAW_RUNTIME_CONFIG": "/claw/claw-runtime.json",
+	}
+	for k, v := range want {
+		if got := result.Environment[k]; got != v {
+			t.Errorf("env %s: expected %q, got %q", k, v, got)
+		}
+	}
+
+	var invocations []RuntimeInvocation
+	if err := json.Unmarshal([]byte(result.Environment["CLAW_INVOCATIONS"]), &invocations); err != nil {
+		t.Fatalf("decode CLAW_INVOCATIONS: %v", err)
+	}
+	if len(invocations) != 1 || invocations[0].Schedule != "0 9 * * 1-5" || invocations[0].Name != "report" {
+		t.Fatalf("unexpected invocations: %+v", invocations)
+	}
+
+	data, err := os.ReadFile(filepath.Join(runtimeDir, "claw-runtime.json"))
+	if err != nil {
+		t.Fatalf("read claw-runtime.json: %v", err)
+	}
+	var cfg RuntimeConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("decode claw-runtime.json: %v", err)
+	}
+	if cfg.Pod != "test-pod" || cfg.Service != "runner" || cfg.Contract != "/claw/AGENTS.md" {
+		t.Fatalf("unexpected runtime config identity: %+v", cfg)
+	}
+	if cfg.LLM == nil || cfg.LLM.BaseURL != "http://cllama:8080/v1" || cfg.LLM.TokenEnv != "CLLAMA_TOKEN" {
+		t.Fatalf("unexpected runtime config llm: %+v", cfg.LLM)
+	}
+	if _, err := os.Stat(filepath.Join(runtimeDir, "CLAWDAPUS.md")); err != nil {
+		t.Fatalf("expected CLAWDAPUS.md: %v", err)
+	}
+}
+
+func TestMaterializeWithoutCllamaOmitsBaseURL(t *testing.T) {
+	rc, tmp := newTestRC(t)
+	result, err := (&Driver{}).Materialize(rc, driver.MaterializeOpts{RuntimeDir: tmp, PodName: "test-pod"})
+	if err != nil {
+		t.Fatalf("Materialize: %v", err)
+	}
+	if _, ok := result.Environment["CLAW_LLM_BASE_URL"]; ok {
+		t.Fatal("expected no CLAW_LLM_BASE_URL without cllama")
+	}
+	if got := result.Environment["CLAW_INVOCATIONS"]; got != "[]" {
+		t.Fatalf("expected empty CLAW_INVOCATIONS array, got %q", got)
+	}
+}
+
+func TestModelEnvKeySanitizesSlot(t *testing.T) {
+	if got := ModelEnvKey("fast-path.v2"); got != "CLAW_MODEL_FAST_PATH_V2" {
+		t.Fatalf("unexpected key: %q", got)
+	}
+}
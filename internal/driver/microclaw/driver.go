@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/docker/docker/client"
-	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/driver/shared"
 	"gopkg.in/yaml.v3"
@@ -68,7 +67,7 @@ func (d *Driver) Validate(rc *driver.ResolvedClaw) error {
 		}
 	}
 
-	if len(rc.Cllama) == 0 {
+	if rc.LLMBaseURL() == "" {
 		llmProvider := shared.NormalizeProvider(provider)
 		if !shared.ProviderAllowsEmptyAPIKey(llmProvider) {
 			if key := shared.ResolveProviderAPIKey(llmProvider, rc.Environment); key == "" {
@@ -264,13 +263,12 @@ func generateConfig(rc *driver.ResolvedClaw) (map[string]interface{}, error) {
 		"web": map[string]interface{}{"enabled": true},
 	}
 
-	if len(rc.Cllama) > 0 {
-		if strings.TrimSpace(rc.CllamaToken) == "" {
+	if firstProxy := rc.LLMBaseURL(); firstProxy != "" {
+		if strings.TrimSpace(rc.LLMToken()) == "" {
 			return nil, fmt.Errorf("microclaw driver: CLLAMA is enabled but token is empty")
 		}
-		firstProxy := cllama.ProxyBaseURL(rc.Cllama[0])
 		cfg["llm_base_url"] = firstProxy
-		cfg["api_key"] = rc.CllamaToken
+		cfg["api_key"] = rc.LLMToken()
 
 		if shared.NormalizeProvider(provider) == "anthropic" {
 			cfg["llm_provider"] = "anthropic"
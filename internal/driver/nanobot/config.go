@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/driver/shared"
 )
@@ -26,17 +25,16 @@ func GenerateConfig(rc *driver.ResolvedClaw) ([]byte, error) {
 		return nil, fmt.Errorf("config generation: %w", err)
 	}
 
-	if len(rc.Cllama) > 0 {
-		if strings.TrimSpace(rc.CllamaToken) == "" {
+	if firstProxy := rc.LLMBaseURL(); firstProxy != "" {
+		if strings.TrimSpace(rc.LLMToken()) == "" {
 			return nil, fmt.Errorf("config generation: CLLAMA is enabled but token is empty")
 		}
-		firstProxy := cllama.ProxyBaseURL(rc.Cllama[0])
 		for _, provider := range shared.CollectProviders(rc.Models) {
 			base := "providers." + provider
 			if err := setPath(config, base+".base_url", firstProxy); err != nil {
 				return nil, fmt.Errorf("config generation: cllama provider %q base_url: %w", provider, err)
 			}
-			if err := setPath(config, base+".api_key", rc.CllamaToken); err != nil {
+			if err := setPath(config, base+".api_key", rc.LLMToken()); err != nil {
 				return nil, fmt.Errorf("config generation: cllama provider %q api_key: %w", provider, err)
 			}
 		}
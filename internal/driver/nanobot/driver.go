@@ -62,7 +62,7 @@ func (d *Driver) Validate(rc *driver.ResolvedClaw) error {
 		}
 	}
 
-	if len(rc.Cllama) == 0 {
+	if rc.LLMBaseURL() == "" {
 		llmProvider := shared.NormalizeProvider(provider)
 		if !shared.ProviderAllowsEmptyAPIKey(llmProvider) {
 			if key := shared.ResolveProviderAPIKey(llmProvider, rc.Environment); key == "" {
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/docker/docker/client"
-	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/driver/shared"
 )
@@ -75,15 +74,14 @@ func (d *Driver) Materialize(rc *driver.ResolvedClaw, opts driver.MaterializeOpt
 		env["CLAW_PERSONA_DIR"] = "/workspace/container/persona"
 	}
 
-	if len(rc.Cllama) > 0 {
-		firstProxy := cllama.ProxyBaseURL(rc.Cllama[0])
+	if firstProxy := rc.LLMBaseURL(); firstProxy != "" {
 		env["ANTHROPIC_BASE_URL"] = firstProxy
 		// Compose network name: {project}_{network}
 		env["CLAW_NETWORK"] = fmt.Sprintf("%s_claw-internal", podName)
 
-		if rc.CllamaToken != "" {
+		if token := rc.LLMToken(); token != "" {
 			// .env file for orchestrator's readEnvFile() — passes to agent-runners via stdin
-			envContent := fmt.Sprintf("ANTHROPIC_API_KEY=%s\n", rc.CllamaToken)
+			envContent := fmt.Sprintf("ANTHROPIC_API_KEY=%s\n", token)
 			envPath := filepath.Join(opts.RuntimeDir, ".env")
 			if err := os.WriteFile(envPath, []byte(envContent), 0600); err != nil {
 				return nil, fmt.Errorf("nanoclaw driver: write .env: %w", err)
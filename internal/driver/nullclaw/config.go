@@ -45,17 +45,19 @@ func GenerateConfig(rc *driver.ResolvedClaw) ([]byte, error) {
 		}
 	}
 
-	if len(rc.Cllama) > 0 {
-		if strings.TrimSpace(rc.CllamaToken) == "" {
+	if firstProxy := rc.LLMBaseURL(); firstProxy != "" {
+		if strings.TrimSpace(rc.LLMToken()) == "" {
 			return nil, fmt.Errorf("config generation: CLLAMA is enabled but token is empty")
 		}
-		firstProxy := fmt.Sprintf("http://cllama-%s:8080/v1", rc.Cllama[0])
+		if len(rc.Cllama) > 0 {
+			firstProxy = fmt.Sprintf("http://cllama-%s:8080/v1", rc.Cllama[0])
+		}
 		for _, provider := range shared.CollectProviders(rc.Models) {
 			base := "models.providers." + provider
 			if err := setPath(config, base+".base_url", firstProxy); err != nil {
 				return nil, fmt.Errorf("config generation: cllama provider %q base_url: %w", provider, err)
 			}
-			if err := setPath(config, base+".api_key", rc.CllamaToken); err != nil {
+			if err := setPath(config, base+".api_key", rc.LLMToken()); err != nil {
 				return nil, fmt.Errorf("config generation: cllama provider %q api_key: %w", provider, err)
 			}
 		}
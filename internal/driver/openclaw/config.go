@@ -7,7 +7,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 )
 
@@ -42,16 +41,15 @@ func GenerateConfig(rc *driver.ResolvedClaw) ([]byte, error) {
 		}
 	}
 
-	if len(rc.Cllama) > 0 {
-		firstProxy := cllama.ProxyBaseURL(rc.Cllama[0])
+	if firstProxy := rc.LLMBaseURL(); firstProxy != "" {
 		providerModels := collectCllamaProviderModels(rc.Models)
 		for provider, modelIDs := range providerModels {
 			basePath := "models.providers." + provider
 			if err := setPath(config, basePath+".baseUrl", firstProxy); err != nil {
 				return nil, fmt.Errorf("config generation: cllama provider %q baseUrl: %w", provider, err)
 			}
-			if rc.CllamaToken != "" {
-				if err := setPath(config, basePath+".apiKey", rc.CllamaToken); err != nil {
+			if token := rc.LLMToken(); token != "" {
+				if err := setPath(config, basePath+".apiKey", token); err != nil {
 					return nil, fmt.Errorf("config generation: cllama provider %q apiKey: %w", provider, err)
 				}
 			}
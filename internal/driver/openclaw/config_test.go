@@ -117,6 +117,23 @@ func TestGenerateConfigNoCllamaNoProviderRewrite(t *testing.T) {
 	}
 }
 
+func TestGenerateConfigMockLLMRewritesProviderBaseURL(t *testing.T) {
+	rc := &driver.ResolvedClaw{
+		Models:  map[string]string{"primary": "anthropic/claude-sonnet-4"},
+		MockLLM: true,
+	}
+	data, err := GenerateConfig(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := getPath(data, "models.providers.anthropic.baseUrl"); v != "http://claw-mock-llm:8080/v1" {
+		t.Errorf("expected mock baseUrl, got %v", v)
+	}
+	if v, _ := getPath(data, "models.providers.anthropic.apiKey"); v != "claw-mock-llm" {
+		t.Errorf("expected mock apiKey, got %v", v)
+	}
+}
+
 func TestGenerateConfigCllamaInjectsDummyToken(t *testing.T) {
 	rc := &driver.ResolvedClaw{
 		Models:      map[string]string{"primary": "anthropic/claude-sonnet-4"},
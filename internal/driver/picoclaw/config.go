@@ -6,7 +6,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/driver/shared"
 )
@@ -150,15 +149,11 @@ func buildModelList(rc *driver.ResolvedClaw) ([]map[string]interface{}, error) {
 	slots := sortedModelSlots(rc.Models)
 	entries := make([]map[string]interface{}, 0, len(slots))
 
-	if len(rc.Cllama) > 0 && strings.TrimSpace(rc.CllamaToken) == "" {
+	firstProxy := rc.LLMBaseURL()
+	if firstProxy != "" && strings.TrimSpace(rc.LLMToken()) == "" {
 		return nil, fmt.Errorf("CLLAMA is enabled but token is empty")
 	}
 
-	firstProxy := ""
-	if len(rc.Cllama) > 0 {
-		firstProxy = cllama.ProxyBaseURL(rc.Cllama[0])
-	}
-
 	for _, slot := range slots {
 		ref := strings.TrimSpace(rc.Models[slot])
 		if ref == "" {
@@ -175,10 +170,10 @@ func buildModelList(rc *driver.ResolvedClaw) ([]map[string]interface{}, error) {
 			"model":   provider + "/" + modelID,
 		}
 
-		if len(rc.Cllama) > 0 {
+		if firstProxy != "" {
 			entry["model"] = "openai/" + ref
 			entry["api_base"] = firstProxy
-			entry["api_key"] = rc.CllamaToken
+			entry["api_key"] = rc.LLMToken()
 		} else {
 			llmProvider := shared.NormalizeProvider(provider)
 			if apiKey := shared.ResolveProviderAPIKey(llmProvider, rc.Environment); apiKey != "" {
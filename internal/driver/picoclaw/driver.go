@@ -73,7 +73,7 @@ func (d *Driver) Validate(rc *driver.ResolvedClaw) error {
 		return fmt.Errorf("picoclaw driver: no channels enabled (add at least one supported HANDLE: %s)", strings.Join(supportedPlatforms, ", "))
 	}
 
-	if len(rc.Cllama) == 0 {
+	if rc.LLMBaseURL() == "" {
 		llmProvider := shared.NormalizeProvider(provider)
 		if !shared.ProviderAllowsEmptyAPIKey(llmProvider) {
 			if key := shared.ResolveProviderAPIKey(llmProvider, rc.Environment); key == "" {
@@ -241,6 +241,20 @@ func TestPostApplyRequiresContainerID(t *testing.T) {
 	}
 }
 
+func TestValidateMockLLMNeedsNoProviderKey(t *testing.T) {
+	rc, _ := newTestRC(t)
+	delete(rc.Environment, "ANTHROPIC_API_KEY")
+
+	d := &Driver{}
+	if err := d.Validate(rc); err == nil || !strings.Contains(err.Error(), "no API key") {
+		t.Fatalf("expected missing provider key error, got %v", err)
+	}
+	rc.MockLLM = true
+	if err := d.Validate(rc); err != nil {
+		t.Fatalf("expected mock LLM to satisfy provider key check, got %v", err)
+	}
+}
+
 func newTestRC(t *testing.T) (*driver.ResolvedClaw, string) {
 	t.Helper()
 	tmp := t.TempDir()
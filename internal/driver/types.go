@@ -1,5 +1,10 @@
 package driver
 
+import (
+	"github.com/mostlydev/clawdapus/internal/cllama"
+	"github.com/mostlydev/clawdapus/internal/mockllm"
+)
+
 // Driver translates Clawfile intent into runner-specific enforcement.
 // Fail-closed: Validate runs before compose up, PostApply runs after.
 type Driver interface {
@@ -40,11 +45,37 @@ type ResolvedClaw struct {
 	Skills          []ResolvedSkill
 	Privileges      map[string]string
 	Configures      []string          // openclaw config set commands from labels
+	Tracks          []string          // package managers wrapped by TRACK (from image labels)
 	Invocations     []Invocation      // scheduled agent tasks from image labels + pod x-claw.invoke
 	Count           int               // from pod x-claw (default 1)
 	Environment     map[string]string // from pod environment block
 	Cllama          []string          // ordered cllama proxy types (e.g., ["passthrough"])
 	CllamaToken     string            // per-agent bearer token injected when cllama is active
+	MockLLM         bool              // claw up --mock-llm with no cllama proxy: providers point at the pod mock LLM
+}
+
+// LLMBaseURL returns the endpoint the agent's model providers are rewired to:
+// its first cllama proxy, or the pod mock LLM. "" means providers are called
+// directly.
+func (rc *ResolvedClaw) LLMBaseURL() string {
+	if len(rc.Cllama) > 0 {
+		return cllama.ProxyBaseURL(rc.Cllama[0])
+	}
+	if rc.MockLLM {
+		return mockllm.BaseURL()
+	}
+	return ""
+}
+
+// LLMToken returns the bearer the agent presents to LLMBaseURL.
+func (rc *ResolvedClaw) LLMToken() string {
+	if len(rc.Cllama) > 0 {
+		return rc.CllamaToken
+	}
+	if rc.MockLLM {
+		return mockllm.Token
+	}
+	return ""
 }
 
 // HandleInfo is the full contact card for an agent on a platform.
@@ -113,6 +144,22 @@ type ResolvedSurface struct {
 	ChannelConfig *ChannelConfig // non-nil only for map-form channel surfaces
 }
 
+// ServiceDescription is an operator-written x-claw.describe block for a
+// service surface target that cannot describe itself (third-party images).
+type ServiceDescription struct {
+	Description string
+	Protocol    string             // e.g. redis, postgres, http
+	Auth        []string           // env var names holding credentials
+	Examples    []string           // example calls, rendered verbatim
+	Operations  []ServiceOperation // what consumers can do with the service
+}
+
+// ServiceOperation is one entry of a describe block's operations list.
+type ServiceOperation struct {
+	Name        string
+	Description string
+}
+
 type GeneratedSkill struct {
 	Name    string // filename (e.g., "surface-fleet-master.md")
 	Content []byte // skill file content
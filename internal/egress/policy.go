@@ -0,0 +1,238 @@
+// Package egress implements the pod-level egress proxy policy: per-service
+// domain allowlists derived from egress:// surfaces, enforced by the
+// claw-egress sidecar.
+package egress
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Well-known names and paths shared by claw up and the proxy binary.
+const (
+	ServiceName      = "claw-egress"
+	NetworkName      = "claw-egress"
+	Port             = "3128"
+	ImageRef         = "ghcr.io/mostlydev/clawegress:latest"
+	PolicyPath       = "/claw/egress-policy.json"
+	LogDir           = "/claw/logs"
+	DeniedLogName    = "egress-denied.jsonl"
+	HealthcheckPath  = "/healthz"
+	policyVersion    = 1
+	secretByteLength = 24
+)
+
+// platformDomains are the hosts a declared channel platform needs to reach.
+var platformDomains = map[string][]string{
+	"discord":  {"discord.com", "*.discord.com", "discord.gg", "*.discord.gg", "discordapp.com", "*.discordapp.com", "*.discordapp.net"},
+	"slack":    {"slack.com", "*.slack.com", "*.slack-edge.com"},
+	"telegram": {"api.telegram.org"},
+}
+
+// providerDomains are the hosts a model provider's API is served from.
+var providerDomains = map[string][]string{
+	"anthropic":  {"api.anthropic.com"},
+	"openai":     {"api.openai.com"},
+	"openrouter": {"openrouter.ai"},
+	"google":     {"generativelanguage.googleapis.com"},
+	"gemini":     {"generativelanguage.googleapis.com"},
+	"xai":        {"api.x.ai"},
+	"mistral":    {"api.mistral.ai"},
+	"groq":       {"api.groq.com"},
+}
+
+// Policy is the document claw up writes for the egress proxy.
+type Policy struct {
+	Version  int                       `json:"version"`
+	Pod      string                    `json:"pod"`
+	Services map[string]*ServicePolicy `json:"services"`
+}
+
+// ServicePolicy is the allowlist and proxy credential of one (expanded) service.
+type ServicePolicy struct {
+	Secret string   `json:"secret"`
+	Allow  []string `json:"allow"`
+}
+
+// NewPolicy returns an empty policy for pod.
+func NewPolicy(pod string) *Policy {
+	return &Policy{Version: policyVersion, Pod: pod, Services: make(map[string]*ServicePolicy)}
+}
+
+// AddService registers service with a fresh proxy secret and its allowlist.
+// Rules are validated, lower-cased and de-duplicated.
+func (p *Policy) AddService(service string, allow []string) error {
+	rules := make([]string, 0, len(allow))
+	seen := make(map[string]struct{}, len(allow))
+	for _, raw := range allow {
+		rule, err := NormalizeRule(raw)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", service, err)
+		}
+		if _, ok := seen[rule]; ok {
+			continue
+		}
+		seen[rule] = struct{}{}
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	p.Services[service] = &ServicePolicy{Secret: generateSecret(), Allow: rules}
+	return nil
+}
+
+// KeepSecrets reuses prev's proxy credentials for services present in both
+// policies, so re-running claw up does not change every claw's HTTP_PROXY.
+func (p *Policy) KeepSecrets(prev *Policy) {
+	if prev == nil || prev.Pod != p.Pod {
+		return
+	}
+	for name, sp := range p.Services {
+		if old := prev.Services[name]; old != nil && old.Secret != "" {
+			sp.Secret = old.Secret
+		}
+	}
+}
+
+// ProxyURL returns the HTTP(S)_PROXY value for service, carrying its
+// credential so the proxy can attribute and authorize each request.
+func (p *Policy) ProxyURL(service string) string {
+	sp := p.Services[service]
+	if sp == nil {
+		return ""
+	}
+	return fmt.Sprintf("http://%s:%s@%s:%s", service, sp.Secret, ServiceName, Port)
+}
+
+// Authenticate reports whether secret is the proxy credential of service.
+func (p *Policy) Authenticate(service, secret string) bool {
+	sp := p.Services[service]
+	if sp == nil || sp.Secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(sp.Secret), []byte(secret)) == 1
+}
+
+// Allowed reports whether service may reach host:port and, when it may, the
+// rule that matched.
+func (p *Policy) Allowed(service, host, port string) (string, bool) {
+	sp := p.Services[service]
+	if sp == nil {
+		return "", false
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	for _, rule := range sp.Allow {
+		if ruleMatches(rule, host, port) {
+			return rule, true
+		}
+	}
+	return "", false
+}
+
+// NormalizeRule validates an allowlist rule: host, *.domain, optionally with
+// :port. Paths and schemes are rejected because the proxy cannot see inside
+// TLS tunnels.
+func NormalizeRule(raw string) (string, error) {
+	rule := strings.ToLower(strings.TrimSpace(raw))
+	if rule == "" {
+		return "", fmt.Errorf("empty egress rule")
+	}
+	if strings.Contains(rule, "://") || strings.ContainsAny(rule, "/?#@ \t") {
+		return "", fmt.Errorf("egress rule %q must be a host or *.domain, optionally with :port", raw)
+	}
+	host, port := rule, ""
+	if h, p, err := net.SplitHostPort(rule); err == nil {
+		host, port = h, p
+	}
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("egress rule %q has invalid port", raw)
+		}
+	}
+	name := strings.TrimPrefix(host, "*.")
+	if name == "" || strings.Contains(name, "*") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return "", fmt.Errorf("egress rule %q: wildcard is only allowed as a leading *. label", raw)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", fmt.Errorf("egress rule %q has an empty label", raw)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+				return "", fmt.Errorf("egress rule %q contains invalid character %q", raw, r)
+			}
+		}
+	}
+	return rule, nil
+}
+
+// PlatformDomains returns the implicit allowlist of a channel platform.
+func PlatformDomains(platform string) []string {
+	return append([]string(nil), platformDomains[strings.ToLower(strings.TrimSpace(platform))]...)
+}
+
+// ProviderDomains returns the implicit allowlist of a model provider.
+func ProviderDomains(provider string) []string {
+	return append([]string(nil), providerDomains[strings.ToLower(strings.TrimSpace(provider))]...)
+}
+
+// LoadPolicy reads a policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read egress policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse egress policy %q: %w", path, err)
+	}
+	if p.Version != policyVersion {
+		return nil, fmt.Errorf("egress policy %q: unsupported version %d", path, p.Version)
+	}
+	if p.Services == nil {
+		p.Services = make(map[string]*ServicePolicy)
+	}
+	return &p, nil
+}
+
+// Write stores the policy at path with owner-only permissions: it holds the
+// proxy credentials of every service.
+func (p *Policy) Write(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal egress policy: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write egress policy: %w", err)
+	}
+	return nil
+}
+
+func ruleMatches(rule, host, port string) bool {
+	ruleHost, rulePort := rule, ""
+	if h, p, err := net.SplitHostPort(rule); err == nil {
+		ruleHost, rulePort = h, p
+	}
+	if rulePort != "" && rulePort != port {
+		return false
+	}
+	if strings.HasPrefix(ruleHost, "*.") {
+		suffix := ruleHost[1:] // ".example.com"
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == ruleHost
+}
+
+func generateSecret() string {
+	b := make([]byte, secretByteLength)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,152 @@
+package egress
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRule(t *testing.T) {
+	valid := map[string]string{
+		"api.example.com":       "api.example.com",
+		" API.Example.com ":     "api.example.com",
+		"*.example.com":         "*.example.com",
+		"api.example.com:443":   "api.example.com:443",
+		"*.internal-corp.io:80": "*.internal-corp.io:80",
+		"127.0.0.1":             "127.0.0.1",
+	}
+	for in, want := range valid {
+		got, err := NormalizeRule(in)
+		if err != nil {
+			t.Fatalf("NormalizeRule(%q): %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("NormalizeRule(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "https://api.example.com", "api.example.com/v1", "api.*.com", "*", "*.", "api.example.com:0", "api.example.com:http", "a..b", "user@host"} {
+		if _, err := NormalizeRule(in); err == nil {
+			t.Fatalf("NormalizeRule(%q): expected error", in)
+		}
+	}
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	p := NewPolicy("ops")
+	if err := p.AddService("bot", []string{"api.example.com", "*.cdn.example.com", "hooks.example.com:8443", "api.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Services["bot"].Allow; len(got) != 3 {
+		t.Fatalf("expected de-duplicated rules, got %v", got)
+	}
+
+	cases := []struct {
+		host, port string
+		want       bool
+	}{
+		{"api.example.com", "443", true},
+		{"API.example.com.", "80", true},
+		{"evil-api.example.com", "443", false},
+		{"img.cdn.example.com", "443", true},
+		{"a.b.cdn.example.com", "443", true},
+		{"cdn.example.com", "443", false},
+		{"hooks.example.com", "8443", true},
+		{"hooks.example.com", "443", false},
+		{"example.com", "443", false},
+	}
+	for _, tc := range cases {
+		if _, got := p.Allowed("bot", tc.host, tc.port); got != tc.want {
+			t.Fatalf("Allowed(%s:%s) = %v, want %v", tc.host, tc.port, got, tc.want)
+		}
+	}
+	if _, ok := p.Allowed("unknown", "api.example.com", "443"); ok {
+		t.Fatal("expected unknown service to be denied")
+	}
+}
+
+func TestPolicyCredentials(t *testing.T) {
+	p := NewPolicy("ops")
+	if err := p.AddService("bot-0", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddService("bot-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	s0, s1 := p.Services["bot-0"].Secret, p.Services["bot-1"].Secret
+	if s0 == "" || s0 == s1 {
+		t.Fatalf("expected distinct non-empty secrets, got %q and %q", s0, s1)
+	}
+	if !p.Authenticate("bot-0", s0) || p.Authenticate("bot-0", s1) || p.Authenticate("nobody", s0) {
+		t.Fatal("unexpected authentication result")
+	}
+	want := "http://bot-0:" + s0 + "@claw-egress:3128"
+	if got := p.ProxyURL("bot-0"); got != want {
+		t.Fatalf("ProxyURL = %q, want %q", got, want)
+	}
+}
+
+func TestKeepSecretsReusesPreviousCredentials(t *testing.T) {
+	prev := NewPolicy("ops")
+	if err := prev.AddService("bot", nil); err != nil {
+		t.Fatal(err)
+	}
+	next := NewPolicy("ops")
+	for _, name := range []string{"bot", "new"} {
+		if err := next.AddService(name, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fresh := next.Services["new"].Secret
+	next.KeepSecrets(prev)
+	if next.Services["bot"].Secret != prev.Services["bot"].Secret || next.Services["new"].Secret != fresh {
+		t.Fatalf("unexpected secrets after KeepSecrets: %+v", next.Services)
+	}
+
+	other := NewPolicy("elsewhere")
+	if err := other.AddService("bot", nil); err != nil {
+		t.Fatal(err)
+	}
+	otherSecret := other.Services["bot"].Secret
+	other.KeepSecrets(prev)
+	if other.Services["bot"].Secret != otherSecret {
+		t.Fatal("expected secrets from another pod to be ignored")
+	}
+}
+
+func TestPolicyWriteLoadRoundTrip(t *testing.T) {
+	p := NewPolicy("ops")
+	if err := p.AddService("bot", []string{"*.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "egress-policy.json")
+	if err := p.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Pod != "ops" || !loaded.Authenticate("bot", p.Services["bot"].Secret) {
+		t.Fatalf("unexpected loaded policy: %+v", loaded)
+	}
+	if _, ok := loaded.Allowed("bot", "api.example.com", "443"); !ok {
+		t.Fatal("expected loaded rule to match")
+	}
+}
+
+func TestAddServiceRejectsInvalidRule(t *testing.T) {
+	err := NewPolicy("ops").AddService("bot", []string{"https://api.example.com/v1"})
+	if err == nil || !strings.Contains(err.Error(), `service "bot"`) {
+		t.Fatalf("expected service-scoped error, got %v", err)
+	}
+}
+
+func TestPlatformDomains(t *testing.T) {
+	if got := PlatformDomains("Discord"); len(got) == 0 || got[0] != "discord.com" {
+		t.Fatalf("unexpected discord domains: %v", got)
+	}
+	if got := PlatformDomains("irc"); len(got) != 0 {
+		t.Fatalf("expected no domains for unknown platform, got %v", got)
+	}
+}
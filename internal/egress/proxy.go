@@ -0,0 +1,236 @@
+package egress
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Denial is one audit record for a blocked egress attempt.
+type Denial struct {
+	Time    string `json:"time"`
+	Pod     string `json:"pod,omitempty"`
+	Service string `json:"service,omitempty"`
+	Method  string `json:"method"`
+	Host    string `json:"host"`
+	Port    string `json:"port,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// Proxy is an HTTP forward proxy that enforces a Policy. Clients authenticate
+// with Proxy-Authorization (username = service, password = its secret); HTTPS
+// is tunnelled with CONNECT and plain HTTP is forwarded.
+type Proxy struct {
+	Policy    *Policy
+	Transport http.RoundTripper
+	Dial      func(network, addr string) (net.Conn, error)
+	Audit     io.Writer // receives one JSON line per denied request
+	Now       func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewProxy returns a proxy enforcing policy and writing denials to audit.
+func NewProxy(policy *Policy, audit io.Writer) *Proxy {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &Proxy{
+		Policy: policy,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		Dial:  dialer.Dial,
+		Audit: audit,
+		Now:   time.Now,
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect && r.URL.Host == "" {
+		if r.URL.Path == HealthcheckPath {
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, "ok\n")
+			return
+		}
+		http.Error(w, "claw-egress: only proxy requests are accepted", http.StatusBadRequest)
+		return
+	}
+
+	host, port := targetHostPort(r)
+	service, secret, ok := proxyCredentials(r)
+	if !ok || !p.Policy.Authenticate(service, secret) {
+		p.deny(r, service, host, port, "unauthenticated")
+		w.Header().Set("Proxy-Authenticate", `Basic realm="claw-egress"`)
+		http.Error(w, "claw-egress: proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+	if _, allowed := p.Policy.Allowed(service, host, port); !allowed {
+		p.deny(r, service, host, port, "not in allowlist")
+		http.Error(w, "claw-egress: "+host+" is not in the egress allowlist for "+service, http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r, net.JoinHostPort(host, port))
+		return
+	}
+	p.forward(w, r)
+}
+
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request, addr string) {
+	upstream, err := p.Dial("tcp", addr)
+	if err != nil {
+		http.Error(w, "claw-egress: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "claw-egress: tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+	if n := buf.Reader.Buffered(); n > 0 {
+		pending, _ := buf.Reader.Peek(n)
+		if _, err := upstream.Write(pending); err != nil {
+			client.Close()
+			upstream.Close()
+			return
+		}
+	}
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		if tc, ok := dst.(interface{ CloseWrite() error }); ok {
+			_ = tc.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go pipe(upstream, client)
+	go pipe(client, upstream)
+	<-done
+	<-done
+	client.Close()
+	upstream.Close()
+}
+
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+
+	resp, err := p.Transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "claw-egress: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func (p *Proxy) deny(r *http.Request, service, host, port, reason string) {
+	if p.Audit == nil {
+		return
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	record := Denial{
+		Time:    now().UTC().Format(time.RFC3339),
+		Pod:     p.Policy.Pod,
+		Service: service,
+		Method:  r.Method,
+		Host:    host,
+		Port:    port,
+		Reason:  reason,
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = p.Audit.Write(append(data, '\n'))
+}
+
+// targetHostPort returns the destination of a proxy request, defaulting the
+// port from the scheme.
+func targetHostPort(r *http.Request) (string, string) {
+	hostport := r.URL.Host
+	if r.Method == http.MethodConnect {
+		hostport = r.Host
+	}
+	if host, port, err := net.SplitHostPort(hostport); err == nil {
+		return strings.ToLower(host), port
+	}
+	port := "80"
+	if r.Method == http.MethodConnect || r.URL.Scheme == "https" {
+		port = "443"
+	}
+	return strings.ToLower(hostport), port
+}
+
+func proxyCredentials(r *http.Request) (string, string, bool) {
+	auth := r.Header.Get("Proxy-Authorization")
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(auth[len(prefix):]))
+	if err != nil {
+		return "", "", false
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	return user, pass, ok
+}
+
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, f := range h.Values("Connection") {
+		for _, name := range strings.Split(f, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
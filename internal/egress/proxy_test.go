@@ -0,0 +1,139 @@
+package egress
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestProxy(t *testing.T, allow []string) (*Policy, *bytes.Buffer, *httptest.Server) {
+	t.Helper()
+	policy := NewPolicy("ops")
+	if err := policy.AddService("bot", allow); err != nil {
+		t.Fatal(err)
+	}
+	audit := &bytes.Buffer{}
+	srv := httptest.NewServer(NewProxy(policy, audit))
+	t.Cleanup(srv.Close)
+	return policy, audit, srv
+}
+
+func proxyClient(t *testing.T, proxyURL string, transport *http.Transport) *http.Client {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport == nil {
+		transport = &http.Transport{}
+	}
+	transport.Proxy = http.ProxyURL(u)
+	return &http.Client{Transport: transport}
+}
+
+func TestProxyForwardsAllowedHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Errorf("proxy credentials leaked upstream")
+		}
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer upstream.Close()
+
+	policy, audit, srv := newTestProxy(t, []string{"127.0.0.1"})
+	proxyURL := strings.Replace(policy.ProxyURL("bot"), "claw-egress:3128", strings.TrimPrefix(srv.URL, "http://"), 1)
+
+	resp, err := proxyClient(t, proxyURL, nil).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+	}
+	if audit.Len() != 0 {
+		t.Fatalf("expected no denials, got %s", audit.String())
+	}
+}
+
+func TestProxyTunnelsAllowedHTTPS(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "secure")
+	}))
+	defer upstream.Close()
+
+	policy, _, srv := newTestProxy(t, []string{"127.0.0.1"})
+	proxyURL := strings.Replace(policy.ProxyURL("bot"), "claw-egress:3128", strings.TrimPrefix(srv.URL, "http://"), 1)
+	transport := upstream.Client().Transport.(*http.Transport).Clone()
+
+	resp, err := proxyClient(t, proxyURL, transport).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "secure" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestProxyDeniesAndAuditsDisallowedHost(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("denied request reached upstream")
+	}))
+	defer upstream.Close()
+
+	policy, audit, srv := newTestProxy(t, []string{"api.example.com"})
+	proxyURL := strings.Replace(policy.ProxyURL("bot"), "claw-egress:3128", strings.TrimPrefix(srv.URL, "http://"), 1)
+
+	resp, err := proxyClient(t, proxyURL, nil).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+
+	var denial Denial
+	if err := json.Unmarshal(audit.Bytes(), &denial); err != nil {
+		t.Fatalf("parse audit line %q: %v", audit.String(), err)
+	}
+	if denial.Service != "bot" || denial.Host != "127.0.0.1" || denial.Pod != "ops" || denial.Reason != "not in allowlist" {
+		t.Fatalf("unexpected denial record: %+v", denial)
+	}
+}
+
+func TestProxyRequiresCredentials(t *testing.T) {
+	_, audit, srv := newTestProxy(t, []string{"127.0.0.1"})
+
+	resp, err := proxyClient(t, srv.URL, nil).Get("http://127.0.0.1:1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		t.Fatalf("expected 407, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(audit.String(), `"reason":"unauthenticated"`) {
+		t.Fatalf("expected unauthenticated denial, got %s", audit.String())
+	}
+}
+
+func TestProxyHealthcheck(t *testing.T) {
+	_, _, srv := newTestProxy(t, nil)
+	resp, err := http.Get(srv.URL + HealthcheckPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
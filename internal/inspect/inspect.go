@@ -17,18 +17,24 @@ type InspectInvocation struct {
 }
 
 type ClawInfo struct {
-	ClawType    string
-	Agent       string
-	Models      map[string]string
-	Cllama      []string
-	Persona     string
-	Handles     []string
-	Surfaces    []string
-	Skills      []string
-	Privileges  map[string]string
-	Configures  []string
-	Invocations []InspectInvocation
-	SkillEmit   string // claw.skill.emit label: path to skill file inside image
+	ClawType     string
+	Agent        string
+	Models       map[string]string
+	Cllama       []string
+	Persona      string
+	Handles      []string
+	Surfaces     []string
+	Skills       []string
+	Privileges   map[string]string
+	Configures   []string
+	Tracks       []string
+	Invocations  []InspectInvocation
+	SkillEmit    string // claw.skill.emit label: path to skill file inside image
+	MCPEndpoint  string // claw.mcp.endpoint label: "<port>/<path>" the MCP server listens on
+	MCPTransport string // claw.mcp.transport label: "http" (default) or "sse"
+	OpenAPI      string // claw.openapi label: spec path in the image, or "<port>/<path>" it is served on
+	OpenAPIAuth  string // claw.openapi.auth-env label: "<scheme>=<ENV_VAR>" pairs naming credential env vars
+	DiscoveryEnv string // claw.discovery.env label: pod env var names a discovery container is started with
 }
 
 func ParseLabels(labels map[string]string) *ClawInfo {
@@ -39,6 +45,7 @@ func ParseLabels(labels map[string]string) *ClawInfo {
 		Skills:     make([]string, 0),
 		Privileges: make(map[string]string),
 		Configures: make([]string, 0),
+		Tracks:     make([]string, 0),
 	}
 
 	type indexedEntry struct {
@@ -51,6 +58,7 @@ func ParseLabels(labels map[string]string) *ClawInfo {
 	surfaces := make([]indexedEntry, 0)
 	skills := make([]indexedEntry, 0)
 	configures := make([]indexedEntry, 0)
+	tracks := make([]indexedEntry, 0)
 	invokeEntries := make([]indexedEntry, 0)
 	cllamaByIndex := make(map[int]string)
 
@@ -99,6 +107,16 @@ func ParseLabels(labels map[string]string) *ClawInfo {
 			})
 		case key == "claw.skill.emit":
 			info.SkillEmit = value
+		case key == "claw.mcp.endpoint":
+			info.MCPEndpoint = value
+		case key == "claw.mcp.transport":
+			info.MCPTransport = value
+		case key == "claw.openapi":
+			info.OpenAPI = value
+		case key == "claw.openapi.auth-env":
+			info.OpenAPIAuth = value
+		case key == "claw.discovery.env":
+			info.DiscoveryEnv = value
 		case strings.HasPrefix(key, "claw.skill."):
 			index := maxInt()
 			suffix := strings.TrimPrefix(key, "claw.skill.")
@@ -121,6 +139,17 @@ func ParseLabels(labels map[string]string) *ClawInfo {
 				Key:   key,
 				Value: value,
 			})
+		case strings.HasPrefix(key, "claw.track."):
+			index := maxInt()
+			suffix := strings.TrimPrefix(key, "claw.track.")
+			if parsed, err := strconv.Atoi(suffix); err == nil {
+				index = parsed
+			}
+			tracks = append(tracks, indexedEntry{
+				Index: index,
+				Key:   key,
+				Value: value,
+			})
 		case strings.HasPrefix(key, "claw.invoke."):
 			index := maxInt()
 			suffix := strings.TrimPrefix(key, "claw.invoke.")
@@ -171,6 +200,17 @@ func ParseLabels(labels map[string]string) *ClawInfo {
 		info.Configures = append(info.Configures, configure.Value)
 	}
 
+	sort.Slice(tracks, func(i int, j int) bool {
+		if tracks[i].Index == tracks[j].Index {
+			return tracks[i].Key < tracks[j].Key
+		}
+		return tracks[i].Index < tracks[j].Index
+	})
+
+	for _, track := range tracks {
+		info.Tracks = append(info.Tracks, track.Value)
+	}
+
 	sort.Slice(invokeEntries, func(i, j int) bool {
 		if invokeEntries[i].Index == invokeEntries[j].Index {
 			return invokeEntries[i].Key < invokeEntries[j].Key
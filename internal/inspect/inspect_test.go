@@ -82,6 +82,32 @@ func TestParseLabelsExtractsSkillEmit(t *testing.T) {
 	}
 }
 
+func TestParseLabelsExtractsMCPEndpoint(t *testing.T) {
+	info := ParseLabels(map[string]string{
+		"claw.mcp.endpoint":  "8080/mcp",
+		"claw.mcp.transport": "sse",
+		"claw.discovery.env": "DATABASE_URL",
+	})
+
+	if info.MCPEndpoint != "8080/mcp" || info.MCPTransport != "sse" {
+		t.Fatalf("expected MCP endpoint labels, got %q %q", info.MCPEndpoint, info.MCPTransport)
+	}
+	if info.DiscoveryEnv != "DATABASE_URL" {
+		t.Fatalf("expected discovery env label, got %q", info.DiscoveryEnv)
+	}
+}
+
+func TestParseLabelsExtractsOpenAPI(t *testing.T) {
+	info := ParseLabels(map[string]string{
+		"claw.openapi":          "8080/openapi.json",
+		"claw.openapi.auth-env": "bearerAuth=TRADING_API_TOKEN",
+	})
+
+	if info.OpenAPI != "8080/openapi.json" || info.OpenAPIAuth != "bearerAuth=TRADING_API_TOKEN" {
+		t.Fatalf("expected OpenAPI labels, got %q %q", info.OpenAPI, info.OpenAPIAuth)
+	}
+}
+
 func TestParseLabelsExtractsHandles(t *testing.T) {
 	raw := map[string]string{
 		"claw.type":           "openclaw",
@@ -234,3 +260,17 @@ func TestParseLabelsCllamaLegacyFallback(t *testing.T) {
 		t.Fatalf("expected legacy cllama passthrough, got %v", info.Cllama)
 	}
 }
+
+func TestParseLabelsTracksIndexedOrdering(t *testing.T) {
+	raw := map[string]string{
+		"claw.track.1": "pip",
+		"claw.track.0": "apt",
+	}
+	info := ParseLabels(raw)
+	if len(info.Tracks) != 2 {
+		t.Fatalf("expected 2 tracks, got %d", len(info.Tracks))
+	}
+	if info.Tracks[0] != "apt" || info.Tracks[1] != "pip" {
+		t.Fatalf("unexpected track ordering: %v", info.Tracks)
+	}
+}
@@ -0,0 +1,313 @@
+package mockllm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRequestBytes bounds request bodies; agent prompts are far smaller.
+const maxRequestBytes = 8 << 20
+
+// Handler serves the OpenAI chat completions API and the Anthropic messages
+// API. Routes are matched by suffix, so both "/v1/chat/completions" and a
+// client that appends "/v1/messages" to a base URL already ending in /v1 work.
+type Handler struct {
+	Script *Script
+	Log    io.Writer        // one JSON line per completion; nil disables
+	Now    func() time.Time // defaults to time.Now
+
+	mu sync.Mutex
+}
+
+// NewHandler returns a handler replying from script.
+func NewHandler(script *Script, log io.Writer) *Handler {
+	return &Handler{Script: script, Log: log}
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type completionRequest struct {
+	Model    string          `json:"model"`
+	System   json.RawMessage `json:"system"`
+	Messages []chatMessage   `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+// LogEntry is the record written to Log for each completion.
+type LogEntry struct {
+	Time             string `json:"time"`
+	API              string `json:"api"`
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	Stream           bool   `json:"stream,omitempty"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == HealthcheckPath:
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "ok\n")
+	case strings.HasSuffix(path, "/models") && r.Method == http.MethodGet:
+		h.models(w)
+	case strings.HasSuffix(path, "/chat/completions") && r.Method == http.MethodPost:
+		h.complete(w, r, "openai")
+	case strings.HasSuffix(path, "/messages") && r.Method == http.MethodPost:
+		h.complete(w, r, "anthropic")
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("claw-mock-llm: no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (h *Handler) complete(w http.ResponseWriter, r *http.Request, api string) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "claw-mock-llm: read request: "+err.Error())
+		return
+	}
+	var req completionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "claw-mock-llm: parse request: "+err.Error())
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "claw-mock-llm: messages must not be empty")
+		return
+	}
+
+	prompt := contentText(req.System)
+	lastUser := ""
+	for _, m := range req.Messages {
+		text := contentText(m.Content)
+		prompt += text
+		if m.Role == "user" {
+			lastUser = text
+		}
+	}
+	reply := h.Script.Reply(req.Model, lastUser)
+	usage := tokenUsage{prompt: EstimateTokens(prompt), completion: EstimateTokens(reply)}
+	id := requestID(body)
+	h.log(api, req, usage)
+
+	if api == "anthropic" {
+		if req.Stream {
+			streamAnthropic(w, id, req.Model, reply, usage)
+			return
+		}
+		writeJSON(w, anthropicResponse(id, req.Model, reply, usage))
+		return
+	}
+	created := h.now().Unix()
+	if req.Stream {
+		streamOpenAI(w, id, created, req.Model, reply, usage)
+		return
+	}
+	writeJSON(w, openAIResponse(id, created, req.Model, reply, usage))
+}
+
+func (h *Handler) models(w http.ResponseWriter) {
+	writeJSON(w, map[string]interface{}{
+		"object": "list",
+		"data": []map[string]interface{}{
+			{"id": "mock", "object": "model", "created": 0, "owned_by": ServiceName},
+		},
+	})
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func (h *Handler) log(api string, req completionRequest, usage tokenUsage) {
+	if h.Log == nil {
+		return
+	}
+	data, err := json.Marshal(LogEntry{
+		Time:             h.now().UTC().Format(time.RFC3339),
+		API:              api,
+		Model:            req.Model,
+		PromptTokens:     usage.prompt,
+		CompletionTokens: usage.completion,
+		Stream:           req.Stream,
+	})
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, _ = h.Log.Write(append(data, '\n'))
+}
+
+type tokenUsage struct {
+	prompt     int
+	completion int
+}
+
+func (u tokenUsage) openAI() map[string]int {
+	return map[string]int{
+		"prompt_tokens":     u.prompt,
+		"completion_tokens": u.completion,
+		"total_tokens":      u.prompt + u.completion,
+	}
+}
+
+func openAIResponse(id string, created int64, model, reply string, usage tokenUsage) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      "chatcmpl-" + id,
+		"object":  "chat.completion",
+		"created": created,
+		"model":   model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": reply},
+			"finish_reason": "stop",
+		}},
+		"usage": usage.openAI(),
+	}
+}
+
+// streamOpenAI sends the reply as one content chunk, a finish chunk carrying
+// usage (as with stream_options.include_usage) and the [DONE] sentinel.
+func streamOpenAI(w http.ResponseWriter, id string, created int64, model, reply string, usage tokenUsage) {
+	startSSE(w)
+	chunk := func(delta map[string]string, finish interface{}, withUsage bool) map[string]interface{} {
+		c := map[string]interface{}{
+			"id":      "chatcmpl-" + id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]interface{}{{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+		if withUsage {
+			c["usage"] = usage.openAI()
+		}
+		return c
+	}
+	writeSSE(w, "", chunk(map[string]string{"role": "assistant", "content": reply}, nil, false))
+	writeSSE(w, "", chunk(map[string]string{}, "stop", true))
+	_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	flush(w)
+}
+
+func anthropicResponse(id, model, reply string, usage tokenUsage) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            "msg_" + id,
+		"type":          "message",
+		"role":          "assistant",
+		"model":         model,
+		"content":       []map[string]string{{"type": "text", "text": reply}},
+		"stop_reason":   "end_turn",
+		"stop_sequence": nil,
+		"usage":         map[string]int{"input_tokens": usage.prompt, "output_tokens": usage.completion},
+	}
+}
+
+// streamAnthropic emits the Anthropic messages event sequence for a single
+// text block.
+func streamAnthropic(w http.ResponseWriter, id, model, reply string, usage tokenUsage) {
+	startSSE(w)
+	message := anthropicResponse(id, model, "", tokenUsage{prompt: usage.prompt})
+	message["content"] = []interface{}{}
+	message["stop_reason"] = nil
+	writeSSE(w, "message_start", map[string]interface{}{"type": "message_start", "message": message})
+	writeSSE(w, "content_block_start", map[string]interface{}{
+		"type": "content_block_start", "index": 0,
+		"content_block": map[string]string{"type": "text", "text": ""},
+	})
+	writeSSE(w, "content_block_delta", map[string]interface{}{
+		"type": "content_block_delta", "index": 0,
+		"delta": map[string]string{"type": "text_delta", "text": reply},
+	})
+	writeSSE(w, "content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": 0})
+	writeSSE(w, "message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": "end_turn", "stop_sequence": nil},
+		"usage": map[string]int{"output_tokens": usage.completion},
+	})
+	writeSSE(w, "message_stop", map[string]string{"type": "message_stop"})
+	flush(w)
+}
+
+// contentText flattens a message content field: a plain string, or a list of
+// typed parts of which only text parts count.
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Type == "text" || p.Type == "input_text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// requestID derives a stable response id from the request body so identical
+// requests get identical responses.
+func requestID(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])[:24]
+}
+
+func startSSE(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeSSE(w http.ResponseWriter, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	if event != "" {
+		_, _ = fmt.Fprintf(w, "event: %s\n", event)
+	}
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+	flush(w)
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"type": "invalid_request_error", "message": msg},
+	})
+}
@@ -0,0 +1,213 @@
+package mockllm
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, script *Script) (*httptest.Server, *bytes.Buffer) {
+	t.Helper()
+	var log bytes.Buffer
+	h := NewHandler(script, &log)
+	h.Now = func() time.Time { return time.Unix(1700000000, 0) }
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv, &log
+}
+
+func post(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletionEchoesWithUsage(t *testing.T) {
+	srv, log := newTestServer(t, &Script{})
+	body := `{"model":"anthropic/claude-sonnet-4","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hello there"}]}`
+
+	resp := post(t, srv.URL+"/v1/chat/completions", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	var got struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage map[string]int `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != "anthropic/claude-sonnet-4" || got.Created != 1700000000 {
+		t.Fatalf("unexpected envelope: %+v", got)
+	}
+	if len(got.Choices) != 1 || got.Choices[0].Message.Content != "mock: hello there" || got.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected choices: %+v", got.Choices)
+	}
+	wantPrompt := EstimateTokens("be briefhello there")
+	if got.Usage["prompt_tokens"] != wantPrompt || got.Usage["completion_tokens"] != EstimateTokens("mock: hello there") ||
+		got.Usage["total_tokens"] != got.Usage["prompt_tokens"]+got.Usage["completion_tokens"] {
+		t.Fatalf("unexpected usage: %v", got.Usage)
+	}
+
+	again := post(t, srv.URL+"/v1/chat/completions", body)
+	var second struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(again.Body).Decode(&second); err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != got.ID {
+		t.Fatalf("expected deterministic id, got %q and %q", got.ID, second.ID)
+	}
+	if !strings.Contains(log.String(), `"api":"openai"`) {
+		t.Fatalf("expected completion log, got %q", log.String())
+	}
+}
+
+func TestChatCompletionUsesScriptAndContentParts(t *testing.T) {
+	script := &Script{
+		Rules: []Rule{
+			{Match: "weather", Model: "openai/gpt-4o", Reply: "model-specific"},
+			{Match: "WEATHER", Reply: "sunny"},
+		},
+		Default: "no idea",
+	}
+	srv, _ := newTestServer(t, script)
+
+	resp := post(t, srv.URL+"/v1/chat/completions", `{"model":"x/y","messages":[{"role":"user","content":[{"type":"text","text":"what is the weather"}]}]}`)
+	var got struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Choices[0].Message.Content != "sunny" {
+		t.Fatalf("expected scripted reply, got %q", got.Choices[0].Message.Content)
+	}
+
+	if reply := script.Reply("x/y", "anything else"); reply != "no idea" {
+		t.Fatalf("expected default reply, got %q", reply)
+	}
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	srv, _ := newTestServer(t, &Script{})
+	resp := post(t, srv.URL+"/v1/chat/completions", `{"model":"m/m","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+	buf := new(bytes.Buffer)
+	_, _ = buf.ReadFrom(resp.Body)
+	out := buf.String()
+	for _, want := range []string{`"content":"mock: hi"`, `"finish_reason":"stop"`, `"total_tokens"`, "data: [DONE]"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stream missing %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestAnthropicMessages(t *testing.T) {
+	srv, _ := newTestServer(t, &Script{})
+	// A base URL ending in /v1 plus an SDK that appends /v1/messages.
+	resp := post(t, srv.URL+"/v1/v1/messages", `{"model":"claude-sonnet-4","max_tokens":64,"system":"sys","messages":[{"role":"user","content":"ping"}]}`)
+	var got struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string         `json:"stop_reason"`
+		Usage      map[string]int `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "message" || len(got.Content) != 1 || got.Content[0].Text != "mock: ping" || got.StopReason != "end_turn" {
+		t.Fatalf("unexpected message: %+v", got)
+	}
+	if got.Usage["input_tokens"] != EstimateTokens("sysping") || got.Usage["output_tokens"] != EstimateTokens("mock: ping") {
+		t.Fatalf("unexpected usage: %v", got.Usage)
+	}
+
+	stream := post(t, srv.URL+"/v1/messages", `{"model":"claude-sonnet-4","stream":true,"messages":[{"role":"user","content":"ping"}]}`)
+	buf := new(bytes.Buffer)
+	_, _ = buf.ReadFrom(stream.Body)
+	for _, want := range []string{"event: message_start", "event: content_block_delta", `"text":"mock: ping"`, "event: message_stop"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("stream missing %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestHandlerRejectsBadRequests(t *testing.T) {
+	srv, _ := newTestServer(t, &Script{})
+	if resp := post(t, srv.URL+"/v1/chat/completions", `{"model":"m","messages":[]}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty messages, got %d", resp.StatusCode)
+	}
+	if resp := post(t, srv.URL+"/v1/embeddings", `{}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown route, got %d", resp.StatusCode)
+	}
+	resp, err := http.Get(srv.URL + HealthcheckPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("healthcheck status %d", resp.StatusCode)
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	if err := os.WriteFile(good, []byte(`{"rules":[{"match":"hi","reply":"hello"}],"default":"ok"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadScript(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Rules) != 1 || s.Default != "ok" {
+		t.Fatalf("unexpected script: %+v", s)
+	}
+
+	for name, content := range map[string]string{
+		"unknown.json": `{"replies":[]}`,
+		"empty.json":   `{"rules":[{"match":"hi"}]}`,
+		"nomatch.json": `{"rules":[{"reply":"x"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadScript(path); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	if s, err := LoadScript(""); err != nil || len(s.Rules) != 0 {
+		t.Fatalf("expected empty script for empty path, got %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package mockllm implements the deterministic OpenAI-compatible stub that
+// claw up --mock-llm injects on claw-internal, so pods can be exercised in CI
+// without provider keys or spend.
+package mockllm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Well-known names and paths shared by claw up and the stub binary.
+const (
+	ServiceName     = "claw-mock-llm"
+	Port            = "8080"
+	ImageRef        = "ghcr.io/mostlydev/clawmockllm:latest"
+	ScriptPath      = "/claw/mock-llm-script.json"
+	HealthcheckPath = "/healthz"
+	// Token is the bearer agents present when they call the stub directly.
+	// The stub accepts any credential; the value only satisfies drivers that
+	// refuse an empty key.
+	Token = "claw-mock-llm"
+)
+
+// BaseURL is the OpenAI-compatible endpoint of the stub on claw-internal.
+func BaseURL() string {
+	return fmt.Sprintf("http://%s:%s/v1", ServiceName, Port)
+}
+
+// Script scripts replies. Rules are tried in order against the last user
+// message; the first match wins. Without a match the stub replies with
+// Default, or echoes the message when Default is empty.
+type Script struct {
+	Rules   []Rule `json:"rules,omitempty"`
+	Default string `json:"default,omitempty"`
+}
+
+// Rule replies with Reply when the last user message contains Match
+// (case-insensitive) and, if set, the request model equals Model.
+type Rule struct {
+	Match string `json:"match"`
+	Model string `json:"model,omitempty"`
+	Reply string `json:"reply"`
+}
+
+// LoadScript reads and validates a script file. An empty path yields the
+// echo-only script.
+func LoadScript(path string) (*Script, error) {
+	if strings.TrimSpace(path) == "" {
+		return &Script{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mock-llm script %q: %w", path, err)
+	}
+	var s Script
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parse mock-llm script %q: %w", path, err)
+	}
+	for i, r := range s.Rules {
+		if strings.TrimSpace(r.Match) == "" && strings.TrimSpace(r.Model) == "" {
+			return nil, fmt.Errorf("mock-llm script %q: rule %d needs match or model", path, i)
+		}
+		if r.Reply == "" {
+			return nil, fmt.Errorf("mock-llm script %q: rule %d has an empty reply", path, i)
+		}
+	}
+	return &s, nil
+}
+
+// Reply returns the scripted response for a request.
+func (s *Script) Reply(model, lastUser string) string {
+	if s != nil {
+		lower := strings.ToLower(lastUser)
+		for _, r := range s.Rules {
+			if r.Model != "" && r.Model != model {
+				continue
+			}
+			if r.Match != "" && !strings.Contains(lower, strings.ToLower(r.Match)) {
+				continue
+			}
+			return r.Reply
+		}
+		if s.Default != "" {
+			return s.Default
+		}
+	}
+	return "mock: " + lastUser
+}
+
+// EstimateTokens approximates a tokenizer at four characters per token so
+// usage blocks scale with content without depending on a real tokenizer.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
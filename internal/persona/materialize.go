@@ -28,7 +28,7 @@ func Materialize(baseDir, runtimeDir, ref string) (*ResolvedPersona, error) {
 	}
 
 	targetDir := filepath.Join(runtimeDir, "persona")
-	if err := os.RemoveAll(targetDir); err != nil {
+	if err := emptyDir(targetDir); err != nil {
 		return nil, fmt.Errorf("reset persona dir: %w", err)
 	}
 	if err := os.MkdirAll(targetDir, 0o700); err != nil {
@@ -52,6 +52,24 @@ func Materialize(baseDir, runtimeDir, ref string) (*ResolvedPersona, error) {
 	return &ResolvedPersona{Ref: ref, HostPath: targetDir}, nil
 }
 
+// emptyDir removes the contents of dir but keeps the directory itself, so a
+// running container that bind-mounts it sees the new persona.
+func emptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func isLocalRef(ref string) bool {
 	return strings.HasPrefix(ref, ".") || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "file://")
 }
@@ -156,18 +174,18 @@ func pullRemotePersona(ctx context.Context, targetDir, ref string) error {
 		return fmt.Errorf("parse persona reference %q: %w", ref, err)
 	}
 
-	repo, err := remote.NewRepository(parsed.Registry + "/" + parsed.Repository)
+	repo, err := newRepository(parsed)
 	if err != nil {
 		return fmt.Errorf("create persona repository %q: %w", ref, err)
 	}
 
-	if store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{}); err == nil {
-		repo.Client = &auth.Client{
-			Credential: credentials.Credential(store),
-			Cache:      auth.NewCache(),
-		}
+	if err := pullPersona(ctx, targetDir, repo, parsed.ReferenceOrDefault()); err != nil {
+		return fmt.Errorf("pull persona %q: %w", ref, err)
 	}
+	return nil
+}
 
+func pullPersona(ctx context.Context, targetDir string, src oras.ReadOnlyTarget, reference string) error {
 	fs, err := file.New(targetDir)
 	if err != nil {
 		return fmt.Errorf("create persona file store: %w", err)
@@ -176,8 +194,28 @@ func pullRemotePersona(ctx context.Context, targetDir, ref string) error {
 	fs.IgnoreNoName = true
 	fs.PreservePermissions = true
 
-	if _, err := oras.Copy(ctx, repo, parsed.ReferenceOrDefault(), fs, "latest", oras.DefaultCopyOptions); err != nil {
-		return fmt.Errorf("pull persona %q: %w", ref, err)
+	// The file store drops unnamed blobs such as the manifest, so copy the
+	// graph by descriptor instead of tagging it in the store.
+	root, err := src.Resolve(ctx, reference)
+	if err != nil {
+		return err
 	}
-	return nil
+	return oras.CopyGraph(ctx, src, fs, root, oras.DefaultCopyGraphOptions)
+}
+
+// newRepository returns a remote repository client authenticated with the
+// local Docker credential store when one is available.
+func newRepository(parsed registry.Reference) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(parsed.Registry + "/" + parsed.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{}); err == nil {
+		repo.Client = &auth.Client{
+			Credential: credentials.Credential(store),
+			Cache:      auth.NewCache(),
+		}
+	}
+	return repo, nil
 }
@@ -0,0 +1,107 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ArtifactType is the OCI artifact type of pushed persona workspaces.
+const ArtifactType = "application/vnd.clawdapus.persona.v1"
+
+// Artifact is a persona workspace packed into a local OCI store. Its Digest is
+// final before anything leaves the host, so a caller can record it first and
+// push afterwards.
+type Artifact struct {
+	Digest string
+	ref    registry.Reference
+	store  *file.Store
+}
+
+// Pack packages a persona workspace directory as an OCI artifact for ref. Each
+// top-level entry becomes one layer titled with its name, so a later PERSONA
+// pull restores the same layout. Close releases the local store.
+func Pack(ctx context.Context, sourceDir, ref string, annotations map[string]string) (*Artifact, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("parse persona reference %q: %w", ref, err)
+	}
+	if parsed.Reference == "" {
+		parsed.Reference = "latest"
+	}
+	return packPersona(ctx, sourceDir, parsed, annotations)
+}
+
+// Push uploads the packed artifact to the registry named by its ref.
+func (a *Artifact) Push(ctx context.Context) error {
+	repo, err := newRepository(a.ref)
+	if err != nil {
+		return fmt.Errorf("create persona repository %q: %w", a.ref, err)
+	}
+	return a.pushTo(ctx, repo)
+}
+
+// Close releases the local store.
+func (a *Artifact) Close() error {
+	return a.store.Close()
+}
+
+func (a *Artifact) pushTo(ctx context.Context, dst oras.Target) error {
+	if _, err := oras.Copy(ctx, a.store, a.ref.Reference, dst, a.ref.Reference, oras.DefaultCopyOptions); err != nil {
+		return fmt.Errorf("push persona: %w", err)
+	}
+	return nil
+}
+
+func packPersona(ctx context.Context, sourceDir string, ref registry.Reference, annotations map[string]string) (*Artifact, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("read persona dir %q: %w", sourceDir, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink != 0 {
+			return nil, fmt.Errorf("persona path %q contains unsupported symlink %q", sourceDir, entry.Name())
+		}
+		names = append(names, entry.Name())
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("persona dir %q is empty", sourceDir)
+	}
+	sort.Strings(names)
+
+	fs, err := file.New(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("create persona file store: %w", err)
+	}
+
+	layers := make([]ocispec.Descriptor, 0, len(names))
+	for _, name := range names {
+		desc, err := fs.Add(ctx, name, "", name)
+		if err != nil {
+			fs.Close()
+			return nil, fmt.Errorf("add persona entry %q: %w", name, err)
+		}
+		layers = append(layers, desc)
+	}
+
+	manifest, err := oras.PackManifest(ctx, fs, oras.PackManifestVersion1_1, ArtifactType, oras.PackManifestOptions{
+		Layers:              layers,
+		ManifestAnnotations: annotations,
+	})
+	if err != nil {
+		fs.Close()
+		return nil, fmt.Errorf("pack persona manifest: %w", err)
+	}
+	if err := fs.Tag(ctx, manifest, ref.Reference); err != nil {
+		fs.Close()
+		return nil, fmt.Errorf("tag persona manifest: %w", err)
+	}
+	return &Artifact{Digest: manifest.Digest.String(), ref: ref, store: fs}, nil
+}
@@ -0,0 +1,61 @@
+package persona
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestPackPersonaRoundTripsThroughPull(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "history"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "style.md"), []byte("dry humor\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "history", "memory.txt"), []byte("first trade\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	store := memory.New()
+	artifact, err := Pack(ctx, srcDir, "registry.example.com/allen:good-day", map[string]string{"claw.snapshot.service": "allen"})
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	defer artifact.Close()
+	if !strings.HasPrefix(artifact.Digest, "sha256:") {
+		t.Fatalf("unexpected digest %q", artifact.Digest)
+	}
+	if err := artifact.pushTo(ctx, store); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if desc, err := store.Resolve(ctx, "good-day"); err != nil || desc.Digest.String() != artifact.Digest {
+		t.Fatalf("expected pushed manifest to match the packed digest, got %v (%v)", desc.Digest, err)
+	}
+
+	targetDir := t.TempDir()
+	if err := pullPersona(ctx, targetDir, store, "good-day"); err != nil {
+		t.Fatalf("pullPersona: %v", err)
+	}
+	style, err := os.ReadFile(filepath.Join(targetDir, "style.md"))
+	if err != nil || string(style) != "dry humor\n" {
+		t.Fatalf("expected style.md restored, got %q (%v)", style, err)
+	}
+	memoryFile, err := os.ReadFile(filepath.Join(targetDir, "history", "memory.txt"))
+	if err != nil || string(memoryFile) != "first trade\n" {
+		t.Fatalf("expected history/memory.txt restored, got %q (%v)", memoryFile, err)
+	}
+}
+
+func TestPackPersonaRejectsEmptyDirectory(t *testing.T) {
+	_, err := Pack(context.Background(), t.TempDir(), "registry.example.com/allen", nil)
+	if err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Fatalf("expected empty persona error, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package pod
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mostlydev/clawdapus/internal/cllama"
+)
+
+// parseCllamaPolicy converts an x-claw.cllama-policy block, declared on a
+// service or as pod-level defaults:
+//
+//	x-claw:
+//	  cllama-policy:
+//	    daily-budget-usd: 5
+//	    monthly-budget-usd: 100
+//	    requests-per-minute: 30
+//	    tokens-per-day: 2000000
+//	    allowed-models: [anthropic/*, openai/gpt-4o-mini]
+//	    max-output-tokens: 4096
+func parseCllamaPolicy(raw interface{}) (*cllama.Policy, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cllama-policy must be a map, got %T", raw)
+	}
+	if len(m) == 0 {
+		return nil, fmt.Errorf("cllama-policy block is empty")
+	}
+
+	p := &cllama.Policy{}
+	for _, key := range sortedMapKeys(m) {
+		val := m[key]
+		var err error
+		switch key {
+		case "daily-budget-usd":
+			p.DailyBudgetUSD, err = policyBudget(key, val)
+		case "monthly-budget-usd":
+			p.MonthlyBudgetUSD, err = policyBudget(key, val)
+		case "requests-per-minute":
+			p.RequestsPerMinute, err = policyLimit(key, val)
+		case "tokens-per-day":
+			p.TokensPerDay, err = policyLimit(key, val)
+		case "max-output-tokens":
+			p.MaxOutputTokens, err = policyLimit(key, val)
+		case "allowed-models":
+			p.AllowedModels, err = policyModels(val)
+		default:
+			err = fmt.Errorf("cllama-policy: unknown key %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkPolicyBudgets(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// checkPolicyBudgets rejects a daily budget above the monthly one. It runs on
+// each declared block and again on the service policy merged over pod
+// defaults, where the two budgets may come from different blocks.
+func checkPolicyBudgets(p *cllama.Policy) error {
+	if p != nil && p.DailyBudgetUSD > 0 && p.MonthlyBudgetUSD > 0 && p.DailyBudgetUSD > p.MonthlyBudgetUSD {
+		return fmt.Errorf("cllama-policy: daily-budget-usd (%g) exceeds monthly-budget-usd (%g)", p.DailyBudgetUSD, p.MonthlyBudgetUSD)
+	}
+	return nil
+}
+
+func policyBudget(key string, raw interface{}) (float64, error) {
+	var v float64
+	switch n := raw.(type) {
+	case int:
+		v = float64(n)
+	case float64:
+		v = n
+	default:
+		return 0, fmt.Errorf("cllama-policy.%s must be a number, got %T", key, raw)
+	}
+	if v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("cllama-policy.%s must be greater than 0", key)
+	}
+	return v, nil
+}
+
+func policyLimit(key string, raw interface{}) (int, error) {
+	n, ok := raw.(int)
+	if !ok {
+		return 0, fmt.Errorf("cllama-policy.%s must be an integer, got %T", key, raw)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("cllama-policy.%s must be greater than 0", key)
+	}
+	return n, nil
+}
+
+func policyModels(raw interface{}) ([]string, error) {
+	models, err := parseStringOrList(raw)
+	if err != nil {
+		return nil, fmt.Errorf("cllama-policy.allowed-models: %w", err)
+	}
+	for i, model := range models {
+		model = strings.TrimSpace(model)
+		if !strings.Contains(model, "/") {
+			return nil, fmt.Errorf("cllama-policy.allowed-models: %q must be <provider>/<model>", model)
+		}
+		models[i] = model
+	}
+	return models, nil
+}
+
+// ValidateCllamaCredentials checks x-claw.cllama-credentials: per-agent
+// provider keys that are written into the agent's proxy context instead of
+// the shared proxy environment. The parser checks the declared values; claw
+// up checks them again once ${VAR} references are expanded.
+func ValidateCllamaCredentials(creds map[string]string) error {
+	keys := make([]string, 0, len(creds))
+	for k := range creds {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !describeEnvPattern.MatchString(key) {
+			return fmt.Errorf("cllama-credentials: %q is not an environment variable name", key)
+		}
+		if strings.TrimSpace(creds[key]) == "" {
+			return fmt.Errorf("cllama-credentials: %q must not be empty", key)
+		}
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/mostlydev/clawdapus/internal/cllama"
 	"github.com/mostlydev/clawdapus/internal/driver"
+	"github.com/mostlydev/clawdapus/internal/egress"
+	"github.com/mostlydev/clawdapus/internal/mockllm"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +22,7 @@ type CllamaProxyConfig struct {
 	AuthHostDir    string            // host path for provider auth state
 	DashboardPort  string            // host port published to proxy UI :8081 (default "8181")
 	Environment    map[string]string // proxy-only env (e.g. CLAW_POD, provider keys)
+	Upstream       string            // next proxy type in the chain; "" when the proxy calls the provider
 	PodName        string
 }
 
@@ -32,6 +35,26 @@ type ClawdashConfig struct {
 	PodName            string
 }
 
+// EgressConfig enables the pod egress proxy. When set, claw-internal becomes an
+// internal network and every claw reaches the outside only through the proxy;
+// non-claw services keep their default network.
+type EgressConfig struct {
+	Image          string            // e.g. ghcr.io/mostlydev/clawegress:latest
+	PolicyHostPath string            // host path to egress-policy.json
+	LogHostDir     string            // host dir for the denial audit log
+	ProxyURLs      map[string]string // expanded service name -> HTTP(S)_PROXY value
+	PodName        string
+}
+
+// MockLLMConfig enables the deterministic LLM stub on claw-internal. Script is
+// declared in x-claw.mock-llm; claw up fills in the rest.
+type MockLLMConfig struct {
+	Script         string // pod-relative reply script; "" echoes
+	Image          string // e.g. ghcr.io/mostlydev/clawmockllm:latest
+	ScriptHostPath string // resolved host path of Script
+	PodName        string
+}
+
 // EmitCompose generates a compose.generated.yml string from pod definition and
 // driver materialization results. Output is deterministic (sorted service names).
 func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies ...CllamaProxyConfig) (string, error) {
@@ -55,6 +78,18 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 		}
 	}
 
+	egressOn := p.Egress != nil
+	var noProxy string
+	if egressOn {
+		if strings.TrimSpace(p.Egress.Image) == "" {
+			return "", fmt.Errorf("egress proxy image must not be empty")
+		}
+		if strings.TrimSpace(p.Egress.PolicyHostPath) == "" {
+			return "", fmt.Errorf("egress policy host path must not be empty")
+		}
+		noProxy = egressNoProxy(p, proxies)
+	}
+
 	// Compute pod-wide CLAW_HANDLE_* env vars from all claw service handles.
 	// These are injected into every service (claw and non-claw) at lowest priority.
 	handleEnvs := computeHandleEnvs(p.Services)
@@ -93,6 +128,18 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 			}
 		}
 
+		var privileges *PrivilegeSpec
+		if svc.Claw != nil {
+			spec, err := ParsePrivileges(svc.Claw.Privileges)
+			if err != nil {
+				return "", fmt.Errorf("service %q: %w", name, err)
+			}
+			privileges = spec
+		}
+
+		// Egress enforcement is default-deny: every claw goes through the proxy.
+		policed := egressOn && isClaw
+
 		// Collect volume surfaces for this service
 		var volumeMounts []interface{}
 		if svc.Claw != nil {
@@ -181,8 +228,18 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 			} else if _, isTarget := serviceSurfaceTargets[name]; isTarget {
 				attachClawInternal = true
 			}
+			if policed && serviceOut["networks"] != nil {
+				return "", fmt.Errorf("service %q: declares its own networks under egress enforcement; remove networks so outbound traffic can only leave through the egress proxy", serviceName)
+			}
 			if attachClawInternal {
-				networks, err := mergedNetworks(serviceOut["networks"], "claw-internal")
+				base := serviceOut["networks"]
+				if egressOn && !policed && base == nil {
+					// claw-internal is internal under egress enforcement; keep the
+					// implicit default network of non-claw services so their own
+					// egress is unchanged.
+					base = []string{"default"}
+				}
+				networks, err := mergedNetworks(base, "claw-internal")
 				if err != nil {
 					return "", fmt.Errorf("service %q: networks: %w", serviceName, err)
 				}
@@ -228,6 +285,20 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 					env["CLLAMA_TOKEN"] = tok
 				}
 			}
+			if policed {
+				proxyURL := p.Egress.ProxyURLs[serviceName]
+				if proxyURL == "" {
+					proxyURL = p.Egress.ProxyURLs[name]
+				}
+				if proxyURL == "" {
+					return "", fmt.Errorf("service %q: no egress proxy credential", serviceName)
+				}
+				for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+					env[key] = proxyURL
+				}
+				env["NO_PROXY"] = noProxy
+				env["no_proxy"] = noProxy
+			}
 			if len(env) > 0 {
 				serviceOut["environment"] = env
 			}
@@ -235,6 +306,9 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 			if isClaw || explicitResult {
 				serviceOut["read_only"] = result.ReadOnly
 			}
+			if err := applyPrivileges(serviceOut, privileges); err != nil {
+				return "", fmt.Errorf("service %q: %w", serviceName, err)
+			}
 			if result.Restart != "" {
 				serviceOut["restart"] = result.Restart
 			}
@@ -253,6 +327,13 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 		}
 	}
 
+	// Infra sidecars that reach the outside (LLM providers) or publish host
+	// ports also join the non-internal egress network when enforcement is on.
+	infraNetworks := []string{"claw-internal"}
+	if egressOn {
+		infraNetworks = []string{"claw-internal", egress.NetworkName}
+	}
+
 	for _, proxy := range proxies {
 		if strings.TrimSpace(proxy.ProxyType) == "" {
 			return "", fmt.Errorf("proxy type must not be empty")
@@ -277,6 +358,15 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 		for k, v := range proxy.Environment {
 			env[k] = v
 		}
+		if strings.TrimSpace(proxy.Upstream) != "" {
+			// Non-terminal hops forward the agent's bearer token unchanged, so the
+			// next proxy resolves identity from the same shared context.
+			env["CLAW_UPSTREAM_URL"] = cllama.ProxyBaseURL(proxy.Upstream)
+		} else if p.MockLLM != nil {
+			// The terminal hop treats the mock like a next hop: same API, no
+			// provider credentials required.
+			env["CLAW_UPSTREAM_URL"] = mockllm.BaseURL()
+		}
 
 		rootServices[serviceName] = map[string]interface{}{
 			"image": proxy.Image,
@@ -299,7 +389,7 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 				"claw.proxy.type": proxy.ProxyType,
 				"claw.service":    serviceName,
 			},
-			"networks": []string{"claw-internal"},
+			"networks": infraNetworks,
 		}
 	}
 
@@ -352,8 +442,73 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 				"claw.role":    "dashboard",
 				"claw.service": "clawdash",
 			},
+			"networks": infraNetworks,
+		}
+	}
+
+	if hasClaw && egressOn {
+		volumes := []string{fmt.Sprintf("%s:%s:ro", p.Egress.PolicyHostPath, egress.PolicyPath)}
+		env := map[string]string{
+			"CLAW_POD":           p.Egress.PodName,
+			"CLAW_EGRESS_POLICY": egress.PolicyPath,
+		}
+		if strings.TrimSpace(p.Egress.LogHostDir) != "" {
+			volumes = append(volumes, fmt.Sprintf("%s:%s:rw", p.Egress.LogHostDir, egress.LogDir))
+			env["CLAW_EGRESS_LOG_DIR"] = egress.LogDir
+		}
+		rootServices[egress.ServiceName] = map[string]interface{}{
+			"image":       p.Egress.Image,
+			"read_only":   true,
+			"volumes":     volumes,
+			"environment": env,
+			"restart":     "on-failure",
+			"healthcheck": map[string]interface{}{
+				"test":     []string{"CMD", "/clawegress", "-healthcheck"},
+				"interval": "15s",
+				"timeout":  "5s",
+				"retries":  3,
+			},
+			"labels": map[string]string{
+				"claw.pod":     p.Egress.PodName,
+				"claw.role":    "egress",
+				"claw.service": egress.ServiceName,
+			},
+			"networks": []string{"claw-internal", egress.NetworkName},
+		}
+	}
+
+	if hasClaw && p.MockLLM != nil {
+		if strings.TrimSpace(p.MockLLM.Image) == "" {
+			return "", fmt.Errorf("mock-llm image must not be empty")
+		}
+		env := map[string]string{"CLAW_POD": p.MockLLM.PodName}
+		volumes := make([]string, 0, 1)
+		if strings.TrimSpace(p.MockLLM.ScriptHostPath) != "" {
+			volumes = append(volumes, fmt.Sprintf("%s:%s:ro", p.MockLLM.ScriptHostPath, mockllm.ScriptPath))
+			env["CLAW_MOCK_LLM_SCRIPT"] = mockllm.ScriptPath
+		}
+		service := map[string]interface{}{
+			"image":       p.MockLLM.Image,
+			"read_only":   true,
+			"environment": env,
+			"restart":     "on-failure",
+			"healthcheck": map[string]interface{}{
+				"test":     []string{"CMD", "/clawmockllm", "-healthcheck"},
+				"interval": "15s",
+				"timeout":  "5s",
+				"retries":  3,
+			},
+			"labels": map[string]string{
+				"claw.pod":     p.MockLLM.PodName,
+				"claw.role":    "mock-llm",
+				"claw.service": mockllm.ServiceName,
+			},
 			"networks": []string{"claw-internal"},
 		}
+		if len(volumes) > 0 {
+			service["volumes"] = volumes
+		}
+		rootServices[mockllm.ServiceName] = service
 	}
 
 	root["services"] = rootServices
@@ -367,13 +522,20 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 	}
 
 	// Add claw-internal network if any claw services exist.
-	// Not internal: claw agents need internet access for LLM APIs, Discord, Slack, etc.
-	// Service isolation is still achieved — only explicitly-attached containers can
-	// communicate on this network.
+	// Not internal by default: claw agents need internet access for LLM APIs,
+	// Discord, Slack, etc. Service isolation is still achieved — only
+	// explicitly-attached containers can communicate on this network. With egress
+	// enforcement it becomes internal and claw-egress carries outbound traffic for
+	// the proxy and infra sidecars.
 	if hasClaw {
-		networks, err := mergedNamedMap(root["networks"], map[string]interface{}{
+		added := map[string]interface{}{
 			"claw-internal": map[string]interface{}{},
-		})
+		}
+		if egressOn {
+			added["claw-internal"] = map[string]interface{}{"internal": true}
+			added[egress.NetworkName] = map[string]interface{}{}
+		}
+		networks, err := mergedNamedMap(root["networks"], added)
 		if err != nil {
 			return "", fmt.Errorf("emit compose: networks: %w", err)
 		}
@@ -388,6 +550,34 @@ func EmitCompose(p *Pod, results map[string]*driver.MaterializeResult, proxies .
 	return string(data), nil
 }
 
+// egressNoProxy lists the in-pod destinations claw services reach directly on
+// claw-internal rather than through the egress proxy.
+func egressNoProxy(p *Pod, proxies []CllamaProxyConfig) string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	for _, name := range sortedServiceNames(p.Services) {
+		svc := p.Services[name]
+		hosts = append(hosts, name)
+		if svc.Claw != nil && svc.Claw.Count > 1 {
+			for i := 0; i < svc.Claw.Count; i++ {
+				hosts = append(hosts, fmt.Sprintf("%s-%d", name, i))
+			}
+		}
+	}
+	seen := make(map[string]struct{})
+	for _, proxy := range proxies {
+		serviceName := cllama.ProxyServiceName(proxy.ProxyType)
+		if _, ok := seen[serviceName]; ok {
+			continue
+		}
+		seen[serviceName] = struct{}{}
+		hosts = append(hosts, serviceName)
+	}
+	if p.MockLLM != nil {
+		hosts = append(hosts, mockllm.ServiceName)
+	}
+	return strings.Join(hosts, ",")
+}
+
 func sortedServiceNames(services map[string]*Service) []string {
 	names := make([]string, 0, len(services))
 	for name := range services {
@@ -0,0 +1,160 @@
+package pod
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+	"gopkg.in/yaml.v3"
+)
+
+type egressComposeFile struct {
+	Services map[string]struct {
+		Volumes     []string          `yaml:"volumes"`
+		Environment map[string]string `yaml:"environment"`
+		Labels      map[string]string `yaml:"labels"`
+		Networks    []string          `yaml:"networks"`
+	} `yaml:"services"`
+	Networks map[string]map[string]interface{} `yaml:"networks"`
+}
+
+func egressTestPod() *Pod {
+	return &Pod{
+		Name: "ops-pod",
+		Services: map[string]*Service{
+			"bot": {
+				Image: "ghcr.io/example/bot:latest",
+				Claw: &ClawBlock{
+					Count: 2,
+					Surfaces: []driver.ResolvedSurface{
+						{Scheme: "egress", Target: "api.example.com"},
+						{Scheme: "service", Target: "api"},
+					},
+				},
+			},
+			"api":   {Image: "ghcr.io/example/api:latest", Compose: map[string]interface{}{}},
+			"quiet": {Image: "ghcr.io/example/quiet:latest", Claw: &ClawBlock{}},
+		},
+		Egress: &EgressConfig{
+			Image:          "ghcr.io/mostlydev/clawegress:latest",
+			PolicyHostPath: "/tmp/.claw-runtime/egress/egress-policy.json",
+			LogHostDir:     "/tmp/.claw-runtime/egress/logs",
+			ProxyURLs: map[string]string{
+				"bot-0": "http://bot-0:s0@claw-egress:3128",
+				"bot-1": "http://bot-1:s1@claw-egress:3128",
+				"quiet": "http://quiet:s2@claw-egress:3128",
+			},
+			PodName: "ops-pod",
+		},
+	}
+}
+
+func TestEmitComposeEgressProxyIsolatesClaws(t *testing.T) {
+	p := egressTestPod()
+	out, err := EmitCompose(p, nil, CllamaProxyConfig{
+		ProxyType:      "passthrough",
+		Image:          "ghcr.io/mostlydev/cllama:latest",
+		ContextHostDir: "/tmp/context",
+		AuthHostDir:    "/tmp/auth",
+		PodName:        "ops-pod",
+	})
+	if err != nil {
+		t.Fatalf("EmitCompose returned error: %v", err)
+	}
+	var cf egressComposeFile
+	if err := yaml.Unmarshal([]byte(out), &cf); err != nil {
+		t.Fatalf("parse compose yaml: %v", err)
+	}
+
+	if cf.Networks["claw-internal"]["internal"] != true {
+		t.Fatalf("expected claw-internal to be internal, got %v", cf.Networks["claw-internal"])
+	}
+	if _, ok := cf.Networks["claw-egress"]; !ok {
+		t.Fatalf("expected claw-egress network, got %v", cf.Networks)
+	}
+
+	proxy, ok := cf.Services["claw-egress"]
+	if !ok {
+		t.Fatal("expected claw-egress service in output")
+	}
+	if strings.Join(proxy.Networks, ",") != "claw-internal,claw-egress" {
+		t.Fatalf("unexpected egress proxy networks: %v", proxy.Networks)
+	}
+	volumes := strings.Join(proxy.Volumes, ",")
+	if !strings.Contains(volumes, "egress-policy.json:/claw/egress-policy.json:ro") || !strings.Contains(volumes, "/logs:/claw/logs:rw") {
+		t.Fatalf("unexpected egress proxy volumes: %v", proxy.Volumes)
+	}
+	if proxy.Labels["claw.role"] != "egress" {
+		t.Fatalf("expected claw.role=egress, got %q", proxy.Labels["claw.role"])
+	}
+
+	for _, name := range []string{"bot-0", "bot-1"} {
+		svc := cf.Services[name]
+		if strings.Join(svc.Networks, ",") != "claw-internal" {
+			t.Fatalf("%s: expected only claw-internal, got %v", name, svc.Networks)
+		}
+		if svc.Environment["HTTPS_PROXY"] != p.Egress.ProxyURLs[name] || svc.Environment["http_proxy"] != p.Egress.ProxyURLs[name] {
+			t.Fatalf("%s: unexpected proxy env %v", name, svc.Environment)
+		}
+		noProxy := svc.Environment["NO_PROXY"]
+		for _, host := range []string{"localhost", "api", "bot-1", "cllama"} {
+			if !strings.Contains(","+noProxy+",", ","+host+",") {
+				t.Fatalf("%s: expected %q in NO_PROXY %q", name, host, noProxy)
+			}
+		}
+	}
+
+	if got := strings.Join(cf.Services["api"].Networks, ","); got != "default,claw-internal" {
+		t.Fatalf("expected service surface target to keep default network, got %q", got)
+	}
+	if got := strings.Join(cf.Services["cllama"].Networks, ","); got != "claw-internal,claw-egress" {
+		t.Fatalf("expected cllama on claw-egress, got %q", got)
+	}
+	quiet := cf.Services["quiet"]
+	if got := strings.Join(quiet.Networks, ","); got != "claw-internal" {
+		t.Fatalf("expected claw without egress surfaces on claw-internal only, got %q", got)
+	}
+	if quiet.Environment["HTTPS_PROXY"] != p.Egress.ProxyURLs["quiet"] {
+		t.Fatalf("expected proxy env on a claw without egress surfaces: %v", quiet.Environment)
+	}
+}
+
+func TestEmitComposeEgressRejectsOwnNetworksOnPolicedClaw(t *testing.T) {
+	p := egressTestPod()
+	p.Services["bot"].Compose = map[string]interface{}{"networks": []interface{}{"public"}}
+	_, err := EmitCompose(p, nil)
+	if err == nil || !strings.Contains(err.Error(), "own networks") {
+		t.Fatalf("expected networks conflict error, got %v", err)
+	}
+}
+
+func TestEmitComposeWithoutEgressKeepsOpenNetwork(t *testing.T) {
+	p := egressTestPod()
+	p.Egress = nil
+	out, err := EmitCompose(p, nil)
+	if err != nil {
+		t.Fatalf("EmitCompose returned error: %v", err)
+	}
+	var cf egressComposeFile
+	if err := yaml.Unmarshal([]byte(out), &cf); err != nil {
+		t.Fatalf("parse compose yaml: %v", err)
+	}
+	if _, ok := cf.Services["claw-egress"]; ok {
+		t.Fatal("did not expect claw-egress service")
+	}
+	if internal, ok := cf.Networks["claw-internal"]["internal"]; ok {
+		t.Fatalf("did not expect claw-internal internal flag, got %v", internal)
+	}
+	if _, ok := cf.Services["bot-0"].Environment["HTTPS_PROXY"]; ok {
+		t.Fatal("did not expect proxy env without egress")
+	}
+}
+
+func TestEmitComposeEgressRequiresProxyCredential(t *testing.T) {
+	p := egressTestPod()
+	delete(p.Egress.ProxyURLs, "bot-1")
+	_, err := EmitCompose(p, nil)
+	if err == nil || !strings.Contains(err.Error(), "bot-1") {
+		t.Fatalf("expected missing credential error for bot-1, got %v", err)
+	}
+}
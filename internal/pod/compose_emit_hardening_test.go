@@ -272,3 +272,81 @@ func TestEmitComposeRejectsInvalidSurfaceAccessMode(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestEmitComposeTranslatesPrivileges(t *testing.T) {
+	p := &Pod{
+		Name: "priv-pod",
+		Services: map[string]*Service{
+			"bot": {
+				Image: "ghcr.io/example/bot:v1",
+				Compose: map[string]interface{}{
+					"security_opt": []interface{}{"label=disable"},
+				},
+				Claw: &ClawBlock{
+					Privileges: map[string]string{
+						"user":              "1000:1000",
+						"cap-drop":          "ALL",
+						"no-new-privileges": "true",
+						"pids-limit":        "128",
+						"memory":            "512m",
+					},
+				},
+			},
+		},
+	}
+
+	out, err := EmitCompose(p, map[string]*driver.MaterializeResult{})
+	if err != nil {
+		t.Fatalf("EmitCompose returned error: %v", err)
+	}
+
+	for _, want := range []string{
+		"user: 1000:1000",
+		"cap_drop:\n            - ALL",
+		"- label=disable\n            - no-new-privileges:true",
+		"pids_limit: 128",
+		"mem_limit: 512m",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestEmitComposePodUserOverridesPrivilegeUser(t *testing.T) {
+	p := &Pod{
+		Name: "priv-pod",
+		Services: map[string]*Service{
+			"bot": {
+				Image:   "ghcr.io/example/bot:v1",
+				Compose: map[string]interface{}{"user": "2000"},
+				Claw:    &ClawBlock{Privileges: map[string]string{"user": "1000"}},
+			},
+		},
+	}
+
+	out, err := EmitCompose(p, map[string]*driver.MaterializeResult{})
+	if err != nil {
+		t.Fatalf("EmitCompose returned error: %v", err)
+	}
+	if !strings.Contains(out, `user: "2000"`) {
+		t.Fatalf("expected pod-level user to win, got:\n%s", out)
+	}
+}
+
+func TestEmitComposeRejectsUnknownPrivilege(t *testing.T) {
+	p := &Pod{
+		Name: "priv-pod",
+		Services: map[string]*Service{
+			"bot": {
+				Image: "ghcr.io/example/bot:v1",
+				Claw:  &ClawBlock{Privileges: map[string]string{"godmode": "true"}},
+			},
+		},
+	}
+
+	_, err := EmitCompose(p, map[string]*driver.MaterializeResult{})
+	if err == nil || !strings.Contains(err.Error(), "unknown PRIVILEGE mode") {
+		t.Fatalf("expected unknown PRIVILEGE mode error, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package pod
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func mockLLMTestPod() *Pod {
+	return &Pod{
+		Name: "ci-pod",
+		Services: map[string]*Service{
+			"bot": {Image: "ghcr.io/example/bot:latest", Claw: &ClawBlock{Count: 1}},
+		},
+		MockLLM: &MockLLMConfig{
+			Script:         "./mock-llm.json",
+			Image:          "ghcr.io/mostlydev/clawmockllm:latest",
+			ScriptHostPath: "/tmp/pod/mock-llm.json",
+			PodName:        "ci-pod",
+		},
+	}
+}
+
+func TestEmitComposeMockLLMService(t *testing.T) {
+	out, err := EmitCompose(mockLLMTestPod(), nil, CllamaProxyConfig{
+		ProxyType:      "passthrough",
+		Image:          "ghcr.io/mostlydev/cllama:latest",
+		ContextHostDir: "/tmp/context",
+		AuthHostDir:    "/tmp/auth",
+		PodName:        "ci-pod",
+	})
+	if err != nil {
+		t.Fatalf("EmitCompose returned error: %v", err)
+	}
+
+	var cf egressComposeFile
+	if err := yaml.Unmarshal([]byte(out), &cf); err != nil {
+		t.Fatalf("parse output: %v", err)
+	}
+	mock, ok := cf.Services["claw-mock-llm"]
+	if !ok {
+		t.Fatalf("expected claw-mock-llm service, got:\n%s", out)
+	}
+	if len(mock.Networks) != 1 || mock.Networks[0] != "claw-internal" {
+		t.Fatalf("expected mock on claw-internal only, got %v", mock.Networks)
+	}
+	if mock.Labels["claw.role"] != "mock-llm" || mock.Labels["claw.pod"] != "ci-pod" {
+		t.Fatalf("unexpected labels: %v", mock.Labels)
+	}
+	if len(mock.Volumes) != 1 || mock.Volumes[0] != "/tmp/pod/mock-llm.json:/claw/mock-llm-script.json:ro" {
+		t.Fatalf("unexpected volumes: %v", mock.Volumes)
+	}
+	if mock.Environment["CLAW_MOCK_LLM_SCRIPT"] != "/claw/mock-llm-script.json" {
+		t.Fatalf("unexpected env: %v", mock.Environment)
+	}
+	if got := cf.Services["cllama"].Environment["CLAW_UPSTREAM_URL"]; got != "http://claw-mock-llm:8080/v1" {
+		t.Fatalf("expected terminal proxy to call the mock, got %q", got)
+	}
+}
+
+func TestEmitComposeMockLLMWithoutScript(t *testing.T) {
+	p := mockLLMTestPod()
+	p.MockLLM.Script = ""
+	p.MockLLM.ScriptHostPath = ""
+	out, err := EmitCompose(p, nil)
+	if err != nil {
+		t.Fatalf("EmitCompose returned error: %v", err)
+	}
+	var cf egressComposeFile
+	if err := yaml.Unmarshal([]byte(out), &cf); err != nil {
+		t.Fatalf("parse output: %v", err)
+	}
+	mock := cf.Services["claw-mock-llm"]
+	if len(mock.Volumes) != 0 || mock.Environment["CLAW_MOCK_LLM_SCRIPT"] != "" {
+		t.Fatalf("expected echo-only mock, got volumes %v env %v", mock.Volumes, mock.Environment)
+	}
+}
+
+func TestParseMockLLM(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		script  string
+		enabled bool
+		wantErr string
+	}{
+		{name: "absent", yaml: "pod: p"},
+		{name: "false", yaml: "mock-llm: false"},
+		{name: "true", yaml: "mock-llm: true", enabled: true},
+		{name: "script", yaml: "mock-llm:\n    script: ./replies.json", enabled: true, script: "./replies.json"},
+		{name: "unknown key", yaml: "mock-llm:\n    reply: hi", wantErr: `unknown key "reply"`},
+		{name: "bad type", yaml: "mock-llm: yes-please", wantErr: "must be a bool or a map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "x-claw:\n  " + tt.yaml + "\nservices:\n  bot:\n    image: bot:latest\n"
+			p, err := Parse(strings.NewReader(src))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if (p.MockLLM != nil) != tt.enabled {
+				t.Fatalf("expected enabled=%v, got %+v", tt.enabled, p.MockLLM)
+			}
+			if p.MockLLM != nil && p.MockLLM.Script != tt.script {
+				t.Fatalf("expected script %q, got %q", tt.script, p.MockLLM.Script)
+			}
+		})
+	}
+}
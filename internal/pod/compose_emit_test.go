@@ -782,6 +782,63 @@ func TestEmitComposeMultipleProxies(t *testing.T) {
 	}
 }
 
+func TestEmitComposeChainedProxySetsUpstreamURL(t *testing.T) {
+	p := &Pod{
+		Name: "test-pod",
+		Services: map[string]*Service{
+			"bot": {
+				Image: "bot:latest",
+				Claw:  &ClawBlock{Count: 1},
+			},
+		},
+	}
+	results := map[string]*driver.MaterializeResult{
+		"bot": {ReadOnly: true, Restart: "on-failure"},
+	}
+	proxies := []CllamaProxyConfig{
+		{
+			ProxyType:      "passthrough",
+			Image:          "ghcr.io/mostlydev/cllama:latest",
+			ContextHostDir: "/tmp/ctx",
+			AuthHostDir:    "/tmp/auth",
+			DashboardPort:  "8181",
+			PodName:        "test-pod",
+		},
+		{
+			ProxyType:      "policy",
+			Image:          "ghcr.io/mostlydev/cllama-policy:latest",
+			ContextHostDir: "/tmp/ctx",
+			AuthHostDir:    "/tmp/auth",
+			DashboardPort:  "8182",
+			Upstream:       "passthrough",
+			PodName:        "test-pod",
+		},
+	}
+	out, err := EmitCompose(p, results, proxies...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cf struct {
+		Services map[string]struct {
+			Environment map[string]string `yaml:"environment"`
+			Ports       []string          `yaml:"ports"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &cf); err != nil {
+		t.Fatal(err)
+	}
+	if got := cf.Services["cllama-policy"].Environment["CLAW_UPSTREAM_URL"]; got != "http://cllama:8080/v1" {
+		t.Errorf("expected policy hop to forward to cllama, got %q", got)
+	}
+	if _, ok := cf.Services["cllama"].Environment["CLAW_UPSTREAM_URL"]; ok {
+		t.Error("terminal hop must not have an upstream URL")
+	}
+	if ports := cf.Services["cllama-policy"].Ports; len(ports) != 1 || ports[0] != "8182:8081" {
+		t.Errorf("expected policy dashboard on 8182, got %v", ports)
+	}
+}
+
 func TestEmitComposeNoProxiesUnchanged(t *testing.T) {
 	p := &Pod{
 		Name: "test-pod",
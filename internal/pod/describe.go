@@ -0,0 +1,145 @@
+package pod
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
+)
+
+var describeEnvPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseDescribe converts an x-claw.describe block:
+//
+//	x-claw:
+//	  describe:
+//	    description: Shared market-data cache.
+//	    protocol: redis
+//	    auth: [REDIS_PASSWORD]
+//	    examples:
+//	      - redis-cli -h cache -a "$REDIS_PASSWORD" GET price:BTC
+//	    operations:
+//	      - name: GET price:<symbol>
+//	        description: Latest cached price for a symbol
+//	      - PUBLISH alerts <json>
+func parseDescribe(raw interface{}) (*driver.ServiceDescription, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("describe must be a map, got %T", raw)
+	}
+	for _, key := range sortedMapKeys(m) {
+		switch key {
+		case "description", "protocol", "auth", "examples", "operations":
+		default:
+			return nil, fmt.Errorf("describe: unknown key %q", key)
+		}
+	}
+
+	d := &driver.ServiceDescription{}
+	var err error
+	if d.Description, err = describeString(m, "description"); err != nil {
+		return nil, err
+	}
+	if d.Protocol, err = describeString(m, "protocol"); err != nil {
+		return nil, err
+	}
+	if d.Auth, err = parseStringOrList(m["auth"]); err != nil {
+		return nil, fmt.Errorf("describe.auth: %w", err)
+	}
+	for _, env := range d.Auth {
+		if !describeEnvPattern.MatchString(env) {
+			return nil, fmt.Errorf("describe.auth: %q is not an environment variable name", env)
+		}
+	}
+	if d.Examples, err = parseStringOrList(m["examples"]); err != nil {
+		return nil, fmt.Errorf("describe.examples: %w", err)
+	}
+	if d.Operations, err = parseDescribeOperations(m["operations"]); err != nil {
+		return nil, err
+	}
+
+	if d.Description == "" && d.Protocol == "" && len(d.Auth) == 0 && len(d.Examples) == 0 && len(d.Operations) == 0 {
+		return nil, fmt.Errorf("describe block is empty")
+	}
+	return d, nil
+}
+
+func describeString(m map[string]interface{}, key string) (string, error) {
+	raw, ok := m[key]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("describe.%s must be a string, got %T", key, raw)
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// parseDescribeOperations accepts plain strings or {name, description} maps.
+func parseDescribeOperations(raw interface{}) ([]driver.ServiceOperation, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("describe.operations must be a list, got %T", raw)
+	}
+	ops := make([]driver.ServiceOperation, 0, len(list))
+	for i, item := range list {
+		var op driver.ServiceOperation
+		switch v := item.(type) {
+		case string:
+			op.Name = strings.TrimSpace(v)
+		case map[string]interface{}:
+			for key := range v {
+				if key != "name" && key != "description" {
+					return nil, fmt.Errorf("describe.operations[%d]: unknown key %q", i, key)
+				}
+			}
+			var err error
+			if op.Name, err = describeString(v, "name"); err != nil {
+				return nil, fmt.Errorf("describe.operations[%d]: %w", i, err)
+			}
+			if op.Description, err = describeString(v, "description"); err != nil {
+				return nil, fmt.Errorf("describe.operations[%d]: %w", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("describe.operations[%d] must be a string or map, got %T", i, item)
+		}
+		if op.Name == "" {
+			return nil, fmt.Errorf("describe.operations[%d]: name is required", i)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// isDescribeOnly reports whether an x-claw block declares nothing but
+// describe, which documents a plain service without making it a claw.
+func isDescribeOnly(rawXClaw interface{}) bool {
+	m, ok := rawXClaw.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return false
+	}
+	for key := range m {
+		if key != "describe" {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
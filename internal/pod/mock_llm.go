@@ -0,0 +1,43 @@
+package pod
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseMockLLM converts the pod-level x-claw.mock-llm declaration:
+//
+//	x-claw:
+//	  mock-llm: true
+//
+//	x-claw:
+//	  mock-llm:
+//	    script: ./mock-llm.json
+func parseMockLLM(raw interface{}) (*MockLLMConfig, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		return &MockLLMConfig{}, nil
+	case map[string]interface{}:
+		cfg := &MockLLMConfig{}
+		for _, key := range sortedMapKeys(v) {
+			switch key {
+			case "script":
+				script, ok := v[key].(string)
+				if !ok || strings.TrimSpace(script) == "" {
+					return nil, fmt.Errorf("mock-llm.script must be a non-empty path")
+				}
+				cfg.Script = strings.TrimSpace(script)
+			default:
+				return nil, fmt.Errorf("mock-llm: unknown key %q", key)
+			}
+		}
+		return cfg, nil
+	default:
+		return nil, fmt.Errorf("mock-llm must be a bool or a map, got %T", raw)
+	}
+}
@@ -21,6 +21,9 @@ type rawPodClaw struct {
 	Pod             string                 `yaml:"pod"`
 	Master          string                 `yaml:"master"`
 	HandlesDefaults map[string]interface{} `yaml:"handles-defaults"`
+	CllamaPolicy    interface{}            `yaml:"cllama-policy"`
+	MockLLM         interface{}            `yaml:"mock-llm"`
+	Generations     interface{}            `yaml:"generations"`
 }
 
 type rawService struct {
@@ -39,16 +42,19 @@ type rawInvokeEntry struct {
 }
 
 type rawClawBlock struct {
-	Agent     string                 `yaml:"agent"`
-	Persona   string                 `yaml:"persona"`
-	Cllama    interface{}            `yaml:"cllama"`
-	CllamaEnv map[string]string      `yaml:"cllama-env"`
-	Count     int                    `yaml:"count"`
-	Handles   map[string]interface{} `yaml:"handles"`
-	Include   []rawIncludeEntry      `yaml:"include"`
-	Surfaces  []interface{}          `yaml:"surfaces"`
-	Skills    []string               `yaml:"skills"`
-	Invoke    []rawInvokeEntry       `yaml:"invoke"`
+	Agent        string                 `yaml:"agent"`
+	Persona      string                 `yaml:"persona"`
+	Cllama       interface{}            `yaml:"cllama"`
+	CllamaEnv    map[string]string      `yaml:"cllama-env"`
+	CllamaCreds  map[string]string      `yaml:"cllama-credentials"`
+	Count        int                    `yaml:"count"`
+	Handles      map[string]interface{} `yaml:"handles"`
+	Include      []rawIncludeEntry      `yaml:"include"`
+	Surfaces     []interface{}          `yaml:"surfaces"`
+	Skills       []string               `yaml:"skills"`
+	Invoke       []rawInvokeEntry       `yaml:"invoke"`
+	Describe     interface{}            `yaml:"describe"`
+	CllamaPolicy interface{}            `yaml:"cllama-policy"`
 }
 
 type rawIncludeEntry struct {
@@ -79,10 +85,33 @@ func Parse(r io.Reader) (*Pod, error) {
 	delete(preservedRoot, "x-claw")
 	delete(preservedRoot, "services")
 
+	volumeAccess, err := parseVolumeAccess(preservedRoot["volumes"])
+	if err != nil {
+		return nil, fmt.Errorf("parse claw-pod.yml: %w", err)
+	}
+
+	defaultPolicy, err := parseCllamaPolicy(raw.XClaw.CllamaPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("parse claw-pod.yml: x-claw: %w", err)
+	}
+
+	mockLLM, err := parseMockLLM(raw.XClaw.MockLLM)
+	if err != nil {
+		return nil, fmt.Errorf("parse claw-pod.yml: x-claw: %w", err)
+	}
+
+	generations, err := parseGenerations(raw.XClaw.Generations)
+	if err != nil {
+		return nil, fmt.Errorf("parse claw-pod.yml: x-claw: %w", err)
+	}
+
 	pod := &Pod{
-		Name:     raw.XClaw.Pod,
-		Services: make(map[string]*Service, len(raw.Services)),
-		Compose:  preservedRoot,
+		Name:         raw.XClaw.Pod,
+		Services:     make(map[string]*Service, len(raw.Services)),
+		Compose:      preservedRoot,
+		VolumeAccess: volumeAccess,
+		MockLLM:      mockLLM,
+		Generations:  generations,
 	}
 
 	rawServices, err := mapStringAny(root["services"])
@@ -92,12 +121,14 @@ func Parse(r io.Reader) (*Pod, error) {
 
 	for name, svc := range raw.Services {
 		serviceCompose := make(map[string]interface{})
+		var rawXClaw interface{}
 		if rawServices != nil {
 			rawServiceMap, err := mapStringAny(rawServices[name])
 			if err != nil {
 				return nil, fmt.Errorf("service %q: %w", name, err)
 			}
 			serviceCompose = deepCopyMap(rawServiceMap)
+			rawXClaw = serviceCompose["x-claw"]
 			delete(serviceCompose, "x-claw")
 		}
 
@@ -128,6 +159,13 @@ func Parse(r io.Reader) (*Pod, error) {
 			Ports:       ports,
 		}
 		if svc.XClaw != nil {
+			describe, err := parseDescribe(svc.XClaw.Describe)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %w", name, err)
+			}
+			service.Describe = describe
+		}
+		if svc.XClaw != nil && !isDescribeOnly(rawXClaw) {
 			count := svc.XClaw.Count
 			if count < 1 {
 				count = 1
@@ -167,6 +205,17 @@ func Parse(r io.Reader) (*Pod, error) {
 			if err != nil {
 				return nil, fmt.Errorf("service %q: parse include: %w", name, err)
 			}
+			policy, err := parseCllamaPolicy(svc.XClaw.CllamaPolicy)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %w", name, err)
+			}
+			mergedPolicy := policy.Merge(defaultPolicy)
+			if err := checkPolicyBudgets(mergedPolicy); err != nil {
+				return nil, fmt.Errorf("service %q: with pod defaults: %w", name, err)
+			}
+			if err := ValidateCllamaCredentials(svc.XClaw.CllamaCreds); err != nil {
+				return nil, fmt.Errorf("service %q: %w", name, err)
+			}
 			invoke := make([]InvokeEntry, 0, len(svc.XClaw.Invoke))
 			for _, rawInv := range svc.XClaw.Invoke {
 				if rawInv.Schedule == "" || rawInv.Message == "" {
@@ -180,16 +229,19 @@ func Parse(r io.Reader) (*Pod, error) {
 				})
 			}
 			service.Claw = &ClawBlock{
-				Agent:     svc.XClaw.Agent,
-				Persona:   svc.XClaw.Persona,
-				Cllama:    cllama,
-				CllamaEnv: svc.XClaw.CllamaEnv,
-				Count:     count,
-				Handles:   handles,
-				Include:   include,
-				Surfaces:  parsedSurfaces,
-				Skills:    skills,
-				Invoke:    invoke,
+				Agent:        svc.XClaw.Agent,
+				Persona:      svc.XClaw.Persona,
+				Cllama:       cllama,
+				CllamaEnv:    svc.XClaw.CllamaEnv,
+				CllamaCreds:  svc.XClaw.CllamaCreds,
+				CllamaPolicy: mergedPolicy,
+				OwnPolicy:    policy != nil,
+				Count:        count,
+				Handles:      handles,
+				Include:      include,
+				Surfaces:     parsedSurfaces,
+				Skills:       skills,
+				Invoke:       invoke,
 			}
 		}
 		pod.Services[name] = service
@@ -616,3 +668,19 @@ func parseExpose(raw interface{}) ([]string, error) {
 	}
 	return out, nil
 }
+
+// parseGenerations reads x-claw.generations, the number of runtime generations
+// claw up keeps for rollback. Zero means unset.
+func parseGenerations(raw interface{}) (int, error) {
+	switch v := raw.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		if v < 1 {
+			return 0, fmt.Errorf("generations must be at least 1, got %d", v)
+		}
+		return v, nil
+	default:
+		return 0, fmt.Errorf("generations must be an integer, got %T", raw)
+	}
+}
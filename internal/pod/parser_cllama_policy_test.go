@@ -0,0 +1,151 @@
+package pod
+
+import (
+	"strings"
+	"testing"
+)
+
+const podWithCllamaPolicy = `
+x-claw:
+  pod: desk
+  cllama-policy:
+    daily-budget-usd: 5
+    monthly-budget-usd: 100
+    requests-per-minute: 30
+    allowed-models: [anthropic/*]
+
+services:
+  analyst:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      cllama: passthrough
+  trader:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      cllama: passthrough
+      cllama-policy:
+        daily-budget-usd: 2.5
+        tokens-per-day: 500000
+        max-output-tokens: 4096
+        allowed-models:
+          - anthropic/claude-sonnet-4
+          - openrouter/*
+`
+
+func TestParseCllamaPolicyMergesPodDefaults(t *testing.T) {
+	p, err := Parse(strings.NewReader(podWithCllamaPolicy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	analyst := p.Services["analyst"].Claw.CllamaPolicy
+	if analyst == nil || analyst.DailyBudgetUSD != 5 || analyst.MonthlyBudgetUSD != 100 || analyst.RequestsPerMinute != 30 {
+		t.Fatalf("expected pod defaults on analyst, got %+v", analyst)
+	}
+
+	trader := p.Services["trader"].Claw.CllamaPolicy
+	if trader.DailyBudgetUSD != 2.5 || trader.MonthlyBudgetUSD != 100 {
+		t.Fatalf("expected service budget over pod default, got %+v", trader)
+	}
+	if trader.TokensPerDay != 500000 || trader.MaxOutputTokens != 4096 || trader.RequestsPerMinute != 30 {
+		t.Fatalf("unexpected trader limits: %+v", trader)
+	}
+	if len(trader.AllowedModels) != 2 || trader.AllowedModels[1] != "openrouter/*" {
+		t.Fatalf("expected service allowlist to replace default, got %v", trader.AllowedModels)
+	}
+}
+
+func TestParseCllamaPolicyRejectsInvalidBlocks(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":      "{budget: 5}",
+		"negative budget":  "{daily-budget-usd: -1}",
+		"fractional limit": "{requests-per-minute: 1.5}",
+		"zero limit":       "{tokens-per-day: 0}",
+		"bare model":       "{allowed-models: [gpt-4o]}",
+		"daily over month": "{daily-budget-usd: 20, monthly-budget-usd: 10}",
+		"empty block":      "{}",
+	}
+	for name, block := range cases {
+		t.Run(name, func(t *testing.T) {
+			src := `
+services:
+  bot:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      cllama-policy: ` + block + "\n"
+			if _, err := Parse(strings.NewReader(src)); err == nil {
+				t.Fatalf("expected error for %s", block)
+			}
+		})
+	}
+}
+
+func TestParseCllamaPolicyRevalidatesMergedBudgets(t *testing.T) {
+	_, err := Parse(strings.NewReader(`
+x-claw:
+  cllama-policy:
+    monthly-budget-usd: 10
+services:
+  bot:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      cllama: passthrough
+      cllama-policy:
+        daily-budget-usd: 20
+`))
+	if err == nil || !strings.Contains(err.Error(), "exceeds monthly-budget-usd") {
+		t.Fatalf("expected merged daily budget over pod monthly budget to fail, got %v", err)
+	}
+}
+
+func TestParseWithoutCllamaPolicyLeavesNil(t *testing.T) {
+	p, err := Parse(strings.NewReader(`
+services:
+  bot:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.Services["bot"].Claw.CllamaPolicy != nil {
+		t.Fatalf("expected nil policy, got %+v", p.Services["bot"].Claw.CllamaPolicy)
+	}
+}
+
+func TestParseCllamaCredentials(t *testing.T) {
+	p, err := Parse(strings.NewReader(`
+services:
+  desk-a:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      cllama: passthrough
+      cllama-credentials:
+        OPENROUTER_API_KEY: "${DESK_A_OPENROUTER_KEY}"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := p.Services["desk-a"].Claw.CllamaCreds["OPENROUTER_API_KEY"]; got != "${DESK_A_OPENROUTER_KEY}" {
+		t.Fatalf("unexpected credentials: %q", got)
+	}
+
+	_, err = Parse(strings.NewReader(`
+services:
+  desk-a:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      cllama-credentials:
+        "bad key": value
+`))
+	if err == nil {
+		t.Fatal("expected invalid credential key error")
+	}
+}
@@ -0,0 +1,97 @@
+package pod
+
+import (
+	"strings"
+	"testing"
+)
+
+const podWithDescribe = `
+x-claw:
+  pod: test-pod
+
+services:
+  cache:
+    image: redis:7
+    expose: ["6379"]
+    x-claw:
+      describe:
+        description: Shared market-data cache.
+        protocol: redis
+        auth: REDIS_PASSWORD
+        examples:
+          - redis-cli -h cache -a "$REDIS_PASSWORD" GET price:BTC
+        operations:
+          - name: GET price:<symbol>
+            description: Latest cached price for a symbol
+          - PUBLISH alerts <json>
+  bot:
+    image: openclaw:latest
+    x-claw:
+      agent: ./AGENTS.md
+      surfaces:
+        - service://cache
+      describe:
+        description: Trading desk agent.
+`
+
+func TestParsePodDescribeOnlyServiceIsNotAClaw(t *testing.T) {
+	p, err := Parse(strings.NewReader(podWithDescribe))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	cache := p.Services["cache"]
+	if cache.Claw != nil {
+		t.Fatalf("describe-only x-claw must not make a claw service, got %+v", cache.Claw)
+	}
+	if _, ok := cache.Compose["x-claw"]; ok {
+		t.Fatal("expected x-claw to be stripped from compose")
+	}
+	d := cache.Describe
+	if d == nil {
+		t.Fatal("expected describe block")
+	}
+	if d.Description != "Shared market-data cache." || d.Protocol != "redis" {
+		t.Fatalf("unexpected describe %+v", d)
+	}
+	if len(d.Auth) != 1 || d.Auth[0] != "REDIS_PASSWORD" {
+		t.Fatalf("expected auth env names, got %v", d.Auth)
+	}
+	if len(d.Examples) != 1 || !strings.Contains(d.Examples[0], "redis-cli") {
+		t.Fatalf("unexpected examples %v", d.Examples)
+	}
+	if len(d.Operations) != 2 ||
+		d.Operations[0].Name != "GET price:<symbol>" || d.Operations[0].Description != "Latest cached price for a symbol" ||
+		d.Operations[1].Name != "PUBLISH alerts <json>" {
+		t.Fatalf("unexpected operations %+v", d.Operations)
+	}
+}
+
+func TestParsePodDescribeOnClawService(t *testing.T) {
+	p, err := Parse(strings.NewReader(podWithDescribe))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	bot := p.Services["bot"]
+	if bot.Claw == nil || bot.Claw.Agent != "./AGENTS.md" {
+		t.Fatalf("expected claw block alongside describe, got %+v", bot.Claw)
+	}
+	if bot.Describe == nil || bot.Describe.Description != "Trading desk agent." {
+		t.Fatalf("expected describe on claw service, got %+v", bot.Describe)
+	}
+}
+
+func TestParsePodDescribeRejectsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"empty":        `{}`,
+		"unknown key":  `{summary: nope}`,
+		"bad auth":     `{auth: ["not a var"]}`,
+		"nameless op":  `{operations: [{description: missing name}]}`,
+		"non-list ops": `{operations: GET}`,
+	}
+	for name, describe := range cases {
+		yaml := "services:\n  cache:\n    image: redis:7\n    x-claw:\n      describe: " + describe + "\n"
+		if _, err := Parse(strings.NewReader(yaml)); err == nil {
+			t.Fatalf("%s: expected parse error", name)
+		}
+	}
+}
@@ -328,3 +328,18 @@ services:
 		t.Errorf("expected ANTHROPIC_API_KEY, got %v", env)
 	}
 }
+
+func TestParseGenerations(t *testing.T) {
+	p, err := Parse(strings.NewReader("x-claw:\n  pod: ops\n  generations: 3\nservices: {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Generations != 3 {
+		t.Fatalf("expected 3 generations, got %d", p.Generations)
+	}
+	for _, bad := range []string{"0", "many"} {
+		if _, err := Parse(strings.NewReader("x-claw:\n  generations: " + bad + "\nservices: {}\n")); err == nil {
+			t.Fatalf("expected error for generations: %s", bad)
+		}
+	}
+}
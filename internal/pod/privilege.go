@@ -0,0 +1,225 @@
+package pod
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrivilegeSpec is the compose hardening derived from PRIVILEGE directives
+// (claw.privilege.<mode> image labels).
+type PrivilegeSpec struct {
+	User            string   // compose user
+	CapDrop         []string // compose cap_drop
+	CapAdd          []string // compose cap_add
+	SecurityOpt     []string // compose security_opt
+	PidsLimit       int      // compose pids_limit (0 = unset)
+	MemLimit        string   // compose mem_limit
+	DockerSocket    bool     // consumed by drivers that mount the Docker socket
+	NoNewPrivileges bool
+	Warnings        []string // deprecated modes that were accepted but not applied
+}
+
+var (
+	capabilityPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	memLimitPattern   = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+	userSpecPattern   = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*(:[A-Za-z0-9_][A-Za-z0-9_.-]*)?$`)
+)
+
+// ParsePrivileges validates PRIVILEGE modes and translates them into compose
+// hardening. Unknown modes are rejected: a Clawfile that asks for a restriction
+// Clawdapus cannot enforce must not start.
+//
+// Supported modes:
+//
+//	user <uid[:gid]|name>          run-as user
+//	worker <user>                  worker-mode (ACT) user; no runtime effect
+//	runtime <user>                 legacy, recorded only; warns and has no runtime effect
+//	cap-drop <CAP>[,<CAP>...]|ALL  dropped Linux capabilities
+//	cap-add <CAP>[,<CAP>...]       added Linux capabilities
+//	no-new-privileges true|false   security_opt no-new-privileges
+//	seccomp <profile|unconfined>   security_opt seccomp=<profile>
+//	apparmor <profile|unconfined>  security_opt apparmor=<profile>
+//	pids-limit <n>                 process ceiling
+//	memory <size>                  memory ceiling (e.g. 512m, 2g)
+//	docker-socket true|false       Docker socket access (driver-mounted)
+func ParsePrivileges(privileges map[string]string) (*PrivilegeSpec, error) {
+	spec := &PrivilegeSpec{}
+	if len(privileges) == 0 {
+		return spec, nil
+	}
+
+	modes := make([]string, 0, len(privileges))
+	seen := make(map[string]string, len(privileges))
+	for mode := range privileges {
+		key := strings.ToLower(strings.TrimSpace(mode))
+		if prev, dup := seen[key]; dup {
+			return nil, fmt.Errorf("PRIVILEGE %s declared twice (%q and %q)", key, prev, mode)
+		}
+		seen[key] = mode
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	for _, mode := range modes {
+		value := strings.TrimSpace(privileges[mode])
+		switch strings.ToLower(strings.TrimSpace(mode)) {
+		case "user":
+			if !userSpecPattern.MatchString(value) {
+				return nil, fmt.Errorf("PRIVILEGE %s: invalid user spec %q (expected uid[:gid] or name)", mode, value)
+			}
+			spec.User = value
+		case "runtime":
+			// Images built before PRIVILEGE user carry runtime labels naming users
+			// that often do not exist in the image; applying them would stop the
+			// container from starting.
+			if value == "" {
+				return nil, fmt.Errorf("PRIVILEGE runtime requires a user")
+			}
+			spec.Warnings = append(spec.Warnings, fmt.Sprintf("PRIVILEGE runtime %s is deprecated and not applied; use PRIVILEGE user to set the run-as user", value))
+		case "worker":
+			if value == "" {
+				return nil, fmt.Errorf("PRIVILEGE worker requires a user")
+			}
+		case "cap-drop":
+			caps, err := parseCapabilities(mode, value, true)
+			if err != nil {
+				return nil, err
+			}
+			spec.CapDrop = caps
+		case "cap-add":
+			caps, err := parseCapabilities(mode, value, false)
+			if err != nil {
+				return nil, err
+			}
+			spec.CapAdd = caps
+		case "no-new-privileges":
+			enabled, err := parsePrivilegeBool(mode, value)
+			if err != nil {
+				return nil, err
+			}
+			spec.NoNewPrivileges = enabled
+		case "seccomp", "apparmor":
+			if value == "" || strings.ContainsAny(value, " \t") {
+				return nil, fmt.Errorf("PRIVILEGE %s: invalid profile %q", mode, value)
+			}
+			spec.SecurityOpt = append(spec.SecurityOpt, fmt.Sprintf("%s=%s", strings.ToLower(mode), value))
+		case "pids-limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("PRIVILEGE pids-limit: expected positive integer, got %q", value)
+			}
+			spec.PidsLimit = n
+		case "memory":
+			if !memLimitPattern.MatchString(value) {
+				return nil, fmt.Errorf("PRIVILEGE memory: invalid size %q (expected e.g. 512m or 2g)", value)
+			}
+			spec.MemLimit = strings.ToLower(value)
+		case "docker-socket":
+			enabled, err := parsePrivilegeBool(mode, value)
+			if err != nil {
+				return nil, err
+			}
+			spec.DockerSocket = enabled
+		default:
+			return nil, fmt.Errorf("unknown PRIVILEGE mode %q (supported: user, worker, runtime, cap-drop, cap-add, no-new-privileges, seccomp, apparmor, pids-limit, memory, docker-socket)", mode)
+		}
+	}
+
+	if spec.NoNewPrivileges {
+		spec.SecurityOpt = append([]string{"no-new-privileges:true"}, spec.SecurityOpt...)
+	}
+	for _, c := range spec.CapAdd {
+		for _, d := range spec.CapDrop {
+			if c == d {
+				return nil, fmt.Errorf("PRIVILEGE cap-add and cap-drop both list %s", c)
+			}
+		}
+	}
+	return spec, nil
+}
+
+func parseCapabilities(mode, value string, allowAll bool) ([]string, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("PRIVILEGE %s requires at least one capability", mode)
+	}
+	seen := make(map[string]struct{}, len(fields))
+	caps := make([]string, 0, len(fields))
+	for _, field := range fields {
+		c := strings.TrimPrefix(strings.ToUpper(field), "CAP_")
+		if c == "ALL" && !allowAll {
+			return nil, fmt.Errorf("PRIVILEGE %s ALL is not allowed", mode)
+		}
+		if !capabilityPattern.MatchString(c) {
+			return nil, fmt.Errorf("PRIVILEGE %s: invalid capability %q", mode, field)
+		}
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		caps = append(caps, c)
+	}
+	return caps, nil
+}
+
+func parsePrivilegeBool(mode, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "1":
+		return true, nil
+	case "false", "no", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("PRIVILEGE %s: expected true or false, got %q", mode, value)
+	}
+}
+
+// applyPrivileges merges privilege hardening into a compose service map.
+// Explicit compose keys in claw-pod.yml win for scalar settings; list
+// settings are appended.
+func applyPrivileges(serviceOut map[string]interface{}, spec *PrivilegeSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.User != "" {
+		if _, exists := serviceOut["user"]; !exists {
+			serviceOut["user"] = spec.User
+		}
+	}
+	if len(spec.CapDrop) > 0 {
+		capDrop, err := appendedSequence(serviceOut["cap_drop"], stringsToInterfaces(spec.CapDrop))
+		if err != nil {
+			return fmt.Errorf("cap_drop: %w", err)
+		}
+		serviceOut["cap_drop"] = capDrop
+	}
+	if len(spec.CapAdd) > 0 {
+		capAdd, err := appendedSequence(serviceOut["cap_add"], stringsToInterfaces(spec.CapAdd))
+		if err != nil {
+			return fmt.Errorf("cap_add: %w", err)
+		}
+		serviceOut["cap_add"] = capAdd
+	}
+	if len(spec.SecurityOpt) > 0 {
+		securityOpt, err := appendedSequence(serviceOut["security_opt"], stringsToInterfaces(spec.SecurityOpt))
+		if err != nil {
+			return fmt.Errorf("security_opt: %w", err)
+		}
+		serviceOut["security_opt"] = securityOpt
+	}
+	if spec.PidsLimit > 0 {
+		if _, exists := serviceOut["pids_limit"]; !exists {
+			serviceOut["pids_limit"] = spec.PidsLimit
+		}
+	}
+	if spec.MemLimit != "" {
+		if _, exists := serviceOut["mem_limit"]; !exists {
+			serviceOut["mem_limit"] = spec.MemLimit
+		}
+	}
+	return nil
+}
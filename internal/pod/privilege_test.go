@@ -0,0 +1,81 @@
+package pod
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrivilegesTranslatesModes(t *testing.T) {
+	spec, err := ParsePrivileges(map[string]string{
+		"user":              "1000:1000",
+		"cap-drop":          "ALL",
+		"cap-add":           "net_bind_service, CAP_CHOWN",
+		"no-new-privileges": "true",
+		"seccomp":           "/etc/claw/seccomp.json",
+		"apparmor":          "claw-default",
+		"pids-limit":        "256",
+		"memory":            "1G",
+		"docker-socket":     "true",
+	})
+	if err != nil {
+		t.Fatalf("ParsePrivileges: %v", err)
+	}
+	if spec.User != "1000:1000" {
+		t.Errorf("expected user 1000:1000, got %q", spec.User)
+	}
+	if !reflect.DeepEqual(spec.CapDrop, []string{"ALL"}) {
+		t.Errorf("unexpected cap_drop: %v", spec.CapDrop)
+	}
+	if !reflect.DeepEqual(spec.CapAdd, []string{"NET_BIND_SERVICE", "CHOWN"}) {
+		t.Errorf("unexpected cap_add: %v", spec.CapAdd)
+	}
+	wantOpts := []string{"no-new-privileges:true", "apparmor=claw-default", "seccomp=/etc/claw/seccomp.json"}
+	if !reflect.DeepEqual(spec.SecurityOpt, wantOpts) {
+		t.Errorf("unexpected security_opt: %v", spec.SecurityOpt)
+	}
+	if spec.PidsLimit != 256 || spec.MemLimit != "1g" {
+		t.Errorf("unexpected ceilings: pids=%d mem=%q", spec.PidsLimit, spec.MemLimit)
+	}
+	if !spec.DockerSocket {
+		t.Error("expected docker-socket to be recognized")
+	}
+}
+
+func TestParsePrivilegesAcceptsLegacyRuntimeAndWorker(t *testing.T) {
+	spec, err := ParsePrivileges(map[string]string{"worker": "root", "runtime": "node"})
+	if err != nil {
+		t.Fatalf("ParsePrivileges: %v", err)
+	}
+	if spec.User != "" {
+		t.Fatalf("expected legacy runtime to be record-only, got user %q", spec.User)
+	}
+	if len(spec.Warnings) != 1 || !strings.Contains(spec.Warnings[0], "deprecated") {
+		t.Fatalf("expected a deprecation warning, got %v", spec.Warnings)
+	}
+}
+
+func TestParsePrivilegesRejectsInvalidInput(t *testing.T) {
+	cases := []struct {
+		name string
+		in   map[string]string
+		want string
+	}{
+		{name: "unknown mode", in: map[string]string{"drop-everything": "true"}, want: "unknown PRIVILEGE mode"},
+		{name: "case-variant duplicate", in: map[string]string{"user": "1000", "User": "node"}, want: "declared twice"},
+		{name: "cap-add all", in: map[string]string{"cap-add": "ALL"}, want: "ALL is not allowed"},
+		{name: "bad capability", in: map[string]string{"cap-drop": "net-admin"}, want: "invalid capability"},
+		{name: "conflicting caps", in: map[string]string{"cap-add": "CHOWN", "cap-drop": "CHOWN"}, want: "both list"},
+		{name: "bad pids", in: map[string]string{"pids-limit": "0"}, want: "positive integer"},
+		{name: "bad memory", in: map[string]string{"memory": "lots"}, want: "invalid size"},
+		{name: "bad bool", in: map[string]string{"no-new-privileges": "maybe"}, want: "true or false"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePrivileges(tc.in)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -1,13 +1,20 @@
 package pod
 
-import "github.com/mostlydev/clawdapus/internal/driver"
+import (
+	"github.com/mostlydev/clawdapus/internal/cllama"
+	"github.com/mostlydev/clawdapus/internal/driver"
+)
 
 // Pod represents a parsed claw-pod.yml.
 type Pod struct {
-	Name     string
-	Services map[string]*Service
-	Compose  map[string]interface{} // preserved top-level compose keys except x-claw and services
-	Clawdash *ClawdashConfig        // runtime-only dashboard sidecar config, injected by claw up
+	Name         string
+	Services     map[string]*Service
+	Compose      map[string]interface{}        // preserved top-level compose keys except x-claw and services
+	VolumeAccess map[string][]VolumeAccessRule // volume name -> x-claw.access ACL from top-level volumes
+	Clawdash     *ClawdashConfig               // runtime-only dashboard sidecar config, injected by claw up
+	Egress       *EgressConfig                 // runtime-only egress proxy config, injected by claw up
+	MockLLM      *MockLLMConfig                // x-claw.mock-llm or claw up --mock-llm
+	Generations  int                           // x-claw.generations: runtime generations kept for rollback; 0 = default
 }
 
 // Service represents a service in a claw-pod.yml.
@@ -16,8 +23,9 @@ type Service struct {
 	Compose     map[string]interface{} // preserved compose service keys except x-claw
 	Claw        *ClawBlock
 	Environment map[string]string
-	Expose      []string // ports exposed to other containers (from compose expose:)
-	Ports       []string // container-side ports from compose ports: (host:container or plain container)
+	Expose      []string                   // ports exposed to other containers (from compose expose:)
+	Ports       []string                   // container-side ports from compose ports: (host:container or plain container)
+	Describe    *driver.ServiceDescription // x-claw.describe: compiled into consumers' surface skills
 }
 
 // InvokeEntry is a scheduled agent task declared in the pod x-claw.invoke block.
@@ -34,7 +42,11 @@ type ClawBlock struct {
 	Persona      string
 	Cllama       []string
 	CllamaEnv    map[string]string
+	CllamaCreds  map[string]string // x-claw.cllama-credentials: provider keys scoped to this agent
+	CllamaPolicy *cllama.Policy    // x-claw.cllama-policy merged over pod-level defaults
+	OwnPolicy    bool              // the service declares its own cllama-policy block
 	CllamaTokens map[string]string // runtime-only: expanded service name -> token
+	Privileges   map[string]string // runtime-only: PRIVILEGE modes from image labels
 	Count        int
 	Handles      map[string]*driver.HandleInfo // platform → contact card
 	Include      []IncludeEntry
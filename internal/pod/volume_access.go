@@ -0,0 +1,244 @@
+package pod
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// VolumeAccessRule grants services matching Service (a name or path.Match
+// glob such as "crusher-*") at most Mode access to a volume.
+type VolumeAccessRule struct {
+	Service string
+	Mode    string // "read-only" or "read-write"
+}
+
+// parseVolumeAccess reads x-claw.access blocks from top-level compose volumes
+// and strips the x-claw key from the volume definitions in place. Both the
+// documented list form and a plain mapping are accepted:
+//
+//	volumes:
+//	  shared-cache:
+//	    x-claw:
+//	      access:
+//	        - analyst: read-write
+//	        - "crusher-*": read-only
+func parseVolumeAccess(rawVolumes interface{}) (map[string][]VolumeAccessRule, error) {
+	volumes, err := mapStringAny(rawVolumes)
+	if err != nil {
+		return nil, fmt.Errorf("volumes: %w", err)
+	}
+	acls := make(map[string][]VolumeAccessRule)
+	for name, rawVolume := range volumes {
+		volume, err := mapStringAny(rawVolume)
+		if err != nil || volume == nil {
+			continue
+		}
+		rawClaw, ok := volume["x-claw"]
+		if !ok {
+			continue
+		}
+		delete(volume, "x-claw")
+
+		clawBlock, err := mapStringAny(rawClaw)
+		if err != nil {
+			return nil, fmt.Errorf("volume %q: x-claw: %w", name, err)
+		}
+		rules, err := parseVolumeAccessRules(clawBlock["access"])
+		if err != nil {
+			return nil, fmt.Errorf("volume %q: x-claw.access: %w", name, err)
+		}
+		if rules != nil {
+			acls[name] = rules
+		}
+	}
+	return acls, nil
+}
+
+func parseVolumeAccessRules(raw interface{}) ([]VolumeAccessRule, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	entries := make([]map[string]interface{}, 0)
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		entries = append(entries, v)
+	case []interface{}:
+		for i, item := range v {
+			m, err := mapStringAny(item)
+			if err != nil || m == nil {
+				return nil, fmt.Errorf("entry %d must be a <service>: <access-mode> mapping", i)
+			}
+			entries = append(entries, m)
+		}
+	default:
+		return nil, fmt.Errorf("expected list of <service>: <access-mode> entries, got %T", raw)
+	}
+
+	rules := make([]VolumeAccessRule, 0)
+	for _, entry := range entries {
+		patterns := make([]string, 0, len(entry))
+		for pattern := range entry {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, key := range patterns {
+			pattern := strings.TrimSpace(key)
+			if pattern == "" {
+				return nil, fmt.Errorf("empty service pattern")
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("service pattern %q: %w", pattern, err)
+			}
+			rawMode, ok := entry[key].(string)
+			if !ok {
+				return nil, fmt.Errorf("service pattern %q: access mode must be a string", pattern)
+			}
+			mode, err := normalizeVolumeMode(rawMode)
+			if err != nil {
+				return nil, fmt.Errorf("service pattern %q: %w", pattern, err)
+			}
+			rules = append(rules, VolumeAccessRule{Service: pattern, Mode: mode})
+		}
+	}
+	return rules, nil
+}
+
+func normalizeVolumeMode(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "read-write", "rw":
+		return "read-write", nil
+	case "read-only", "ro":
+		return "read-only", nil
+	default:
+		return "", fmt.Errorf("unsupported access mode %q (use read-only or read-write)", raw)
+	}
+}
+
+// ValidateVolumeAccess checks every volume mount in the pod against the
+// volume's x-claw.access ACL. Volumes without an ACL are unrestricted. Both
+// volume:// surfaces and plain compose volume mounts are checked; a service
+// must be granted at least the mode it mounts with.
+func ValidateVolumeAccess(p *Pod) error {
+	if len(p.VolumeAccess) == 0 {
+		return nil
+	}
+	for _, name := range sortedServiceNames(p.Services) {
+		svc := p.Services[name]
+		claims, err := serviceVolumeClaims(svc)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", name, err)
+		}
+		identities := []string{name}
+		if svc.Claw != nil && svc.Claw.Count > 1 {
+			identities = make([]string, 0, svc.Claw.Count)
+			for i := 0; i < svc.Claw.Count; i++ {
+				identities = append(identities, fmt.Sprintf("%s-%d", name, i))
+			}
+		}
+		for _, claim := range claims {
+			rules, ok := p.VolumeAccess[claim.volume]
+			if !ok {
+				continue
+			}
+			for _, identity := range identities {
+				granted := grantedVolumeMode(rules, name, identity)
+				if granted == "" {
+					return fmt.Errorf("service %q: volume %q is not granted to %q by x-claw.access", name, claim.volume, identity)
+				}
+				if claim.mode == "read-write" && granted == "read-only" {
+					return fmt.Errorf("service %q: volume %q is mounted read-write but x-claw.access grants %q read-only", name, claim.volume, identity)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// grantedVolumeMode returns the most permissive mode any rule grants to the
+// service, matching patterns against both the base and the ordinal name.
+func grantedVolumeMode(rules []VolumeAccessRule, base, identity string) string {
+	granted := ""
+	for _, rule := range rules {
+		matched, _ := path.Match(rule.Service, identity)
+		if !matched && base != identity {
+			matched, _ = path.Match(rule.Service, base)
+		}
+		if !matched {
+			continue
+		}
+		if rule.Mode == "read-write" {
+			return "read-write"
+		}
+		granted = rule.Mode
+	}
+	return granted
+}
+
+type volumeClaim struct {
+	volume string
+	mode   string
+}
+
+func serviceVolumeClaims(svc *Service) ([]volumeClaim, error) {
+	claims := make([]volumeClaim, 0)
+	if svc.Claw != nil {
+		for _, surface := range svc.Claw.Surfaces {
+			if surface.Scheme != "volume" {
+				continue
+			}
+			mode, err := surfaceAccessMode(surface)
+			if err != nil {
+				return nil, err
+			}
+			volumeMode := "read-write"
+			if mode == "ro" {
+				volumeMode = "read-only"
+			}
+			claims = append(claims, volumeClaim{volume: strings.TrimSpace(surface.Target), mode: volumeMode})
+		}
+	}
+
+	rawMounts, ok := svc.Compose["volumes"].([]interface{})
+	if !ok {
+		return claims, nil
+	}
+	for _, rawMount := range rawMounts {
+		switch m := rawMount.(type) {
+		case string:
+			parts := strings.Split(m, ":")
+			if len(parts) < 2 || !isNamedVolumeSource(parts[0]) {
+				continue
+			}
+			mode := "read-write"
+			if len(parts) > 2 {
+				for _, opt := range strings.Split(parts[2], ",") {
+					if strings.TrimSpace(opt) == "ro" {
+						mode = "read-only"
+					}
+				}
+			}
+			claims = append(claims, volumeClaim{volume: parts[0], mode: mode})
+		case map[string]interface{}:
+			if kind, _ := m["type"].(string); kind != "" && kind != "volume" {
+				continue
+			}
+			source, _ := m["source"].(string)
+			if !isNamedVolumeSource(source) {
+				continue
+			}
+			mode := "read-write"
+			if readOnly, _ := m["read_only"].(bool); readOnly {
+				mode = "read-only"
+			}
+			claims = append(claims, volumeClaim{volume: source, mode: mode})
+		}
+	}
+	return claims, nil
+}
+
+func isNamedVolumeSource(source string) bool {
+	source = strings.TrimSpace(source)
+	return source != "" && !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "~") && !strings.HasPrefix(source, "$")
+}
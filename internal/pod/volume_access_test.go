@@ -0,0 +1,129 @@
+package pod
+
+import (
+	"strings"
+	"testing"
+)
+
+const volumeACLPod = `
+x-claw:
+  pod: fleet
+services:
+  analyst:
+    image: analyst:latest
+    x-claw:
+      agent: ./AGENTS.md
+      surfaces:
+        - "volume://shared-cache read-write"
+  crusher:
+    image: crusher:latest
+    x-claw:
+      agent: ./AGENTS.md
+      count: 2
+      surfaces:
+        - "volume://shared-cache read-only"
+volumes:
+  shared-cache:
+    driver: local
+    x-claw:
+      access:
+        - analyst: read-write
+        - "crusher-*": read-only
+  scratch: {}
+`
+
+func TestParseVolumeAccess(t *testing.T) {
+	p, err := Parse(strings.NewReader(volumeACLPod))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	rules := p.VolumeAccess["shared-cache"]
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", rules)
+	}
+	if rules[0] != (VolumeAccessRule{Service: "analyst", Mode: "read-write"}) || rules[1] != (VolumeAccessRule{Service: "crusher-*", Mode: "read-only"}) {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if _, ok := p.VolumeAccess["scratch"]; ok {
+		t.Fatal("did not expect ACL for scratch")
+	}
+
+	volumes := p.Compose["volumes"].(map[string]interface{})
+	shared := volumes["shared-cache"].(map[string]interface{})
+	if _, ok := shared["x-claw"]; ok {
+		t.Fatal("expected x-claw stripped from compose volume definition")
+	}
+	if shared["driver"] != "local" {
+		t.Fatalf("expected other volume keys preserved, got %v", shared)
+	}
+}
+
+func TestParseVolumeAccessRejectsBadMode(t *testing.T) {
+	src := strings.Replace(volumeACLPod, "analyst: read-write", "analyst: write-only", 1)
+	_, err := Parse(strings.NewReader(src))
+	if err == nil || !strings.Contains(err.Error(), `volume "shared-cache"`) {
+		t.Fatalf("expected volume-scoped error, got %v", err)
+	}
+}
+
+func TestValidateVolumeAccessAllowsGrantedModes(t *testing.T) {
+	p, err := Parse(strings.NewReader(volumeACLPod))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := ValidateVolumeAccess(p); err != nil {
+		t.Fatalf("expected granted mounts to pass, got %v", err)
+	}
+}
+
+func TestValidateVolumeAccessRejectsEscalation(t *testing.T) {
+	src := strings.Replace(volumeACLPod, `"volume://shared-cache read-only"`, `"volume://shared-cache read-write"`, 1)
+	p, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	err = ValidateVolumeAccess(p)
+	if err == nil || !strings.Contains(err.Error(), `grants "crusher-0" read-only`) {
+		t.Fatalf("expected read-only escalation error, got %v", err)
+	}
+}
+
+func TestValidateVolumeAccessRejectsUngrantedService(t *testing.T) {
+	src := strings.Replace(volumeACLPod, `        - analyst: read-write
+`, "", 1)
+	p, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	err = ValidateVolumeAccess(p)
+	if err == nil || !strings.Contains(err.Error(), `not granted to "analyst"`) {
+		t.Fatalf("expected ungranted error, got %v", err)
+	}
+}
+
+func TestValidateVolumeAccessChecksComposeMounts(t *testing.T) {
+	p, err := Parse(strings.NewReader(volumeACLPod))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	p.Services["backup"] = &Service{
+		Image:   "backup:latest",
+		Compose: map[string]interface{}{"volumes": []interface{}{"shared-cache:/data:ro", "./local:/local"}},
+	}
+	err = ValidateVolumeAccess(p)
+	if err == nil || !strings.Contains(err.Error(), `service "backup"`) {
+		t.Fatalf("expected compose mount to be checked, got %v", err)
+	}
+
+	p.VolumeAccess["shared-cache"] = append(p.VolumeAccess["shared-cache"], VolumeAccessRule{Service: "backup", Mode: "read-only"})
+	if err := ValidateVolumeAccess(p); err != nil {
+		t.Fatalf("expected read-only compose mount to pass, got %v", err)
+	}
+
+	p.Services["backup"].Compose["volumes"] = []interface{}{
+		map[string]interface{}{"type": "volume", "source": "shared-cache", "target": "/data"},
+	}
+	if err := ValidateVolumeAccess(p); err == nil || !strings.Contains(err.Error(), "mounted read-write") {
+		t.Fatalf("expected long-syntax read-write mount to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package recipe turns TRACK mutation logs into promotable package sets.
+package recipe
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mutation is one JSONL record written by the TRACK package manager wrappers.
+type Mutation struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Manager   string            `json:"manager"`
+	Command   string            `json:"command"`
+	Action    string            `json:"action"`
+	Args      []string          `json:"args"`
+	ExitCode  int               `json:"exit_code"`
+	Versions  map[string]string `json:"versions"`
+}
+
+// Package is a single promoted package with its pinned version.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Recipe is the net set of packages a claw installed at runtime, per manager.
+type Recipe struct {
+	Service     string               `json:"service"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Since       *time.Time           `json:"since,omitempty"`
+	Packages    map[string][]Package `json:"packages"`
+	Skipped     []string             `json:"skipped,omitempty"`
+}
+
+// managerOrder is the order managers are promoted in: system packages first so
+// language toolchains they provide are available to later steps.
+var managerOrder = []string{"apt", "apk", "pip", "npm"}
+
+var (
+	packageNamePattern    = regexp.MustCompile(`^[A-Za-z0-9@][A-Za-z0-9@._/+-]*$`)
+	packageVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+:~-]*$`)
+)
+
+// ReadMutations decodes a JSONL mutation log. Blank and malformed lines are
+// skipped; a partially written last line must not make the log unreadable.
+func ReadMutations(r io.Reader) ([]Mutation, error) {
+	var out []Mutation
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var m Mutation
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			continue
+		}
+		out = append(out, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read mutation log: %w", err)
+	}
+	return out, nil
+}
+
+// LoadMutations reads a mutation log file. A missing file yields no mutations.
+func LoadMutations(path string) ([]Mutation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open mutation log: %w", err)
+	}
+	defer f.Close()
+	return ReadMutations(f)
+}
+
+// Build replays successful mutations at or after since (zero = all) in
+// timestamp order and returns the net installed package set. Later installs
+// override earlier versions; removals drop the package.
+func Build(service string, mutations []Mutation, since time.Time) *Recipe {
+	ordered := append([]Mutation(nil), mutations...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	state := make(map[string]map[string]string)
+	skipped := make(map[string]struct{})
+	for _, m := range ordered {
+		if m.ExitCode != 0 {
+			continue
+		}
+		if !since.IsZero() && m.Timestamp.Before(since) {
+			continue
+		}
+		remove, ok := classifyAction(m.Manager, m.Action)
+		if !ok {
+			continue
+		}
+		if m.Manager == "npm" && !remove && !hasGlobalFlag(m.Args) {
+			for name := range m.Versions {
+				skipped[fmt.Sprintf("npm: %s (project-local install)", name)] = struct{}{}
+			}
+			continue
+		}
+
+		pkgs := state[m.Manager]
+		if pkgs == nil {
+			pkgs = make(map[string]string)
+			state[m.Manager] = pkgs
+		}
+		for name, version := range m.Versions {
+			if remove {
+				delete(pkgs, name)
+				continue
+			}
+			if !packageNamePattern.MatchString(name) {
+				skipped[fmt.Sprintf("%s: %q (unsafe package name)", m.Manager, name)] = struct{}{}
+				continue
+			}
+			if version != "" && !packageVersionPattern.MatchString(version) {
+				skipped[fmt.Sprintf("%s: %s %q (unsafe version)", m.Manager, name, version)] = struct{}{}
+				version = ""
+			}
+			pkgs[name] = version
+		}
+	}
+
+	r := &Recipe{
+		Service:     service,
+		GeneratedAt: time.Now().UTC(),
+		Packages:    make(map[string][]Package),
+	}
+	if !since.IsZero() {
+		s := since.UTC()
+		r.Since = &s
+	}
+	for manager, pkgs := range state {
+		if len(pkgs) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(pkgs))
+		for name := range pkgs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			r.Packages[manager] = append(r.Packages[manager], Package{Name: name, Version: pkgs[name]})
+		}
+	}
+	for s := range skipped {
+		r.Skipped = append(r.Skipped, s)
+	}
+	sort.Strings(r.Skipped)
+	return r
+}
+
+// Empty reports whether the recipe promotes nothing.
+func (r *Recipe) Empty() bool {
+	for _, pkgs := range r.Packages {
+		if len(pkgs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Managers returns the managers with promoted packages in promotion order.
+func (r *Recipe) Managers() []string {
+	out := make([]string, 0, len(r.Packages))
+	for _, manager := range managerOrder {
+		if len(r.Packages[manager]) > 0 {
+			out = append(out, manager)
+		}
+	}
+	return out
+}
+
+// RunLines renders the recipe as Dockerfile RUN instructions, one per manager.
+func (r *Recipe) RunLines() []string {
+	lines := make([]string, 0, len(r.Packages))
+	for _, manager := range r.Managers() {
+		specs := make([]string, 0, len(r.Packages[manager]))
+		for _, pkg := range r.Packages[manager] {
+			specs = append(specs, pinnedSpec(manager, pkg))
+		}
+		joined := strings.Join(specs, " ")
+		switch manager {
+		case "apt":
+			lines = append(lines, "RUN apt-get update && apt-get install -y --no-install-recommends "+joined+" && rm -rf /var/lib/apt/lists/*")
+		case "apk":
+			lines = append(lines, "RUN apk add --no-cache "+joined)
+		case "pip":
+			lines = append(lines, "RUN pip install --no-cache-dir "+joined)
+		case "npm":
+			lines = append(lines, "RUN npm install -g "+joined)
+		}
+	}
+	return lines
+}
+
+func pinnedSpec(manager string, pkg Package) string {
+	if pkg.Version == "" {
+		return pkg.Name
+	}
+	switch manager {
+	case "pip":
+		return pkg.Name + "==" + pkg.Version
+	case "npm":
+		return pkg.Name + "@" + pkg.Version
+	default:
+		return pkg.Name + "=" + pkg.Version
+	}
+}
+
+// classifyAction reports whether action is a removal and whether it changes
+// the installed package set at all.
+func classifyAction(manager, action string) (remove bool, ok bool) {
+	switch manager + ":" + action {
+	case "apt:install", "apt:reinstall", "apk:add",
+		"pip:install",
+		"npm:install", "npm:i", "npm:add":
+		return false, true
+	case "apt:remove", "apt:purge", "apk:del",
+		"pip:uninstall",
+		"npm:uninstall", "npm:remove", "npm:rm", "npm:un":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+func hasGlobalFlag(args []string) bool {
+	for _, a := range args {
+		if a == "-g" || a == "--global" {
+			return true
+		}
+	}
+	return false
+}
+
+// Marshal renders the recipe file format.
+func (r *Recipe) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal recipe: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Save writes the recipe as <dir>/<timestamp>.json and returns the path.
+func Save(dir string, r *Recipe) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create recipe dir: %w", err)
+	}
+	data, err := r.Marshal()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.GeneratedAt.UTC().Format("20060102T150405Z")+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write recipe: %w", err)
+	}
+	return path, nil
+}
+
+// Load reads a recipe file.
+func Load(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read recipe: %w", err)
+	}
+	var r Recipe
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse recipe %q: %w", path, err)
+	}
+	if r.Packages == nil {
+		r.Packages = make(map[string][]Package)
+	}
+	for manager, pkgs := range r.Packages {
+		if !containsString(managerOrder, manager) {
+			return nil, fmt.Errorf("recipe %q: unsupported package manager %q", path, manager)
+		}
+		for _, pkg := range pkgs {
+			if !packageNamePattern.MatchString(pkg.Name) {
+				return nil, fmt.Errorf("recipe %q: unsafe %s package name %q", path, manager, pkg.Name)
+			}
+			if pkg.Version != "" && !packageVersionPattern.MatchString(pkg.Version) {
+				return nil, fmt.Errorf("recipe %q: unsafe %s version %q for %s", path, manager, pkg.Version, pkg.Name)
+			}
+		}
+	}
+	return &r, nil
+}
+
+// Latest returns the newest recipe file in dir.
+func Latest(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read recipe dir: %w", err)
+	}
+	latest := ""
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if entry.Name() > latest {
+			latest = entry.Name()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no recipes found in %s (run 'claw recipe' first)", dir)
+	}
+	return filepath.Join(dir, latest), nil
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package recipe
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleLog = `{"timestamp":"2026-03-01T10:00:00Z","manager":"pip","command":"pip","action":"install","args":["install","tiktoken==0.6.0"],"exit_code":0,"versions":{"tiktoken":"0.6.0"}}
+{"timestamp":"2026-03-02T10:00:00Z","manager":"pip","command":"pip","action":"install","args":["install","-U","tiktoken"],"exit_code":0,"versions":{"tiktoken":"0.7.0"}}
+{"timestamp":"2026-03-02T11:00:00Z","manager":"pip","command":"pip3","action":"install","args":["install","requests"],"exit_code":0,"versions":{"requests":"2.31.0"}}
+{"timestamp":"2026-03-02T12:00:00Z","manager":"pip","command":"pip","action":"uninstall","args":["uninstall","-y","requests"],"exit_code":0,"versions":{"requests":""}}
+{"timestamp":"2026-03-02T13:00:00Z","manager":"apt","command":"apt-get","action":"install","args":["install","-y","jq"],"exit_code":0,"versions":{"jq":"1.6-2.1"}}
+{"timestamp":"2026-03-02T14:00:00Z","manager":"apt","command":"apt-get","action":"install","args":["install","-y","nope"],"exit_code":100,"versions":{}}
+{"timestamp":"2026-03-02T15:00:00Z","manager":"npm","command":"npm","action":"install","args":["install","lodash"],"exit_code":0,"versions":{"lodash":"4.17.21"}}
+{"timestamp":"2026-03-02T16:00:00Z","manager":"npm","command":"npm","action":"install","args":["install","-g","@scope/cli@2"],"exit_code":0,"versions":{"@scope/cli":"2.1.0"}}
+{"timestamp":"2026-03-02T17:00:00Z","manager":"pip","command":"pip","action":"install","args":["install","x"],"exit_code":0,"versions":{"x; rm -rf /":"1"}}
+not json
+`
+
+func TestBuildResolvesNetPackageSet(t *testing.T) {
+	mutations, err := ReadMutations(strings.NewReader(sampleLog))
+	if err != nil {
+		t.Fatalf("ReadMutations: %v", err)
+	}
+	if len(mutations) != 9 {
+		t.Fatalf("expected 9 parsed mutations (malformed line skipped), got %d", len(mutations))
+	}
+
+	r := Build("crusher", mutations, time.Time{})
+
+	want := map[string][]Package{
+		"apt": {{Name: "jq", Version: "1.6-2.1"}},
+		"pip": {{Name: "tiktoken", Version: "0.7.0"}},
+		"npm": {{Name: "@scope/cli", Version: "2.1.0"}},
+	}
+	if !reflect.DeepEqual(r.Packages, want) {
+		t.Fatalf("unexpected packages:\n got %#v\nwant %#v", r.Packages, want)
+	}
+	if len(r.Skipped) != 2 {
+		t.Fatalf("expected project-local npm and unsafe pip name to be skipped, got %v", r.Skipped)
+	}
+	if r.Since != nil {
+		t.Fatalf("expected no since for full history, got %v", r.Since)
+	}
+}
+
+func TestBuildHonorsSince(t *testing.T) {
+	mutations, err := ReadMutations(strings.NewReader(sampleLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Build("crusher", mutations, time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC))
+	if _, ok := r.Packages["pip"]; ok {
+		t.Fatalf("expected pip installs before since to be ignored, got %v", r.Packages["pip"])
+	}
+	if len(r.Packages["apt"]) != 1 {
+		t.Fatalf("expected apt jq after since, got %v", r.Packages)
+	}
+}
+
+func TestRunLinesPinVersionsInManagerOrder(t *testing.T) {
+	r := &Recipe{Packages: map[string][]Package{
+		"npm": {{Name: "@scope/cli", Version: "2.1.0"}},
+		"pip": {{Name: "tiktoken", Version: "0.7.0"}, {Name: "trafilatura"}},
+		"apt": {{Name: "jq", Version: "1.6-2.1"}},
+	}}
+	got := r.RunLines()
+	want := []string{
+		"RUN apt-get update && apt-get install -y --no-install-recommends jq=1.6-2.1 && rm -rf /var/lib/apt/lists/*",
+		"RUN pip install --no-cache-dir tiktoken==0.7.0 trafilatura",
+		"RUN npm install -g @scope/cli@2.1.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected RUN lines:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSaveLoadLatestRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recipes")
+	older := &Recipe{Service: "crusher", GeneratedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Packages: map[string][]Package{"apt": {{Name: "curl"}}}}
+	newer := &Recipe{Service: "crusher", GeneratedAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Packages: map[string][]Package{"pip": {{Name: "tiktoken", Version: "0.7.0"}}}}
+	if _, err := Save(dir, older); err != nil {
+		t.Fatal(err)
+	}
+	newerPath, err := Save(dir, newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := Latest(dir)
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest != newerPath {
+		t.Fatalf("expected latest %s, got %s", newerPath, latest)
+	}
+	loaded, err := Load(latest)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Packages, newer.Packages) {
+		t.Fatalf("unexpected loaded packages: %#v", loaded.Packages)
+	}
+}
+
+func TestLatestWithoutRecipesFails(t *testing.T) {
+	if _, err := Latest(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no recipes found") {
+		t.Fatalf("expected no recipes error, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// credentialScanTimeout bounds exporting and walking an image filesystem.
+const credentialScanTimeout = 5 * time.Minute
+
+// credentialConfigDirs are per-user config directories that provider SDKs and
+// CLIs write keys into.
+var credentialConfigDirs = []string{
+	".config/openai",
+	".config/anthropic",
+	".config/openrouter",
+}
+
+// ScanImageCredentialFiles exports the filesystem of a never-started container
+// created from imageRef and returns the paths of well-known credential files,
+// sorted. The container is always removed.
+func ScanImageCredentialFiles(imageRef string) ([]string, error) {
+	docker, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("create docker client: %w", err)
+	}
+	defer docker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialScanTimeout)
+	defer cancel()
+
+	// The container is never started; the entrypoint only satisfies create for
+	// images that declare no command.
+	resp, err := docker.ContainerCreate(ctx, &container.Config{Image: imageRef, Entrypoint: []string{"true"}}, nil, nil, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("create scan container from %q: %w", imageRef, err)
+	}
+	defer docker.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	export, err := docker.ContainerExport(ctx, resp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("export %q filesystem: %w", imageRef, err)
+	}
+	defer export.Close()
+
+	files, err := scanCredentialFiles(export)
+	if err != nil {
+		return nil, fmt.Errorf("scan %q filesystem: %w", imageRef, err)
+	}
+	return files, nil
+}
+
+// scanCredentialFiles walks a filesystem tar stream and returns the absolute
+// paths of regular files that look like stored credentials.
+func scanCredentialFiles(r io.Reader) ([]string, error) {
+	tr := tar.NewReader(r)
+	found := make([]string, 0)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := "/" + strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if isCredentialFile(name) {
+			found = append(found, name)
+		}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+// isCredentialFile matches dotenv files, anything under a provider config
+// directory, and auth.json inside a dot-directory (for example
+// ~/.codex/auth.json). Package manager trees are skipped.
+func isCredentialFile(name string) bool {
+	if strings.Contains(name, "/node_modules/") || strings.Contains(name, "/site-packages/") {
+		return false
+	}
+	base := path.Base(name)
+	if base == ".env" {
+		return true
+	}
+	for _, dir := range credentialConfigDirs {
+		if strings.Contains(name, "/"+dir+"/") {
+			return true
+		}
+	}
+	if base == "auth.json" {
+		for _, part := range strings.Split(path.Dir(name), "/") {
+			if strings.HasPrefix(part, ".") && len(part) > 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
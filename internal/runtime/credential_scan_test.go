@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestScanCredentialFilesFindsWellKnownFiles(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []struct {
+		name string
+		typ  byte
+	}{
+		{"app/.env", tar.TypeReg},
+		{"root/.config/openai/credentials", tar.TypeReg},
+		{"root/.codex/auth.json", tar.TypeReg},
+		{"app/config/auth.json", tar.TypeReg},
+		{"app/node_modules/dotenv/.env", tar.TypeReg},
+		{"etc/.env", tar.TypeDir},
+		{"app/main.py", tar.TypeReg},
+	}
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: e.typ, Mode: 0o644}); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+
+	got, err := scanCredentialFiles(&buf)
+	if err != nil {
+		t.Fatalf("scanCredentialFiles: %v", err)
+	}
+	want := []string{"/app/.env", "/root/.codex/auth.json", "/root/.config/openai/credentials"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// discoveryTimeout bounds how long a throwaway discovery container may take to
+// start answering.
+const discoveryTimeout = 60 * time.Second
+
+// withDiscoveryContainer starts a throwaway container from imageRef with
+// containerPort published on a random loopback port and calls probe until it
+// succeeds or the discovery timeout expires. The container is always removed.
+func withDiscoveryContainer(imageRef, containerPort string, env map[string]string, probe func(ctx context.Context, hostPort string) error) error {
+	docker, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("create docker client: %w", err)
+	}
+	defer docker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	port, err := nat.NewPort("tcp", containerPort)
+	if err != nil {
+		return fmt.Errorf("discovery port %q: %w", containerPort, err)
+	}
+	envList := make([]string, 0, len(env))
+	for k, v := range env {
+		envList = append(envList, k+"="+v)
+	}
+	sort.Strings(envList)
+
+	resp, err := docker.ContainerCreate(ctx,
+		&container.Config{Image: imageRef, Env: envList, ExposedPorts: nat.PortSet{port: struct{}{}}},
+		&container.HostConfig{PortBindings: nat.PortMap{port: {{HostIP: "127.0.0.1"}}}},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("create discovery container from %q: %w", imageRef, err)
+	}
+	defer docker.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	if err := docker.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("start discovery container from %q: %w", imageRef, err)
+	}
+	info, err := docker.ContainerInspect(ctx, resp.ID)
+	if err != nil {
+		return fmt.Errorf("inspect discovery container: %w", err)
+	}
+	var hostPort string
+	if info.NetworkSettings != nil {
+		if bindings := info.NetworkSettings.Ports[port]; len(bindings) > 0 {
+			hostPort = bindings[0].HostPort
+		}
+	}
+	if hostPort == "" {
+		return fmt.Errorf("discovery port %s was not published", containerPort)
+	}
+
+	for {
+		err := probe(ctx, hostPort)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// splitPortPath parses the "<port>/<path>", ":<port>/<path>" and
+// "http://host:<port>/<path>" forms accepted by discovery labels.
+func splitPortPath(raw string) (string, string, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", "", fmt.Errorf("value is empty")
+	}
+	port, path := "", ""
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid URL %q: %w", raw, err)
+		}
+		port, path = u.Port(), u.Path
+		if port == "" {
+			port = "80"
+		}
+	} else {
+		value = strings.TrimPrefix(value, ":")
+		port = value
+		if idx := strings.Index(value, "/"); idx >= 0 {
+			port, path = value[:idx], value[idx:]
+		}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", "", fmt.Errorf("%q has invalid port %q", raw, port)
+	}
+	return port, path, nil
+}
@@ -0,0 +1,458 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/skillmd"
+)
+
+// MCP transports accepted in the claw.mcp.transport label.
+const (
+	MCPTransportHTTP = "http" // streamable HTTP (default)
+	MCPTransportSSE  = "sse"  // legacy HTTP+SSE
+)
+
+const mcpProtocolVersion = "2025-03-26"
+
+// MCPTool is one entry of an MCP tools/list response.
+type MCPTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// MCPEndpoint is a parsed claw.mcp.endpoint label: the container port and the
+// HTTP path the MCP server listens on.
+type MCPEndpoint struct {
+	Port      string
+	Path      string
+	Transport string
+}
+
+// ParseMCPEndpoint parses claw.mcp.endpoint ("8080/mcp", ":8080/mcp" or
+// "http://0.0.0.0:8080/mcp") and claw.mcp.transport ("http" or "sse").
+func ParseMCPEndpoint(endpoint, transport string) (MCPEndpoint, error) {
+	transport = strings.ToLower(strings.TrimSpace(transport))
+	switch transport {
+	case "", "streamable-http":
+		transport = MCPTransportHTTP
+	case MCPTransportHTTP, MCPTransportSSE:
+	default:
+		return MCPEndpoint{}, fmt.Errorf("unsupported MCP transport %q (use http or sse)", transport)
+	}
+
+	port, path, err := splitPortPath(endpoint)
+	if err != nil {
+		return MCPEndpoint{}, fmt.Errorf("MCP endpoint: %w", err)
+	}
+	if path == "" {
+		path = "/mcp"
+		if transport == MCPTransportSSE {
+			path = "/sse"
+		}
+	}
+	return MCPEndpoint{Port: port, Path: path, Transport: transport}, nil
+}
+
+// URL returns the endpoint URL for host.
+func (e MCPEndpoint) URL(host string) string {
+	return fmt.Sprintf("http://%s:%s%s", host, e.Port, e.Path)
+}
+
+// GenerateMCPServiceSkill renders a service surface skill from the tools an
+// MCP server advertised.
+func GenerateMCPServiceSkill(target string, endpoint MCPEndpoint, ports []string, tools []MCPTool) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("# %s (MCP service surface)\n\n", target))
+	b.WriteString("## Connection\n")
+	b.WriteString(fmt.Sprintf("- **Hostname:** %s\n", target))
+	b.WriteString("- **Network:** claw-internal (pod-internal, no external access)\n")
+	if len(ports) > 0 {
+		b.WriteString(fmt.Sprintf("- **Ports:** %s\n", strings.Join(ports, ", ")))
+	}
+	b.WriteString(fmt.Sprintf("- **MCP endpoint:** `%s` (%s transport)\n", endpoint.URL(target), endpoint.Transport))
+	b.WriteString("\n## Tools\n")
+	if len(tools) == 0 {
+		b.WriteString("The server advertised no tools at startup.\n")
+	}
+
+	sorted := append([]MCPTool(nil), tools...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, tool := range sorted {
+		b.WriteString(fmt.Sprintf("\n### %s\n", tool.Name))
+		if desc := strings.TrimSpace(tool.Description); desc != "" {
+			b.WriteString("\n" + desc + "\n")
+		}
+		if schema := indentJSON(tool.InputSchema); schema != "" {
+			b.WriteString("\nInput schema:\n\n```json\n" + schema + "\n```\n")
+		}
+	}
+
+	skillID := strings.TrimSpace(strings.ReplaceAll(target, "/", "-"))
+	if skillID == "" {
+		skillID = "unknown"
+	}
+	return skillmd.Format(
+		fmt.Sprintf("surface-%s", skillID),
+		fmt.Sprintf("MCP tools exposed by the %s service surface.", target),
+		b.String(),
+	)
+}
+
+func indentJSON(raw json.RawMessage) string {
+	if len(bytes.TrimSpace(raw)) == 0 || string(bytes.TrimSpace(raw)) == "null" {
+		return ""
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		return ""
+	}
+	return out.String()
+}
+
+// DiscoverMCPTools starts a throwaway container from imageRef with the MCP
+// port published on loopback, lists its tools, and removes the container.
+func DiscoverMCPTools(imageRef string, endpoint MCPEndpoint, env map[string]string) ([]MCPTool, error) {
+	var tools []MCPTool
+	err := withDiscoveryContainer(imageRef, endpoint.Port, env, func(ctx context.Context, hostPort string) error {
+		local := endpoint
+		local.Port = hostPort
+		var err error
+		tools, err = ListMCPTools(ctx, local.URL("127.0.0.1"), local.Transport, nil)
+		if err != nil {
+			return fmt.Errorf("MCP server did not answer tools/list: %w", err)
+		}
+		return nil
+	})
+	return tools, err
+}
+
+// ListMCPTools runs the MCP handshake against endpointURL and pages through
+// tools/list.
+func ListMCPTools(ctx context.Context, endpointURL, transport string, httpClient *http.Client) ([]MCPTool, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	var session mcpSession
+	switch transport {
+	case "", MCPTransportHTTP:
+		session = &streamableSession{url: endpointURL, client: httpClient}
+	case MCPTransportSSE:
+		sse, err := openSSESession(ctx, endpointURL)
+		if err != nil {
+			return nil, err
+		}
+		defer sse.Close()
+		session = sse
+	default:
+		return nil, fmt.Errorf("unsupported MCP transport %q", transport)
+	}
+
+	if _, err := session.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "claw", "version": "1"},
+	}); err != nil {
+		return nil, fmt.Errorf("MCP initialize: %w", err)
+	}
+	if err := session.notify(ctx, "notifications/initialized"); err != nil {
+		return nil, fmt.Errorf("MCP initialized notification: %w", err)
+	}
+
+	tools := make([]MCPTool, 0)
+	cursor := ""
+	for page := 0; page < 100; page++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		result, err := session.call(ctx, "tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("MCP tools/list: %w", err)
+		}
+		var listed struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(result, &listed); err != nil {
+			return nil, fmt.Errorf("decode tools/list result: %w", err)
+		}
+		tools = append(tools, listed.Tools...)
+		if listed.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = listed.NextCursor
+	}
+	return nil, fmt.Errorf("MCP tools/list: too many pages")
+}
+
+type mcpSession interface {
+	call(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+	notify(ctx context.Context, method string) error
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (m *rpcMessage) outcome() (json.RawMessage, error) {
+	if m.Error != nil {
+		return nil, fmt.Errorf("rpc error %d: %s", m.Error.Code, m.Error.Message)
+	}
+	return m.Result, nil
+}
+
+// streamableSession speaks the streamable HTTP transport: every message is a
+// POST whose response is JSON or a short SSE stream.
+type streamableSession struct {
+	url       string
+	client    *http.Client
+	sessionID string
+	nextID    int
+}
+
+func (s *streamableSession) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.nextID++
+	id := s.nextID
+	resp, err := s.post(ctx, rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected HTTP status %d", method, resp.StatusCode)
+	}
+	if sid := resp.Header.Get("Mcp-Session-Id"); sid != "" {
+		s.sessionID = sid
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var found *rpcMessage
+		err := readSSE(resp.Body, func(event, data string) bool {
+			var msg rpcMessage
+			if json.Unmarshal([]byte(data), &msg) == nil && msg.ID != nil && *msg.ID == id {
+				found = &msg
+				return false
+			}
+			return true
+		})
+		if found == nil {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("%s: no response in event stream: %w", method, err)
+		}
+		return found.outcome()
+	}
+
+	var msg rpcMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("%s: decode response: %w", method, err)
+	}
+	return msg.outcome()
+}
+
+func (s *streamableSession) notify(ctx context.Context, method string) error {
+	resp, err := s.post(ctx, rpcMessage{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected HTTP status %d", method, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *streamableSession) post(ctx context.Context, msg rpcMessage) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if s.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", s.sessionID)
+		req.Header.Set("MCP-Protocol-Version", mcpProtocolVersion)
+	}
+	return s.client.Do(req)
+}
+
+// sseSession speaks the legacy HTTP+SSE transport: responses arrive on a
+// long-lived event stream, requests are POSTed to the announced endpoint.
+type sseSession struct {
+	postURL string
+	body    io.ReadCloser
+	nextID  int
+
+	mu       sync.Mutex
+	pending  map[int]chan rpcMessage
+	closed   chan struct{}
+	closeErr error
+}
+
+func openSSESession(ctx context.Context, endpointURL string) (*sseSession, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("open SSE stream: unexpected HTTP status %d", resp.StatusCode)
+	}
+
+	s := &sseSession{body: resp.Body, pending: make(map[int]chan rpcMessage), closed: make(chan struct{})}
+	endpointCh := make(chan string, 1)
+	go func() {
+		err := readSSE(resp.Body, func(event, data string) bool {
+			if event == "endpoint" {
+				select {
+				case endpointCh <- data:
+				default:
+				}
+				return true
+			}
+			var msg rpcMessage
+			if json.Unmarshal([]byte(data), &msg) != nil || msg.ID == nil {
+				return true
+			}
+			s.mu.Lock()
+			ch := s.pending[*msg.ID]
+			delete(s.pending, *msg.ID)
+			s.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+			return true
+		})
+		s.closeErr = err
+		close(s.closed)
+	}()
+
+	select {
+	case endpoint := <-endpointCh:
+		base, err := url.Parse(endpointURL)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		ref, err := url.Parse(strings.TrimSpace(endpoint))
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("invalid SSE endpoint event %q: %w", endpoint, err)
+		}
+		s.postURL = base.ResolveReference(ref).String()
+		return s, nil
+	case <-s.closed:
+		return nil, fmt.Errorf("SSE stream closed before endpoint event")
+	case <-ctx.Done():
+		s.Close()
+		return nil, ctx.Err()
+	}
+}
+
+func (s *sseSession) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.nextID++
+	id := s.nextID
+	ch := make(chan rpcMessage, 1)
+	s.mu.Lock()
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	if err := s.post(ctx, rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+	select {
+	case msg := <-ch:
+		return msg.outcome()
+	case <-s.closed:
+		return nil, fmt.Errorf("%s: SSE stream closed: %v", method, s.closeErr)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *sseSession) notify(ctx context.Context, method string) error {
+	return s.post(ctx, rpcMessage{JSONRPC: "2.0", Method: method})
+}
+
+func (s *sseSession) post(ctx context.Context, msg rpcMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.postURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected HTTP status %d", msg.Method, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *sseSession) Close() error {
+	return s.body.Close()
+}
+
+// readSSE dispatches server-sent events to fn until it returns false or the
+// stream ends.
+func readSSE(r io.Reader, fn func(event, data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	event := ""
+	data := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if !fn(event, strings.Join(data, "\n")) {
+					return nil
+				}
+			}
+			event, data = "", data[:0]
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
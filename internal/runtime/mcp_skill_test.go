@@ -0,0 +1,192 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseMCPEndpoint(t *testing.T) {
+	cases := []struct {
+		endpoint, transport string
+		want                MCPEndpoint
+	}{
+		{"8080/mcp", "", MCPEndpoint{Port: "8080", Path: "/mcp", Transport: "http"}},
+		{":3000", "http", MCPEndpoint{Port: "3000", Path: "/mcp", Transport: "http"}},
+		{"9000", "sse", MCPEndpoint{Port: "9000", Path: "/sse", Transport: "sse"}},
+		{"http://0.0.0.0:8081/v1/mcp", "streamable-http", MCPEndpoint{Port: "8081", Path: "/v1/mcp", Transport: "http"}},
+	}
+	for _, tc := range cases {
+		got, err := ParseMCPEndpoint(tc.endpoint, tc.transport)
+		if err != nil {
+			t.Fatalf("ParseMCPEndpoint(%q, %q): %v", tc.endpoint, tc.transport, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ParseMCPEndpoint(%q, %q) = %+v, want %+v", tc.endpoint, tc.transport, got, tc.want)
+		}
+	}
+
+	for _, bad := range [][2]string{{"", ""}, {"mcp", ""}, {"70000/mcp", ""}, {"8080", "stdio"}} {
+		if _, err := ParseMCPEndpoint(bad[0], bad[1]); err == nil {
+			t.Fatalf("ParseMCPEndpoint(%q, %q): expected error", bad[0], bad[1])
+		}
+	}
+}
+
+// fakeMCPHandler answers initialize and a two-page tools/list over the
+// streamable HTTP transport, replying with SSE when sse is set.
+func fakeMCPHandler(t *testing.T, sse bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     *int                   `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req.Method != "initialize" && r.Header.Get("Mcp-Session-Id") != "sess-1" {
+			t.Errorf("%s: missing session header", req.Method)
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+			result = map[string]interface{}{"protocolVersion": mcpProtocolVersion, "capabilities": map[string]interface{}{"tools": map[string]interface{}{}}}
+		case "tools/list":
+			if req.Params["cursor"] == "page-2" {
+				result = map[string]interface{}{"tools": []interface{}{map[string]interface{}{"name": "get_whale_activity", "description": "Large wallet movements"}}}
+			} else {
+				result = map[string]interface{}{
+					"tools": []interface{}{map[string]interface{}{
+						"name":        "get_price",
+						"description": "Current token price",
+						"inputSchema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"symbol": map[string]string{"type": "string"}}},
+					}},
+					"nextCursor": "page-2",
+				}
+			}
+		default:
+			t.Errorf("unexpected method %q", req.Method)
+		}
+		payload, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": *req.ID, "result": result})
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, ": keepalive\n\nevent: message\ndata: %s\n\n", payload)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	})
+}
+
+func TestListMCPToolsStreamableHTTP(t *testing.T) {
+	for _, sse := range []bool{false, true} {
+		srv := httptest.NewServer(fakeMCPHandler(t, sse))
+		tools, err := ListMCPTools(context.Background(), srv.URL+"/mcp", MCPTransportHTTP, nil)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("sse=%v: ListMCPTools: %v", sse, err)
+		}
+		if len(tools) != 2 || tools[0].Name != "get_price" || tools[1].Name != "get_whale_activity" {
+			t.Fatalf("sse=%v: unexpected tools %+v", sse, tools)
+		}
+		if !strings.Contains(string(tools[0].InputSchema), `"symbol"`) {
+			t.Fatalf("sse=%v: expected input schema, got %s", sse, tools[0].InputSchema)
+		}
+	}
+}
+
+func TestListMCPToolsLegacySSE(t *testing.T) {
+	messages := make(chan string, 4)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case msg := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		_ = json.Unmarshal(body, &req)
+		w.WriteHeader(http.StatusAccepted)
+		if req.ID == nil {
+			return
+		}
+		result := `{}`
+		if req.Method == "tools/list" {
+			result = `{"tools":[{"name":"search","description":"Full-text search"}]}`
+		}
+		messages <- fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, *req.ID, result)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tools, err := ListMCPTools(context.Background(), srv.URL+"/sse", MCPTransportSSE, nil)
+	if err != nil {
+		t.Fatalf("ListMCPTools: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "search" {
+		t.Fatalf("unexpected tools %+v", tools)
+	}
+}
+
+func TestListMCPToolsReportsRPCError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := ListMCPTools(context.Background(), srv.URL, MCPTransportHTTP, nil)
+	if err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Fatalf("expected rpc error, got %v", err)
+	}
+}
+
+func TestGenerateMCPServiceSkill(t *testing.T) {
+	endpoint := MCPEndpoint{Port: "8080", Path: "/mcp", Transport: MCPTransportHTTP}
+	result := GenerateMCPServiceSkill("market-scanner", endpoint, []string{"8080"}, []MCPTool{
+		{Name: "get_whale_activity", Description: "Large wallet movements in last N hours"},
+		{Name: "get_price", Description: "Current token price", InputSchema: json.RawMessage(`{"type":"object","required":["symbol"]}`)},
+	})
+
+	for _, want := range []string{
+		`name: "surface-market-scanner"`,
+		"`http://market-scanner:8080/mcp` (http transport)",
+		"### get_price",
+		"Current token price",
+		`"required": [`,
+		"### get_whale_activity",
+	} {
+		if !strings.Contains(result, want) {
+			t.Fatalf("expected %q in skill:\n%s", want, result)
+		}
+	}
+	if strings.Index(result, "### get_price") > strings.Index(result, "### get_whale_activity") {
+		t.Fatal("expected tools sorted by name")
+	}
+}
@@ -0,0 +1,474 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mostlydev/clawdapus/internal/skillmd"
+	"gopkg.in/yaml.v3"
+)
+
+// maxOpenAPISpecBytes bounds how much of a served OpenAPI document is read.
+const maxOpenAPISpecBytes = 8 << 20
+
+// OpenAPISource is a parsed claw.openapi label: either a file inside the
+// image, or a port and URL path the running service serves the spec on.
+type OpenAPISource struct {
+	File string // absolute path inside the image
+	Port string
+	Path string
+}
+
+// ParseOpenAPISource parses claw.openapi. Absolute paths ("/app/openapi.yaml")
+// are read from the image; "8080/openapi.json", ":8080/openapi.json" and
+// "http://0.0.0.0:8080/openapi.json" are fetched from the running service.
+func ParseOpenAPISource(label string) (OpenAPISource, error) {
+	raw := strings.TrimSpace(label)
+	if raw == "" {
+		return OpenAPISource{}, fmt.Errorf("OpenAPI source is empty")
+	}
+	if strings.HasPrefix(raw, "/") {
+		return OpenAPISource{File: raw}, nil
+	}
+	port, path, err := splitPortPath(raw)
+	if err != nil {
+		return OpenAPISource{}, fmt.Errorf("OpenAPI source: %w", err)
+	}
+	if path == "" || path == "/" {
+		return OpenAPISource{}, fmt.Errorf("OpenAPI source %q has no URL path", label)
+	}
+	return OpenAPISource{Port: port, Path: path}, nil
+}
+
+// FetchOpenAPISpec reads the OpenAPI document of imageRef: file sources are
+// copied out of the image; URL sources are fetched from a throwaway container.
+func FetchOpenAPISpec(imageRef string, source OpenAPISource, env map[string]string) ([]byte, error) {
+	if source.File != "" {
+		return ExtractServiceSkill(imageRef, source.File)
+	}
+	var spec []byte
+	err := withDiscoveryContainer(imageRef, source.Port, env, func(ctx context.Context, hostPort string) error {
+		var err error
+		spec, err = GetOpenAPISpec(ctx, fmt.Sprintf("http://127.0.0.1:%s%s", hostPort, source.Path), nil)
+		return err
+	})
+	return spec, err
+}
+
+// GetOpenAPISpec downloads an OpenAPI document.
+func GetOpenAPISpec(ctx context.Context, specURL string, httpClient *http.Client) ([]byte, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.5")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", specURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOpenAPISpecBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read OpenAPI spec: %w", err)
+	}
+	return data, nil
+}
+
+type openAPIDoc struct {
+	Swagger string `yaml:"swagger"`
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title       string `yaml:"title"`
+		Version     string `yaml:"version"`
+		Description string `yaml:"description"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	BasePath   string                           `yaml:"basePath"`
+	Paths      map[string]openAPIPathItem       `yaml:"paths"`
+	Parameters map[string]openAPIParameter      `yaml:"parameters"`
+	Security   []map[string][]string            `yaml:"security"`
+	SecDefs    map[string]openAPISecurityScheme `yaml:"securityDefinitions"`
+	Components struct {
+		Parameters      map[string]openAPIParameter      `yaml:"parameters"`
+		SecuritySchemes map[string]openAPISecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation  `yaml:"get"`
+	Put        *openAPIOperation  `yaml:"put"`
+	Post       *openAPIOperation  `yaml:"post"`
+	Delete     *openAPIOperation  `yaml:"delete"`
+	Options    *openAPIOperation  `yaml:"options"`
+	Head       *openAPIOperation  `yaml:"head"`
+	Patch      *openAPIOperation  `yaml:"patch"`
+}
+
+type openAPIOperation struct {
+	OperationID string                 `yaml:"operationId"`
+	Summary     string                 `yaml:"summary"`
+	Description string                 `yaml:"description"`
+	Deprecated  bool                   `yaml:"deprecated"`
+	Parameters  []openAPIParameter     `yaml:"parameters"`
+	Consumes    []string               `yaml:"consumes"`
+	Security    *[]map[string][]string `yaml:"security"`
+	RequestBody *struct {
+		Required bool                   `yaml:"required"`
+		Content  map[string]interface{} `yaml:"content"`
+	} `yaml:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref      string `yaml:"$ref"`
+	Name     string `yaml:"name"`
+	In       string `yaml:"in"`
+	Required bool   `yaml:"required"`
+	Type     string `yaml:"type"`
+	Schema   *struct {
+		Ref  string `yaml:"$ref"`
+		Type string `yaml:"type"`
+	} `yaml:"schema"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `yaml:"type"`
+	Scheme string `yaml:"scheme"`
+	In     string `yaml:"in"`
+	Name   string `yaml:"name"`
+	Env    string `yaml:"x-claw-env"`
+}
+
+// GenerateOpenAPIServiceSkill renders a compact endpoint catalog from an
+// OpenAPI 3.x or Swagger 2.0 document (JSON or YAML). authEnv maps security
+// scheme names to the env vars that hold their credentials; the "" key
+// applies to every scheme without its own entry or x-claw-env extension.
+func GenerateOpenAPIServiceSkill(target string, source OpenAPISource, ports []string, spec []byte, authEnv map[string]string) (string, error) {
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return "", fmt.Errorf("parse OpenAPI spec: %w", err)
+	}
+	if doc.OpenAPI == "" && doc.Swagger == "" {
+		return "", fmt.Errorf("parse OpenAPI spec: missing openapi or swagger version field")
+	}
+	schemes := doc.Components.SecuritySchemes
+	if len(schemes) == 0 {
+		schemes = doc.SecDefs
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s (REST service surface)\n\n", target))
+	if title := strings.TrimSpace(doc.Info.Title); title != "" {
+		line := title
+		if v := strings.TrimSpace(doc.Info.Version); v != "" {
+			line += " " + v
+		}
+		b.WriteString(line + "\n\n")
+	}
+	b.WriteString("## Connection\n")
+	b.WriteString(fmt.Sprintf("- **Hostname:** %s\n", target))
+	b.WriteString("- **Network:** claw-internal (pod-internal, no external access)\n")
+	if len(ports) > 0 {
+		b.WriteString(fmt.Sprintf("- **Ports:** %s\n", strings.Join(ports, ", ")))
+	}
+	if base := openAPIBaseURL(target, source, ports, &doc); base != "" {
+		b.WriteString(fmt.Sprintf("- **Base URL:** `%s`\n", base))
+	}
+
+	if len(schemes) > 0 {
+		b.WriteString("\n## Authentication\n")
+		for _, name := range sortedKeys(schemes) {
+			scheme := schemes[name]
+			line := fmt.Sprintf("- `%s`: %s", name, describeSecurityScheme(scheme))
+			if env := securitySchemeEnv(name, scheme, authEnv); env != "" {
+				line += fmt.Sprintf(" — credential in `$%s`", env)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	b.WriteString("\n## Endpoints\n")
+	paths := sortedKeys(doc.Paths)
+	if len(paths) == 0 {
+		b.WriteString("The spec declares no paths.\n")
+	}
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, op := range item.operations() {
+			b.WriteString(renderOpenAPIOperation(path, op.method, op.op, item.Parameters, &doc))
+		}
+	}
+
+	skillID := strings.TrimSpace(strings.ReplaceAll(target, "/", "-"))
+	if skillID == "" {
+		skillID = "unknown"
+	}
+	return skillmd.Format(
+		fmt.Sprintf("surface-%s", skillID),
+		fmt.Sprintf("REST endpoints exposed by the %s service surface.", target),
+		b.String(),
+	), nil
+}
+
+type methodOperation struct {
+	method string
+	op     *openAPIOperation
+}
+
+func (p openAPIPathItem) operations() []methodOperation {
+	all := []methodOperation{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options},
+	}
+	out := make([]methodOperation, 0, len(all))
+	for _, mo := range all {
+		if mo.op != nil {
+			out = append(out, mo)
+		}
+	}
+	return out
+}
+
+func renderOpenAPIOperation(path, method string, op *openAPIOperation, shared []openAPIParameter, doc *openAPIDoc) string {
+	var b strings.Builder
+	line := fmt.Sprintf("- `%s %s`", method, path)
+	summary := strings.TrimSpace(op.Summary)
+	if summary == "" {
+		summary = firstLine(op.Description)
+	}
+	if summary != "" {
+		line += " — " + summary
+	}
+	if op.OperationID != "" {
+		line += fmt.Sprintf(" (`%s`)", op.OperationID)
+	}
+	if op.Deprecated {
+		line += " **deprecated**"
+	}
+	b.WriteString(line + "\n")
+
+	params := make([]string, 0)
+	bodyTypes := []string(nil)
+	bodyRequired := false
+	for _, param := range mergeOpenAPIParameters(shared, op.Parameters, doc) {
+		if param.In == "body" {
+			bodyTypes = append([]string(nil), op.Consumes...)
+			if len(bodyTypes) == 0 {
+				bodyTypes = []string{"application/json"}
+			}
+			bodyRequired = param.Required
+			continue
+		}
+		desc := fmt.Sprintf("`%s` (%s", param.Name, param.In)
+		if t := param.typeName(); t != "" {
+			desc += ", " + t
+		}
+		if param.Required {
+			desc += ", required"
+		}
+		params = append(params, desc+")")
+	}
+	if len(params) > 0 {
+		b.WriteString("  - params: " + strings.Join(params, ", ") + "\n")
+	}
+	if op.RequestBody != nil {
+		bodyTypes = sortedKeys(op.RequestBody.Content)
+		bodyRequired = op.RequestBody.Required
+	}
+	if len(bodyTypes) > 0 {
+		body := "  - body: " + strings.Join(bodyTypes, ", ")
+		if bodyRequired {
+			body += " (required)"
+		}
+		b.WriteString(body + "\n")
+	}
+
+	security := doc.Security
+	if op.Security != nil {
+		// An explicit empty list opts the operation out of the global requirement.
+		security = *op.Security
+		if len(security) == 0 {
+			security = []map[string][]string{{}}
+		}
+	}
+	if auth := securityRequirementNames(security); auth != "" {
+		b.WriteString("  - auth: " + auth + "\n")
+	}
+	return b.String()
+}
+
+// mergeOpenAPIParameters resolves $refs and lets operation parameters override
+// path-level ones with the same name and location.
+func mergeOpenAPIParameters(shared, own []openAPIParameter, doc *openAPIDoc) []openAPIParameter {
+	out := make([]openAPIParameter, 0, len(shared)+len(own))
+	index := make(map[string]int)
+	for _, list := range [][]openAPIParameter{shared, own} {
+		for _, param := range list {
+			param = resolveOpenAPIParameter(param, doc)
+			if param.Name == "" {
+				continue
+			}
+			key := param.In + "\x00" + param.Name
+			if i, ok := index[key]; ok {
+				out[i] = param
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, param)
+		}
+	}
+	return out
+}
+
+func resolveOpenAPIParameter(param openAPIParameter, doc *openAPIDoc) openAPIParameter {
+	if param.Ref == "" {
+		return param
+	}
+	name := param.Ref[strings.LastIndex(param.Ref, "/")+1:]
+	switch {
+	case strings.HasPrefix(param.Ref, "#/components/parameters/"):
+		return doc.Components.Parameters[name]
+	case strings.HasPrefix(param.Ref, "#/parameters/"):
+		return doc.Parameters[name]
+	}
+	return openAPIParameter{}
+}
+
+func (p openAPIParameter) typeName() string {
+	if p.Type != "" {
+		return p.Type
+	}
+	if p.Schema == nil {
+		return ""
+	}
+	if p.Schema.Type != "" {
+		return p.Schema.Type
+	}
+	if p.Schema.Ref != "" {
+		return p.Schema.Ref[strings.LastIndex(p.Schema.Ref, "/")+1:]
+	}
+	return ""
+}
+
+func describeSecurityScheme(s openAPISecurityScheme) string {
+	switch strings.ToLower(s.Type) {
+	case "http":
+		if strings.EqualFold(s.Scheme, "bearer") {
+			return "HTTP bearer token in the `Authorization` header"
+		}
+		if strings.EqualFold(s.Scheme, "basic") {
+			return "HTTP basic auth"
+		}
+		return fmt.Sprintf("HTTP %s auth", s.Scheme)
+	case "basic":
+		return "HTTP basic auth"
+	case "apikey":
+		return fmt.Sprintf("API key in the `%s` %s", s.Name, s.In)
+	case "oauth2":
+		return "OAuth2 access token"
+	case "openidconnect":
+		return "OpenID Connect token"
+	default:
+		return s.Type
+	}
+}
+
+func securitySchemeEnv(name string, scheme openAPISecurityScheme, authEnv map[string]string) string {
+	if env := strings.TrimSpace(authEnv[name]); env != "" {
+		return env
+	}
+	if env := strings.TrimSpace(scheme.Env); env != "" {
+		return env
+	}
+	return strings.TrimSpace(authEnv[""])
+}
+
+func securityRequirementNames(reqs []map[string][]string) string {
+	if len(reqs) == 0 {
+		return ""
+	}
+	alternatives := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if len(req) == 0 {
+			alternatives = append(alternatives, "none")
+			continue
+		}
+		alternatives = append(alternatives, strings.Join(sortedKeys(req), " + "))
+	}
+	return strings.Join(alternatives, " or ")
+}
+
+// ParseOpenAPIAuthEnv parses claw.openapi.auth-env: comma-separated
+// "<scheme>=<ENV_VAR>" pairs, or a bare env var name for every scheme.
+func ParseOpenAPIAuthEnv(label string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, entry := range strings.Split(label, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scheme, env := "", entry
+		if k, v, ok := strings.Cut(entry, "="); ok {
+			scheme, env = strings.TrimSpace(k), strings.TrimSpace(v)
+			if scheme == "" {
+				return nil, fmt.Errorf("auth env entry %q has an empty scheme name", entry)
+			}
+		}
+		if env == "" {
+			return nil, fmt.Errorf("auth env entry %q has an empty env var name", entry)
+		}
+		out[scheme] = env
+	}
+	return out, nil
+}
+
+func openAPIBaseURL(target string, source OpenAPISource, ports []string, doc *openAPIDoc) string {
+	port := source.Port
+	if port == "" && len(ports) > 0 {
+		port = ports[0]
+	}
+	basePath := doc.BasePath
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(strings.TrimSpace(doc.Servers[0].URL)); err == nil {
+			basePath = u.Path
+			if port == "" {
+				port = u.Port()
+			}
+		}
+	}
+	if port == "" {
+		return ""
+	}
+	return fmt.Sprintf("http://%s:%s%s", target, port, strings.TrimRight(basePath, "/"))
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		s = strings.TrimSpace(s[:idx])
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
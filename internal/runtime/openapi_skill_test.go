@@ -0,0 +1,199 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info:
+  title: Trading API
+  version: "2.1"
+servers:
+  - url: http://localhost:4000/v2
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-Api-Key
+      x-claw-env: TRADING_API_KEY
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      schema:
+        type: integer
+security:
+  - bearerAuth: []
+paths:
+  /orders/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getOrder
+      summary: Fetch one order
+    delete:
+      operationId: cancelOrder
+      summary: Cancel an order
+      security:
+        - apiKey: []
+  /orders:
+    get:
+      summary: List orders
+      parameters:
+        - $ref: '#/components/parameters/Limit'
+    post:
+      operationId: placeOrder
+      requestBody:
+        required: true
+        content:
+          application/json: {}
+  /health:
+    get:
+      summary: Liveness
+      security: []
+`
+
+func TestParseOpenAPISource(t *testing.T) {
+	cases := []struct {
+		label string
+		want  OpenAPISource
+	}{
+		{"/app/openapi.yaml", OpenAPISource{File: "/app/openapi.yaml"}},
+		{"8080/openapi.json", OpenAPISource{Port: "8080", Path: "/openapi.json"}},
+		{":4000/v2/spec", OpenAPISource{Port: "4000", Path: "/v2/spec"}},
+		{"http://0.0.0.0:9000/docs/openapi.json", OpenAPISource{Port: "9000", Path: "/docs/openapi.json"}},
+	}
+	for _, tc := range cases {
+		got, err := ParseOpenAPISource(tc.label)
+		if err != nil {
+			t.Fatalf("ParseOpenAPISource(%q): %v", tc.label, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ParseOpenAPISource(%q) = %+v, want %+v", tc.label, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "8080", "nope/openapi.json", "70000/openapi.json"} {
+		if _, err := ParseOpenAPISource(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseOpenAPIAuthEnv(t *testing.T) {
+	got, err := ParseOpenAPIAuthEnv("bearerAuth=TRADING_API_TOKEN, FALLBACK_TOKEN")
+	if err != nil {
+		t.Fatalf("ParseOpenAPIAuthEnv: %v", err)
+	}
+	if got["bearerAuth"] != "TRADING_API_TOKEN" || got[""] != "FALLBACK_TOKEN" {
+		t.Fatalf("unexpected auth env %v", got)
+	}
+	if _, err := ParseOpenAPIAuthEnv("=TOKEN"); err == nil {
+		t.Fatal("expected error for empty scheme name")
+	}
+}
+
+func TestGenerateOpenAPIServiceSkill(t *testing.T) {
+	out, err := GenerateOpenAPIServiceSkill("trading-api", OpenAPISource{File: "/app/openapi.yaml"}, []string{"4000"}, []byte(testOpenAPISpec),
+		map[string]string{"bearerAuth": "TRADING_API_TOKEN"})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPIServiceSkill: %v", err)
+	}
+
+	for _, want := range []string{
+		`name: "surface-trading-api"`,
+		"# trading-api (REST service surface)",
+		"Trading API 2.1",
+		"- **Base URL:** `http://trading-api:4000/v2`",
+		"- `apiKey`: API key in the `X-Api-Key` header — credential in `$TRADING_API_KEY`",
+		"- `bearerAuth`: HTTP bearer token in the `Authorization` header — credential in `$TRADING_API_TOKEN`",
+		"- `GET /orders` — List orders\n  - params: `limit` (query, integer)\n  - auth: bearerAuth\n",
+		"- `POST /orders` (`placeOrder`)\n  - body: application/json (required)\n",
+		"- `GET /orders/{id}` — Fetch one order (`getOrder`)\n  - params: `id` (path, string, required)\n",
+		"- `DELETE /orders/{id}` — Cancel an order (`cancelOrder`)\n  - params: `id` (path, string, required)\n  - auth: apiKey\n",
+		"- `GET /health` — Liveness\n  - auth: none\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in skill, got:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "GET /health") > strings.Index(out, "GET /orders") {
+		t.Fatalf("expected endpoints sorted by path, got:\n%s", out)
+	}
+}
+
+func TestGenerateOpenAPIServiceSkillSwagger2(t *testing.T) {
+	spec := `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy", "version": "1"},
+  "basePath": "/api",
+  "securityDefinitions": {"token": {"type": "apiKey", "in": "query", "name": "token"}},
+  "paths": {
+    "/quotes": {
+      "post": {
+        "summary": "Create quote",
+        "consumes": ["application/json"],
+        "parameters": [
+          {"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Quote"}},
+          {"name": "dry_run", "in": "query", "type": "boolean"}
+        ]
+      }
+    }
+  }
+}`
+	out, err := GenerateOpenAPIServiceSkill("legacy", OpenAPISource{Port: "9000", Path: "/swagger.json"}, nil, []byte(spec),
+		map[string]string{"": "LEGACY_TOKEN"})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPIServiceSkill: %v", err)
+	}
+	for _, want := range []string{
+		"- **Base URL:** `http://legacy:9000/api`",
+		"- `token`: API key in the `token` query — credential in `$LEGACY_TOKEN`",
+		"- `POST /quotes` — Create quote\n  - params: `dry_run` (query, boolean)\n  - body: application/json (required)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in skill, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateOpenAPIServiceSkillRejectsNonSpec(t *testing.T) {
+	if _, err := GenerateOpenAPIServiceSkill("x", OpenAPISource{}, nil, []byte(`{"hello": "world"}`), nil); err == nil {
+		t.Fatal("expected error for document without openapi/swagger field")
+	}
+}
+
+func TestGetOpenAPISpec(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openapi.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testOpenAPISpec))
+	}))
+	defer srv.Close()
+
+	spec, err := GetOpenAPISpec(context.Background(), srv.URL+"/openapi.yaml", nil)
+	if err != nil {
+		t.Fatalf("GetOpenAPISpec: %v", err)
+	}
+	if !strings.Contains(string(spec), "Trading API") {
+		t.Fatalf("unexpected spec body: %s", spec)
+	}
+	if _, err := GetOpenAPISpec(context.Background(), srv.URL+"/missing", nil); err == nil {
+		t.Fatal("expected error for 404")
+	}
+}
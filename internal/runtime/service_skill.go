@@ -9,6 +9,7 @@ import (
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/mostlydev/clawdapus/internal/driver"
 	"github.com/mostlydev/clawdapus/internal/skillmd"
 )
 
@@ -40,6 +41,62 @@ func GenerateServiceSkillFallback(target string, ports []string) string {
 	)
 }
 
+// GenerateDescribedServiceSkill renders a service surface skill from an
+// operator-written x-claw.describe block.
+func GenerateDescribedServiceSkill(target string, ports []string, d *driver.ServiceDescription) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("# %s (service surface)\n\n", target))
+	if d.Description != "" {
+		b.WriteString(d.Description + "\n\n")
+	}
+	b.WriteString("## Connection\n")
+	b.WriteString(fmt.Sprintf("- **Hostname:** %s\n", target))
+	b.WriteString("- **Network:** claw-internal (pod-internal, no external access)\n")
+	if len(ports) > 0 {
+		b.WriteString(fmt.Sprintf("- **Ports:** %s\n", strings.Join(ports, ", ")))
+	}
+	if d.Protocol != "" {
+		b.WriteString(fmt.Sprintf("- **Protocol:** %s\n", d.Protocol))
+	}
+
+	if len(d.Auth) > 0 {
+		b.WriteString("\n## Authentication\n")
+		b.WriteString("Credentials are provided via these environment variables:\n")
+		for _, env := range d.Auth {
+			b.WriteString(fmt.Sprintf("- `$%s`\n", env))
+		}
+	}
+
+	if len(d.Operations) > 0 {
+		b.WriteString("\n## Operations\n")
+		for _, op := range d.Operations {
+			line := fmt.Sprintf("- `%s`", op.Name)
+			if op.Description != "" {
+				line += " — " + op.Description
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if len(d.Examples) > 0 {
+		b.WriteString("\n## Examples\n")
+		for _, example := range d.Examples {
+			b.WriteString("\n```\n" + strings.TrimRight(example, "\n") + "\n```\n")
+		}
+	}
+
+	skillID := strings.TrimSpace(strings.ReplaceAll(target, "/", "-"))
+	if skillID == "" {
+		skillID = "unknown"
+	}
+	description := fmt.Sprintf("Connection details and usage for the %s service surface.", target)
+	if d.Protocol != "" {
+		description = fmt.Sprintf("Connection details and usage for the %s %s service surface.", target, d.Protocol)
+	}
+	return skillmd.Format(fmt.Sprintf("surface-%s", skillID), description, b.String())
+}
+
 // ExtractServiceSkill extracts a skill file from a service image using Docker.
 // Creates a temporary container (without starting it), copies the file out,
 // then removes the container.
@@ -3,6 +3,8 @@ package runtime
 import (
 	"strings"
 	"testing"
+
+	"github.com/mostlydev/clawdapus/internal/driver"
 )
 
 func TestGenerateServiceSkillFallbackWithPorts(t *testing.T) {
@@ -55,3 +57,31 @@ func TestGenerateServiceSkillFallbackEmptyPorts(t *testing.T) {
 		t.Error("should not include ports line for empty ports slice")
 	}
 }
+
+func TestGenerateDescribedServiceSkill(t *testing.T) {
+	result := GenerateDescribedServiceSkill("cache", []string{"6379"}, &driver.ServiceDescription{
+		Description: "Shared market-data cache.",
+		Protocol:    "redis",
+		Auth:        []string{"REDIS_PASSWORD"},
+		Examples:    []string{`redis-cli -h cache -a "$REDIS_PASSWORD" GET price:BTC`},
+		Operations: []driver.ServiceOperation{
+			{Name: "GET price:<symbol>", Description: "Latest cached price"},
+			{Name: "PUBLISH alerts <json>"},
+		},
+	})
+
+	for _, want := range []string{
+		`name: "surface-cache"`,
+		`description: "Connection details and usage for the cache redis service surface."`,
+		"# cache (service surface)\n\nShared market-data cache.\n",
+		"**Ports:** 6379",
+		"**Protocol:** redis",
+		"- `$REDIS_PASSWORD`",
+		"- `GET price:<symbol>` — Latest cached price\n- `PUBLISH alerts <json>`\n",
+		"```\nredis-cli -h cache -a \"$REDIS_PASSWORD\" GET price:BTC\n```",
+	} {
+		if !strings.Contains(result, want) {
+			t.Fatalf("expected %q in skill, got:\n%s", want, result)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package secretscan finds credentials pasted into agent-facing artifacts
+// (contracts, includes, skills, persona files) before they are mounted.
+package secretscan
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AllowlistFile is the pod-relative file listing finding fingerprints that
+// are known false positives.
+const AllowlistFile = ".claw-secrets-allow"
+
+// maxScanBytes skips files too large to be hand-written agent content.
+const maxScanBytes = 5 << 20
+
+// Finding is one suspected secret.
+type Finding struct {
+	Path        string
+	Line        int
+	Rule        string
+	Fingerprint string // first 16 hex chars of sha256(match); stable across runs
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s (fingerprint %s)", f.Path, f.Line, f.Rule, f.Fingerprint)
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var rules = []rule{
+	{"anthropic-api-key", regexp.MustCompile(`sk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openrouter-api-key", regexp.MustCompile(`sk-or-v1-[0-9a-f]{32,}`)},
+	{"openai-api-key", regexp.MustCompile(`sk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{32,}`)},
+	{"google-api-key", regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"discord-bot-token", regexp.MustCompile(`\b[MNO][A-Za-z0-9_-]{23,25}\.[A-Za-z0-9_-]{6}\.[A-Za-z0-9_-]{27,38}\b`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`)},
+}
+
+// tokenCandidate matches long opaque words considered for the entropy rule.
+// "/" and "=" split words so paths, model refs and KEY=value stay short.
+var tokenCandidate = regexp.MustCompile(`[A-Za-z0-9+_-]{32,}`)
+
+// minTokenEntropy is the Shannon entropy (bits per char) above which a mixed
+// case alphanumeric word is treated as a generated secret.
+const minTokenEntropy = 4.5
+
+// ScanBytes scans content, reporting findings against displayPath.
+func ScanBytes(displayPath string, content []byte) []Finding {
+	findings := make([]Finding, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), maxScanBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		matched := make(map[string]struct{})
+		for _, r := range rules {
+			for _, m := range r.pattern.FindAllString(text, -1) {
+				if _, dup := matched[m]; dup {
+					continue
+				}
+				matched[m] = struct{}{}
+				findings = append(findings, Finding{Path: displayPath, Line: line, Rule: r.name, Fingerprint: fingerprint(m)})
+			}
+		}
+		for _, m := range tokenCandidate.FindAllString(text, -1) {
+			if coveredBy(m, matched) || !looksGenerated(m) {
+				continue
+			}
+			matched[m] = struct{}{}
+			findings = append(findings, Finding{Path: displayPath, Line: line, Rule: "high-entropy-token", Fingerprint: fingerprint(m)})
+		}
+	}
+	return findings
+}
+
+// ScanPath scans a file, or every text file under a directory. Binary files,
+// files over 5 MiB and .git directories are skipped.
+func ScanPath(path string) ([]Finding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return scanFile(path)
+	}
+
+	findings := make([]Finding, 0)
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fileFindings, err := scanFile(p)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, fileFindings...)
+		return nil
+	})
+	return findings, err
+}
+
+func scanFile(path string) ([]Finding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxScanBytes {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	head := content
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+	return ScanBytes(path, content), nil
+}
+
+// Redact replaces every suspected secret in s with "[redacted]".
+func Redact(s string) string {
+	for _, r := range rules {
+		s = r.pattern.ReplaceAllString(s, redactedText)
+	}
+	return tokenCandidate.ReplaceAllStringFunc(s, func(m string) string {
+		if looksGenerated(m) {
+			return redactedText
+		}
+		return m
+	})
+}
+
+const redactedText = "[redacted]"
+
+// Allowlist holds fingerprints of accepted findings.
+type Allowlist map[string]struct{}
+
+// LoadAllowlist reads one fingerprint per line; blank lines and text after
+// "#" are ignored. A missing file yields an empty allowlist.
+func LoadAllowlist(path string) (Allowlist, error) {
+	allow := make(Allowlist)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return allow, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secret allowlist %q: %w", path, err)
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(line) != 16 || strings.Trim(line, "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("secret allowlist %q line %d: %q is not a 16-char fingerprint", path, i+1, line)
+		}
+		allow[line] = struct{}{}
+	}
+	return allow, nil
+}
+
+// Filter drops allowlisted findings and returns the rest sorted by path/line.
+func (a Allowlist) Filter(findings []Finding) []Finding {
+	out := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		if _, ok := a[f.Fingerprint]; ok {
+			continue
+		}
+		out = append(out, f)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out
+}
+
+func fingerprint(match string) string {
+	sum := sha256.Sum256([]byte(match))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func coveredBy(candidate string, matched map[string]struct{}) bool {
+	for m := range matched {
+		if strings.Contains(candidate, m) || strings.Contains(m, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksGenerated requires upper case, lower case and digits together with high
+// entropy, which rules out hex digests, paths and identifiers in prose.
+func looksGenerated(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && shannonEntropy(s) >= minTokenEntropy
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len(s))
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}